@@ -0,0 +1,114 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+
+	"github.com/spf13/cobra"
+)
+
+// probeTiming is the duration of a probe step
+type probeTiming struct {
+	Step string
+	Took time.Duration
+}
+
+func (p *proberClient) track(step string, start time.Time) {
+	p.timings = append(p.timings, probeTiming{Step: step, Took: time.Since(start)})
+}
+
+var CheckCmd = &cobra.Command{
+	Use:   "check <url>",
+	Short: "Probe a node locally without reporting to the server",
+	Long: `Probe a node locally using the same checks as the probe jobs and print
+the result without reporting it to the server.
+
+Example: xmr-nodes probe check https://node.example.com:18089`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		node, err := parseNodeURL(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		node.ZMQPort, _ = cmd.Flags().GetUint("zmq-port")
+		node.P2PPort, _ = cmd.Flags().GetUint("p2p-port")
+		node.RPCUsername, _ = cmd.Flags().GetString("rpc-username")
+		node.RPCPassword, _ = cmd.Flags().GetString("rpc-password")
+
+		prober := newProber()
+		prober.dryRun = true
+		// the node is given explicitly, only the matching network is accepted
+		prober.acceptTor = node.IsTor
+		prober.acceptI2P = node.IsI2P
+		if node.ZMQPort > 0 {
+			prober.checkZMQ = true
+		}
+		if node.P2PPort > 0 {
+			prober.checkP2P = true
+		}
+		if prober.acceptTor && prober.torSOCKS == "" {
+			fmt.Println(errNoTorSocks)
+			os.Exit(1)
+		}
+		if prober.acceptI2P && prober.I2PSOCKS == "" {
+			fmt.Println(errNoI2PSocks)
+			os.Exit(1)
+		}
+
+		_, err = prober.fetchNode(node)
+		if prober.report != nil {
+			fmt.Println(prettyPrint(prober.report))
+		}
+		fmt.Println("Timings:")
+		for _, t := range prober.timings {
+			fmt.Printf("  %-18s %s\n", t.Step, t.Took.Round(time.Microsecond))
+		}
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+		}
+		if err != nil || prober.report == nil || !prober.report.Node.IsAvailable {
+			os.Exit(1)
+		}
+	},
+}
+
+// parseNodeURL parses `http(s)://hostname[:port]` node URL, port default to
+// 18081 (HTTP) or 18089 (HTTPS)
+func parseNodeURL(rawURL string) (monero.Node, error) {
+	var node monero.Node
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return node, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return node, errors.New("invalid protocol, must one of or HTTP/HTTPS")
+	}
+	if u.Hostname() == "" {
+		return node, errors.New("no hostname was provided")
+	}
+
+	node.Protocol = u.Scheme
+	node.Hostname = u.Hostname()
+	node.Port = 18081
+	if u.Scheme == "https" {
+		node.Port = 18089
+	}
+	if p := u.Port(); p != "" {
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil || port == 0 {
+			return node, errors.New("invalid port number")
+		}
+		node.Port = uint(port)
+	}
+	node.IsTor = strings.HasSuffix(node.Hostname, ".onion")
+	node.IsI2P = strings.HasSuffix(node.Hostname, ".i2p")
+	return node, nil
+}
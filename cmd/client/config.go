@@ -0,0 +1,167 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	defaultTimeout       = 60 * time.Second
+	defaultServerTimeout = 60 * time.Second
+)
+
+// proberConfig is the prober configuration file, see prober.example.toml.
+// Unset global values fall back to the environment variables, unset server
+// values fall back to the global values.
+type proberConfig struct {
+	TorSOCKS       *string `toml:"tor_socks"`
+	I2PSOCKS       *string `toml:"i2p_socks"`
+	AcceptTor      *bool   `toml:"accept_tor"`
+	AcceptI2P      *bool   `toml:"accept_i2p"`
+	AcceptIPv6     *bool   `toml:"accept_ipv6"`
+	CheckZMQ       *bool   `toml:"check_zmq"`
+	CheckP2P       *bool   `toml:"check_p2p"`
+	SOCKSIsolation *bool   `toml:"socks_isolation"`
+	SOCKSRetries   *int    `toml:"socks_retries"`
+	SpoolDir       *string `toml:"spool_dir"`
+	SpoolSize      *int    `toml:"spool_size"`
+
+	Timeout       time.Duration `toml:"timeout"`
+	ServerTimeout time.Duration `toml:"server_timeout"`
+
+	Servers []serverConfig `toml:"server"`
+}
+
+// serverConfig is an upstream server the prober fetches jobs from and
+// reports results to
+type serverConfig struct {
+	Name       string `toml:"name"`
+	Endpoint   string `toml:"endpoint"`
+	APIKey     string `toml:"api_key"`
+	AcceptTor  *bool  `toml:"accept_tor"`
+	AcceptI2P  *bool  `toml:"accept_i2p"`
+	AcceptIPv6 *bool  `toml:"accept_ipv6"`
+	Capacity   *int   `toml:"capacity"` // probes per hour declared to the server
+
+	Timeout       time.Duration `toml:"timeout"`        // node RPC request timeout
+	ServerTimeout time.Duration `toml:"server_timeout"` // fetch job and report request timeout
+}
+
+func setString(dst *string, src *string) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setBool(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setDuration(dst *time.Duration, src time.Duration) {
+	if src > 0 {
+		*dst = src
+	}
+}
+
+// loadProberConfig reads the prober configuration file and returns a prober
+// for each configured server
+func loadProberConfig(path string) ([]*proberClient, error) {
+	var c proberConfig
+	md, err := toml.DecodeFile(path, &c)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown config key %q", undecoded[0].String())
+	}
+	return c.probers(config.AppCfg())
+}
+
+func (c proberConfig) probers(env *config.App) ([]*proberClient, error) {
+	if len(c.Servers) == 0 {
+		return nil, errors.New("no [[server]] was configured")
+	}
+	if c.Timeout < 0 || c.ServerTimeout < 0 {
+		return nil, errors.New("timeout must not be negative")
+	}
+
+	global := &proberClient{
+		acceptTor:      env.AcceptTor,
+		torSOCKS:       env.TorSOCKS,
+		acceptI2P:      env.AcceptI2P,
+		I2PSOCKS:       env.I2PSOCKS,
+		acceptIPv6:     env.IPv6Capable,
+		checkZMQ:       env.CheckZMQ,
+		checkP2P:       env.CheckP2P,
+		socksIsolation: env.SOCKSIsolation,
+		socksRetries:   env.SOCKSRetries,
+		capacity:       env.Capacity,
+		spoolDir:       env.ReportSpoolDir,
+		spoolSize:      env.ReportSpoolSize,
+		timeout:        defaultTimeout,
+		serverTimeout:  defaultServerTimeout,
+	}
+	setString(&global.torSOCKS, c.TorSOCKS)
+	setString(&global.I2PSOCKS, c.I2PSOCKS)
+	setBool(&global.acceptTor, c.AcceptTor)
+	setBool(&global.acceptI2P, c.AcceptI2P)
+	setBool(&global.acceptIPv6, c.AcceptIPv6)
+	setBool(&global.checkZMQ, c.CheckZMQ)
+	setBool(&global.checkP2P, c.CheckP2P)
+	setBool(&global.socksIsolation, c.SOCKSIsolation)
+	if c.SOCKSRetries != nil {
+		if *c.SOCKSRetries < 0 {
+			return nil, errors.New("socks_retries must not be negative")
+		}
+		global.socksRetries = *c.SOCKSRetries
+	}
+	setString(&global.spoolDir, c.SpoolDir)
+	if c.SpoolSize != nil {
+		if *c.SpoolSize < 0 {
+			return nil, errors.New("spool_size must not be negative")
+		}
+		global.spoolSize = *c.SpoolSize
+	}
+	setDuration(&global.timeout, c.Timeout)
+	setDuration(&global.serverTimeout, c.ServerTimeout)
+
+	probers := make([]*proberClient, 0, len(c.Servers))
+	names := make(map[string]bool, len(c.Servers))
+	for i, s := range c.Servers {
+		if s.Name == "" {
+			s.Name = s.Endpoint
+		}
+		if s.Name == "" {
+			return nil, fmt.Errorf("server #%d: no endpoint was provided", i+1)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("server %q: duplicate server name", s.Name)
+		}
+		names[s.Name] = true
+		if s.Timeout < 0 || s.ServerTimeout < 0 {
+			return nil, fmt.Errorf("server %q: timeout must not be negative", s.Name)
+		}
+
+		p := *global
+		p.name = s.Name
+		p.endpoint = s.Endpoint
+		p.apiKey = s.APIKey
+		setBool(&p.acceptTor, s.AcceptTor)
+		setBool(&p.acceptI2P, s.AcceptI2P)
+		setBool(&p.acceptIPv6, s.AcceptIPv6)
+		if s.Capacity != nil {
+			p.capacity = *s.Capacity
+		}
+		setDuration(&p.timeout, s.Timeout)
+		setDuration(&p.serverTimeout, s.ServerTimeout)
+		probers = append(probers, &p)
+	}
+	return probers, nil
+}
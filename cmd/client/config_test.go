@@ -0,0 +1,193 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Single test:
+// go test -race ./cmd/client -run=TestProberConfig_probers -v
+func TestProberConfig_probers(t *testing.T) {
+	env := &config.App{
+		AcceptTor:       true,
+		TorSOCKS:        "127.0.0.1:9050",
+		I2PSOCKS:        "127.0.0.1:4447",
+		IPv6Capable:     true,
+		CheckZMQ:        true,
+		SOCKSIsolation:  true,
+		SOCKSRetries:    1,
+		Capacity:        100,
+		ReportSpoolDir:  "/var/spool/env",
+		ReportSpoolSize: 1000,
+	}
+	// env values without any config override
+	fromEnv := proberClient{
+		acceptTor:      true,
+		torSOCKS:       "127.0.0.1:9050",
+		I2PSOCKS:       "127.0.0.1:4447",
+		acceptIPv6:     true,
+		checkZMQ:       true,
+		socksIsolation: true,
+		socksRetries:   1,
+		capacity:       100,
+		spoolDir:       "/var/spool/env",
+		spoolSize:      1000,
+		timeout:        defaultTimeout,
+		serverTimeout:  defaultServerTimeout,
+	}
+
+	tests := []struct {
+		name    string
+		toml    string
+		want    func() []proberClient
+		wantErr string
+	}{
+		{
+			name: "Environment values",
+			toml: `
+[[server]]
+endpoint = "https://a.example"
+api_key = "key-a"`,
+			want: func() []proberClient {
+				a := fromEnv
+				a.name, a.endpoint, a.apiKey = "https://a.example", "https://a.example", "key-a"
+				return []proberClient{a}
+			},
+		},
+		{
+			name: "Global values override environment, server values override global",
+			toml: `
+tor_socks = "127.0.0.1:9150"
+accept_tor = false
+accept_i2p = true
+check_p2p = true
+socks_isolation = false
+socks_retries = 3
+spool_dir = "/var/spool/global"
+spool_size = 10
+timeout = "30s"
+server_timeout = "20s"
+
+[[server]]
+name = "a"
+endpoint = "https://a.example"
+api_key = "key-a"
+
+[[server]]
+name = "b"
+endpoint = "https://b.example"
+api_key = "key-b"
+accept_tor = true
+accept_ipv6 = false
+capacity = 0
+timeout = "5s"`,
+			want: func() []proberClient {
+				global := fromEnv
+				global.torSOCKS = "127.0.0.1:9150"
+				global.acceptTor = false
+				global.acceptI2P = true
+				global.checkP2P = true
+				global.socksIsolation = false
+				global.socksRetries = 3
+				global.spoolDir = "/var/spool/global"
+				global.spoolSize = 10
+				global.timeout = 30 * time.Second
+				global.serverTimeout = 20 * time.Second
+
+				a := global
+				a.name, a.endpoint, a.apiKey = "a", "https://a.example", "key-a"
+				b := global
+				b.name, b.endpoint, b.apiKey = "b", "https://b.example", "key-b"
+				b.acceptTor = true
+				b.acceptIPv6 = false
+				b.capacity = 0
+				b.timeout = 5 * time.Second
+				return []proberClient{a, b}
+			},
+		},
+		{
+			name:    "No server",
+			toml:    `accept_tor = true`,
+			wantErr: "no [[server]] was configured",
+		},
+		{
+			name:    "Server without endpoint",
+			toml:    "[[server]]\napi_key = \"key\"",
+			wantErr: "server #1: no endpoint was provided",
+		},
+		{
+			name: "Duplicate server name",
+			toml: `
+[[server]]
+name = "a"
+endpoint = "https://a.example"
+
+[[server]]
+name = "a"
+endpoint = "https://b.example"`,
+			wantErr: `server "a": duplicate server name`,
+		},
+		{
+			name: "Duplicate endpoint without name",
+			toml: `
+[[server]]
+endpoint = "https://a.example"
+
+[[server]]
+endpoint = "https://a.example"`,
+			wantErr: `server "https://a.example": duplicate server name`,
+		},
+		{
+			name:    "Negative global timeout",
+			toml:    "timeout = \"-1s\"\n[[server]]\nendpoint = \"https://a.example\"",
+			wantErr: "timeout must not be negative",
+		},
+		{
+			name:    "Negative server timeout",
+			toml:    "[[server]]\nendpoint = \"https://a.example\"\nserver_timeout = \"-1s\"",
+			wantErr: `server "https://a.example": timeout must not be negative`,
+		},
+		{
+			name:    "Negative socks_retries",
+			toml:    "socks_retries = -1\n[[server]]\nendpoint = \"https://a.example\"",
+			wantErr: "socks_retries must not be negative",
+		},
+		{
+			name:    "Negative spool_size",
+			toml:    "spool_size = -1\n[[server]]\nendpoint = \"https://a.example\"",
+			wantErr: "spool_size must not be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c proberConfig
+			if _, err := toml.Decode(tt.toml, &c); err != nil {
+				t.Fatal(err)
+			}
+			probers, err := c.probers(env)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("proberConfig.probers() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want()
+			if len(probers) != len(want) {
+				t.Fatalf("proberConfig.probers() returned %d probers, want %d", len(probers), len(want))
+			}
+			for i, p := range probers {
+				if !reflect.DeepEqual(*p, want[i]) {
+					t.Errorf("proberConfig.probers()[%d] = %+v, want %+v", i, *p, want[i])
+				}
+			}
+		})
+	}
+}
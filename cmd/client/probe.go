@@ -3,8 +3,6 @@ package client
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,14 +11,16 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+	"github.com/ditatompel/xmr-remote-nodes/internal/digest"
 	"github.com/ditatompel/xmr-remote-nodes/internal/ip"
 	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+	"github.com/ditatompel/xmr-remote-nodes/internal/portcheck"
 
 	"github.com/spf13/cobra"
-	"golang.org/x/net/proxy"
 )
 
 const RPCUserAgent = "ditatombot/0.0.3 (Monero RPC Monitoring; https://github.com/ditatompel/xmr-remote-nodes)"
@@ -40,6 +40,7 @@ func (err errProber) Error() string {
 }
 
 type proberClient struct {
+	name       string // server name in the prober config file
 	endpoint   string // server endpoint
 	apiKey     string // prober api key
 	acceptTor  bool   // accept tor
@@ -47,7 +48,40 @@ type proberClient struct {
 	acceptI2P  bool   // accept i2p
 	I2PSOCKS   string // IP:Port of i2p socks
 	acceptIPv6 bool   // accept ipv6
-	message    string // message to include when reporting back to server
+	checkZMQ   bool   // check ZMQ pub port
+	checkP2P   bool   // check P2P port
+
+	socksIsolation bool   // isolate Tor circuits per probed node
+	socksRetries   int    // retries on Tor circuit or SOCKS proxy failure
+	capacity       int    // probes per hour declared to the server
+	message        string // message to include when reporting back to server
+
+	timeout       time.Duration // node RPC request timeout
+	serverTimeout time.Duration // fetch job and report request timeout
+	spoolDir      string        // failed reports spool, see reportSpool
+	spoolSize     int           // maximum number of spooled reports
+
+	// dry run keeps the probe report in report instead of sending it to the
+	// server, see CheckCmd
+	dryRun bool
+	report *monero.ProbeReport
+
+	timings []probeTiming // duration of each probe step
+
+	// duration of the successful get_info request attempt, zero if the node
+	// did not respond
+	rpcTime time.Duration
+
+	// one of monero.FailureCodes to include when reporting back to server
+	failureCode string
+
+	// ZMQ pub and P2P port check result to include when reporting back to
+	// server, nil if no port is checked
+	portCheck *monero.PortCheck
+
+	// TLS certificate details of HTTPS node to include when reporting back
+	// to server, nil if no TLS handshake was made
+	tlsInfo *monero.TLSInfo
 }
 
 func newProber() *proberClient {
@@ -60,6 +94,16 @@ func newProber() *proberClient {
 		acceptI2P:  cfg.AcceptI2P,
 		I2PSOCKS:   cfg.I2PSOCKS,
 		acceptIPv6: cfg.IPv6Capable,
+		checkZMQ:   cfg.CheckZMQ,
+		checkP2P:   cfg.CheckP2P,
+
+		socksIsolation: cfg.SOCKSIsolation,
+		socksRetries:   cfg.SOCKSRetries,
+		capacity:       cfg.Capacity,
+		timeout:        defaultTimeout,
+		serverTimeout:  defaultServerTimeout,
+		spoolDir:       cfg.ReportSpoolDir,
+		spoolSize:      cfg.ReportSpoolSize,
 	}
 }
 
@@ -67,29 +111,56 @@ var ProbeCmd = &cobra.Command{
 	Use:   "probe",
 	Short: "Probe remote nodes",
 	Run: func(cmd *cobra.Command, args []string) {
-		prober := newProber()
-		if e, _ := cmd.Flags().GetString("endpoint"); e != "" {
-			prober.SetEndpoint(e)
-		}
-		if t, _ := cmd.Flags().GetBool("no-tor"); t {
-			prober.SetAcceptTor(false)
-		}
-		if t, _ := cmd.Flags().GetBool("no-i2p"); t {
-			prober.SetAcceptI2P(false)
+		probers := []*proberClient{newProber()}
+		if path, _ := cmd.Flags().GetString("prober-config"); path != "" {
+			var err error
+			if probers, err = loadProberConfig(path); err != nil {
+				slog.Error(fmt.Sprintf("[PROBE] %s: %s", path, err.Error()))
+				os.Exit(1)
+			}
 		}
 
-		if err := prober.Run(); err != nil {
-			switch err.(type) {
-			case errProber:
-				slog.Error(fmt.Sprintf("[PROBE] %s", err.Error()))
-				os.Exit(1)
-			default:
-				slog.Warn(fmt.Sprintf("[PROBE] %s", err.Error()))
+		failed := false
+		for _, prober := range probers {
+			if prober.name != "" {
+				slog.Info(fmt.Sprintf("[PROBE] Probing for server %s", prober.name))
+			} else if e, _ := cmd.Flags().GetString("endpoint"); e != "" {
+				prober.SetEndpoint(e)
+			}
+			if t, _ := cmd.Flags().GetBool("no-tor"); t {
+				prober.SetAcceptTor(false)
 			}
+			if t, _ := cmd.Flags().GetBool("no-i2p"); t {
+				prober.SetAcceptI2P(false)
+			}
+
+			// an unreachable or misconfigured server must not prevent
+			// probing for the other servers
+			if err := prober.Run(); err != nil {
+				switch err.(type) {
+				case errProber:
+					slog.Error(fmt.Sprintf("[PROBE] %s%s", prober.logPrefix(), err.Error()))
+					failed = true
+				default:
+					slog.Warn(fmt.Sprintf("[PROBE] %s%s", prober.logPrefix(), err.Error()))
+				}
+			}
+		}
+		if failed {
+			os.Exit(1)
 		}
 	},
 }
 
+// logPrefix returns the server name to prefix log messages with when the
+// prober reports to multiple servers
+func (p *proberClient) logPrefix() string {
+	if p.name == "" {
+		return ""
+	}
+	return fmt.Sprintf("[%s] ", p.name)
+}
+
 func (p *proberClient) SetEndpoint(endpoint string) {
 	p.endpoint = endpoint
 }
@@ -112,6 +183,10 @@ func (p *proberClient) Run() error {
 		return err
 	}
 
+	if err := p.replaySpool(); err != nil {
+		slog.Warn(fmt.Sprintf("[PROBE] Failed to replay spooled reports: %s", err))
+	}
+
 	node, err := p.fetchJob()
 	if err != nil {
 		return err
@@ -162,7 +237,7 @@ func (p *proberClient) fetchJob() (monero.Node, error) {
 
 	var node monero.Node
 
-	uri := fmt.Sprintf("%s/api/v1/job?accept_tor=%d&accept_i2p=%d&accept_ipv6=%d", p.endpoint, acceptTor, acceptI2P, acceptIPv6)
+	uri := fmt.Sprintf("%s/api/v1/job?accept_tor=%d&accept_i2p=%d&accept_ipv6=%d&capacity=%d", p.endpoint, acceptTor, acceptI2P, acceptIPv6, p.capacity)
 	slog.Info(fmt.Sprintf("[PROBE] Getting node from %s", uri))
 
 	req, err := http.NewRequest(http.MethodGet, uri, nil)
@@ -173,7 +248,7 @@ func (p *proberClient) fetchJob() (monero.Node, error) {
 	req.Header.Add(monero.ProberAPIKey, p.apiKey)
 	req.Header.Set("User-Agent", RPCUserAgent)
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: p.serverTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return node, err
@@ -190,13 +265,18 @@ func (p *proberClient) fetchJob() (monero.Node, error) {
 	}
 
 	response := struct {
-		Data monero.Node `json:"data"`
+		Message string      `json:"message"`
+		Data    monero.Node `json:"data"`
 	}{}
 
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil {
 		return node, err
 	}
+	if response.Data.ID == 0 {
+		// e.g. no node is due for probing
+		return node, errors.New(response.Message)
+	}
 
 	node = response.Data
 	slog.Info(fmt.Sprintf("[PROBE] Got node: %s://%s:%d", node.Protocol, node.Hostname, node.Port))
@@ -211,78 +291,70 @@ func (p *proberClient) fetchNode(node monero.Node) (monero.Node, error) {
 	slog.Info(fmt.Sprintf("[PROBE] Fetching node info from %s", endpoint))
 	slog.Debug(fmt.Sprintf("[PROBE] RPC param: %s", string(rpcParam)))
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(rpcParam))
-	if err != nil {
-		return node, err
+	if node.RPCUsername != "" {
+		node.LoginRequired = true
 	}
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
-	req.Header.Set("User-Agent", RPCUserAgent)
-	req.Header.Set("Origin", "https://xmr.ditatompel.com")
 
-	sslIsInvalid := false
-
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		VerifyConnection: func(c tls.ConnectionState) error {
-			opts := x509.VerifyOptions{
-				DNSName:       c.ServerName,
-				Intermediates: x509.NewCertPool(),
-			}
-			for _, cert := range c.PeerCertificates[1:] {
-				opts.Intermediates.AddCert(cert)
-			}
-			_, err := c.PeerCertificates[0].Verify(opts)
-			if err != nil {
-				// if verification fails, mark as invalid but do not terminate the connection
-				sslIsInvalid = true
-			}
-			return nil
-		},
-	}
-
-	transport := &http.Transport{
-		TLSClientConfig:   tlsConfig,
-		DisableKeepAlives: true,
-	}
-
-	var socks5 string
-
-	if p.acceptTor && node.IsTor {
-		socks5 = p.torSOCKS
-	} else if p.acceptI2P && node.IsI2P {
-		socks5 = p.I2PSOCKS
-	}
-
-	if socks5 != "" {
-		dialer, err := proxy.SOCKS5("tcp", socks5, nil, proxy.Direct)
+	// reset the default node struct
+	node.IsAvailable = false
+	p.portCheck = nil
+	p.tlsInfo = nil
+	p.failureCode = ""
+	p.timings = nil
+	p.rpcTime = 0
+
+	var (
+		client http.Client
+		dialFn portcheck.DialFunc
+		resp   *http.Response
+	)
+	stepTime := time.Now()
+	for attempt := 0; ; attempt++ {
+		attemptTime := time.Now()
+		var err error
+		client, dialFn, err = p.newRPCClient(node, attempt)
 		if err != nil {
 			return node, err
 		}
-		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return dialer.Dial(network, addr)
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(rpcParam))
+		if err != nil {
+			return node, err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		req.Header.Set("User-Agent", RPCUserAgent)
+		req.Header.Set("Origin", "https://xmr.ditatompel.com")
+
+		resp, err = client.Do(req)
+		if err == nil {
+			p.rpcTime = time.Since(attemptTime)
+			p.message = ""
+			p.failureCode = ""
+			break
 		}
-	}
-
-	client := http.Client{
-		Transport: transport,
-		Timeout:   60 * time.Second,
-	}
-
-	// reset the default node struct
-	node.IsAvailable = false
-
-	resp, err := client.Do(req)
-	if err != nil {
 		p.message = err.Error()
-		if err := p.reportResult(node, time.Since(startTime).Seconds()); err != nil {
+		p.failureCode = monero.ClassifyFailure(err)
+		if dialFn == nil || attempt >= p.socksRetries || !retryableFailure(p.failureCode) {
+			p.track("get_info", stepTime)
+			if err := p.reportResult(node, time.Since(startTime).Seconds()); err != nil {
+				return node, err
+			}
 			return node, err
 		}
-		return node, err
+		slog.Warn(fmt.Sprintf("[PROBE] %s: %s, retrying (%d/%d)", p.failureCode, err, attempt+1, p.socksRetries))
 	}
 	defer resp.Body.Close()
+	p.track("get_info", stepTime)
 
 	if resp.StatusCode != 200 {
 		p.message = fmt.Sprintf("status code: %d", resp.StatusCode)
+		p.failureCode = monero.FailureHTTPStatus
+		if digest.IsChallenge(resp) {
+			node.LoginRequired = true
+			if node.RPCUsername == "" {
+				p.message = "RPC login required"
+				p.failureCode = monero.FailureLoginRequired
+			}
+		}
 		if err := p.reportResult(node, time.Since(startTime).Seconds()); err != nil {
 			return node, err
 		}
@@ -292,6 +364,7 @@ func (p *proberClient) fetchNode(node monero.Node) (monero.Node, error) {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		p.message = err.Error()
+		p.failureCode = monero.ClassifyFailure(err)
 		if err := p.reportResult(node, time.Since(startTime).Seconds()); err != nil {
 			return node, err
 		}
@@ -304,12 +377,17 @@ func (p *proberClient) fetchNode(node monero.Node) (monero.Node, error) {
 
 	if err := json.Unmarshal(body, &reportNode); err != nil {
 		p.message = err.Error()
+		p.failureCode = monero.FailureJSONDecode
 		if err := p.reportResult(node, time.Since(startTime).Seconds()); err != nil {
 			return node, err
 		}
+		return node, err
 	}
 	if reportNode.Status == "OK" {
 		node.IsAvailable = true
+	} else {
+		p.message = fmt.Sprintf("RPC status: %q", reportNode.Status)
+		p.failureCode = monero.FailureRPCStatus
 	}
 	node.Nettype = reportNode.Nettype
 	node.AdjustedTime = reportNode.AdjustedTime
@@ -318,7 +396,8 @@ func (p *proberClient) fetchNode(node monero.Node) (monero.Node, error) {
 	node.Height = reportNode.Height
 	node.Version = reportNode.Version
 
-	if !sslIsInvalid && (resp.Header.Get("Access-Control-Allow-Origin") == "*" || resp.Header.Get("Access-Control-Allow-Origin") == "https://xmr.ditatompel.com") {
+	tlsIsValid := p.tlsInfo == nil || p.tlsInfo.IsValid
+	if tlsIsValid && (resp.Header.Get("Access-Control-Allow-Origin") == "*" || resp.Header.Get("Access-Control-Allow-Origin") == "https://xmr.ditatompel.com") {
 		node.CORSCapable = true
 	}
 
@@ -335,12 +414,30 @@ func (p *proberClient) fetchNode(node monero.Node) (monero.Node, error) {
 	// time.Sleep(1 * time.Second)
 
 	// check fee
+	stepTime = time.Now()
 	fee, err := p.fetchFee(client, endpoint)
+	p.track("get_fee_estimate", stepTime)
 	if err != nil {
 		return node, err
 	}
 	node.EstimateFee = fee
 
+	// hard fork info is optional, nodes may not allow the RPC method
+	stepTime = time.Now()
+	if hf, err := p.fetchHardForkInfo(client, endpoint); err != nil {
+		slog.Warn(fmt.Sprintf("[PROBE] Failed to fetch hard fork info: %s", err))
+	} else {
+		node.HFVersion = hf.Version
+		node.HFVoting = hf.Voting
+	}
+	p.track("hard_fork_info", stepTime)
+
+	if node.IsAvailable && (p.checkZMQ || p.checkP2P) {
+		stepTime = time.Now()
+		p.portCheck = p.checkPorts(node, dialFn)
+		p.track("port_check", stepTime)
+	}
+
 	tookTime := time.Since(startTime).Seconds()
 
 	slog.Info(fmt.Sprintf("[PROBE] Took %f seconds", tookTime))
@@ -350,6 +447,32 @@ func (p *proberClient) fetchNode(node monero.Node) (monero.Node, error) {
 	return node, nil
 }
 
+// checkPorts checks ZMQ pub and P2P ports of the node. The node nettype must
+// be known to check the nettype default ports.
+func (p *proberClient) checkPorts(node monero.Node, dialFn portcheck.DialFunc) *monero.PortCheck {
+	pc := &monero.PortCheck{ZMQ: 2, P2P: 2}
+	check := func(name string, port uint, fn func(context.Context, portcheck.DialFunc, string) error) int {
+		if port == 0 {
+			return 2
+		}
+		addr := net.JoinHostPort(node.Hostname, strconv.Itoa(int(port)))
+		if err := fn(context.Background(), dialFn, addr); err != nil {
+			slog.Info(fmt.Sprintf("[PROBE] %s port %s check failed: %s", name, addr, err))
+			return 0
+		}
+		slog.Info(fmt.Sprintf("[PROBE] %s port %s is reachable", name, addr))
+		return 1
+	}
+
+	if p.checkZMQ {
+		pc.ZMQ = check("ZMQ", node.ZMQEndpointPort(), portcheck.ZMQ)
+	}
+	if p.checkP2P {
+		pc.P2P = check("P2P", node.P2PEndpointPort(), portcheck.P2P)
+	}
+	return pc
+}
+
 // get estimate fee from remote node
 func (p *proberClient) fetchFee(client http.Client, endpoint string) (uint, error) {
 	rpcParam := []byte(`{"jsonrpc": "2.0","id": "0","method": "get_fee_estimate"}`)
@@ -388,23 +511,115 @@ func (p *proberClient) fetchFee(client http.Client, endpoint string) (uint, erro
 	return f.Result.Fee, nil
 }
 
+// hardForkInfo is the `hard_fork_info` RPC result
+type hardForkInfo struct {
+	Version uint   `json:"version"` // hard fork version the node follows
+	Voting  uint   `json:"voting"`  // hard fork version the node votes for
+	Status  string `json:"status"`
+}
+
+// get hard fork version and voting from remote node
+func (p *proberClient) fetchHardForkInfo(client http.Client, endpoint string) (hardForkInfo, error) {
+	var hf hardForkInfo
+	rpcParam := []byte(`{"jsonrpc": "2.0","id": "0","method": "hard_fork_info"}`)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(rpcParam))
+	if err != nil {
+		return hf, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("User-Agent", RPCUserAgent)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return hf, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return hf, fmt.Errorf("status code: %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return hf, err
+	}
+
+	f := struct {
+		Result hardForkInfo `json:"result"`
+	}{}
+	if err := json.Unmarshal(body, &f); err != nil {
+		return hf, err
+	}
+	if f.Result.Status != "OK" {
+		return hf, fmt.Errorf("status: %q", f.Result.Status)
+	}
+
+	return f.Result, nil
+}
+
 func (p *proberClient) reportResult(node monero.Node, tookTime float64) error {
+	checkedAt := time.Now()
+	// never send the credentials back
+	node.RPCUsername = ""
+	node.RPCPassword = ""
+
 	if !node.IsTor && !node.IsI2P {
+		stepTime := time.Now()
 		if hostIps, err := net.LookupIP(node.Hostname); err == nil {
 			node.IPv6Only = ip.IsIPv6Only(hostIps)
 			node.IPAddresses = ip.SliceToString(hostIps)
 		}
+		p.track("ip_lookup", stepTime)
 	}
 
-	jsonData, err := json.Marshal(monero.ProbeReport{
-		TookTime: tookTime,
-		Message:  p.message,
-		Node:     node,
-	})
+	report := monero.ProbeReport{
+		TookTime:    tookTime,
+		RPCTime:     p.rpcTime.Seconds(),
+		Message:     p.message,
+		FailureCode: p.failureCode,
+		Node:        node,
+		PortCheck:   p.portCheck,
+		TLS:         p.tlsInfo,
+	}
+	if p.dryRun {
+		p.report = &report
+		return nil
+	}
+
+	jsonData, err := json.Marshal(report)
 	if err != nil {
 		return err
 	}
 
+	err = p.postReport(jsonData)
+	switch err.(type) {
+	case nil, errRejected, errProber:
+		return err
+	}
+	// server down or network failure, keep the report to replay it later.
+	// Only replayed reports carry checked_at, live reports use the server
+	// time, so prober clock skew doesn't matter.
+	if s := p.spool(); s != nil {
+		report.CheckedAt = checkedAt.Unix()
+		if errSpool := s.push(report); errSpool != nil {
+			slog.Error(fmt.Sprintf("[PROBE] Failed to spool report: %s", errSpool))
+		} else {
+			slog.Warn(fmt.Sprintf("[PROBE] Report spooled to %s", s.dir))
+		}
+	}
+	return err
+}
+
+// errRejected is returned when the server refuses the report, sending it
+// again will not succeed
+type errRejected string
+
+func (err errRejected) Error() string {
+	return string(err)
+}
+
+// postReport sends JSON encoded monero.ProbeReport to the server
+func (p *proberClient) postReport(jsonData []byte) error {
 	endpoint := fmt.Sprintf("%s/api/v1/job", p.endpoint)
 	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
@@ -414,17 +629,22 @@ func (p *proberClient) reportResult(node monero.Node, tookTime float64) error {
 	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
 	req.Header.Set("User-Agent", RPCUserAgent)
 
-	client := &http.Client{Timeout: 60 * time.Second}
+	client := &http.Client{Timeout: p.serverTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("status code: %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode == 200:
+		return nil
+	case resp.StatusCode == 401:
+		return errInvalidCredentials
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 429:
+		return errRejected(fmt.Sprintf("report rejected, status code: %d", resp.StatusCode))
 	}
-	return nil
+	return fmt.Errorf("status code: %d", resp.StatusCode)
 }
 
 // for debug purposes
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/digest"
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+	"github.com/ditatompel/xmr-remote-nodes/internal/portcheck"
+
+	"golang.org/x/net/proxy"
+)
+
+// retryableFailures are failures caused by a bad Tor circuit or overloaded
+// SOCKS proxy, worth retrying through a new circuit or the next endpoint
+var retryableFailures = []string{
+	monero.FailureSOCKSUnreachable,
+	monero.FailureSOCKSError,
+	monero.FailureCircuitFailed,
+	monero.FailureTTLExpired,
+	monero.FailureHostUnreachable,
+	monero.FailureTimeout,
+}
+
+func retryableFailure(code string) bool {
+	return slices.Contains(retryableFailures, code)
+}
+
+// splitEndpoints splits comma separated SOCKS endpoints
+func splitEndpoints(s string) []string {
+	var endpoints []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// socksEndpoint returns SOCKS proxy endpoint to probe the node, empty for
+// clearnet nodes. Nodes are spread over the endpoint pool by their ID, each
+// retry attempt uses the next endpoint.
+func (p *proberClient) socksEndpoint(node monero.Node, attempt int) string {
+	var pool []string
+	if p.acceptTor && node.IsTor {
+		pool = splitEndpoints(p.torSOCKS)
+	} else if p.acceptI2P && node.IsI2P {
+		pool = splitEndpoints(p.I2PSOCKS)
+	}
+	if len(pool) == 0 {
+		return ""
+	}
+	return pool[(int(node.ID)+attempt)%len(pool)]
+}
+
+// isolationAuth returns SOCKS credentials to isolate Tor streams per node.
+// Tor (IsolateSOCKSAuth, enabled by default) doesn't share circuits between
+// streams with different credentials, the attempt number changes the
+// password to build a new circuit on retry.
+func isolationAuth(node monero.Node, attempt int) *proxy.Auth {
+	return &proxy.Auth{
+		User:     fmt.Sprintf("xmr-nodes-%d", node.ID),
+		Password: fmt.Sprintf("attempt-%d", attempt),
+	}
+}
+
+// newRPCClient returns HTTP client to probe the node RPC and dial function
+// to use for the node ports, nil for clearnet nodes
+func (p *proberClient) newRPCClient(node monero.Node, attempt int) (http.Client, portcheck.DialFunc, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyConnection: func(c tls.ConnectionState) error {
+			// if verification fails, the certificate is reported as
+			// invalid but do not terminate the connection
+			p.tlsInfo = monero.InspectTLS(c, nil)
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+	}
+
+	var dialFn portcheck.DialFunc
+	if socks5 := p.socksEndpoint(node, attempt); socks5 != "" {
+		var auth *proxy.Auth
+		// I2P SOCKS proxies may not support username/password method
+		if p.socksIsolation && node.IsTor {
+			auth = isolationAuth(node, attempt)
+		}
+		dialer, err := proxy.SOCKS5("tcp", socks5, auth, proxy.Direct)
+		if err != nil {
+			return http.Client{}, nil, err
+		}
+		dialFn = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if d, ok := dialer.(proxy.ContextDialer); ok {
+				return d.DialContext(ctx, network, addr)
+			}
+			return dialer.Dial(network, addr)
+		}
+		transport.DialContext = dialFn
+	}
+
+	var rt http.RoundTripper = transport
+	if node.RPCUsername != "" {
+		// node behind `--rpc-login`, credentials are shared by the operator
+		rt = &digest.Transport{
+			Username: node.RPCUsername,
+			Password: node.RPCPassword,
+			Base:     transport,
+		}
+	}
+
+	client := http.Client{
+		Transport: rt,
+		Timeout:   p.timeout,
+	}
+	return client, dialFn, nil
+}
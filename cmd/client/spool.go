@@ -0,0 +1,135 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+)
+
+// reportSpool is on-disk queue of probe reports that could not be sent to
+// the server. Each report is stored as a JSON file named by its checked_at
+// time, so the file names sort in probe order.
+type reportSpool struct {
+	dir  string
+	size int // maximum number of spooled reports
+}
+
+var unsafePathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// spool returns the report spool of the server endpoint, nil if spooling is
+// disabled
+func (p *proberClient) spool() *reportSpool {
+	if p.spoolSize <= 0 || p.dryRun {
+		return nil
+	}
+	dir := p.spoolDir
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			slog.Warn(fmt.Sprintf("[PROBE] Report spooling disabled: %s", err))
+			return nil
+		}
+		dir = filepath.Join(cacheDir, "xmr-nodes", "spool")
+	}
+	// one queue per server, the prober may report to multiple servers
+	server := strings.Trim(unsafePathChars.ReplaceAllString(p.endpoint, "_"), "_")
+	return &reportSpool{dir: filepath.Join(dir, server), size: p.spoolSize}
+}
+
+// push adds the report to the spool, the oldest reports are dropped when the
+// spool is full
+func (s *reportSpool) push(report monero.ProbeReport) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%d.json", report.CheckedAt, report.Node.ID)
+	// write to temporary file first so a partially written report is
+	// never replayed
+	tmp := filepath.Join(s.dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for len(files) > s.size {
+		slog.Warn(fmt.Sprintf("[PROBE] Report spool is full, dropping %s", files[0]))
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// files returns the spooled report files, oldest first
+func (s *reportSpool) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(files, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+	return files, nil
+}
+
+// replaySpool sends the spooled reports to the server in probe order. It
+// stops at the first report that fails to send, the remaining reports are
+// kept for the next run.
+func (p *proberClient) replaySpool() error {
+	s := p.spool()
+	if s == nil {
+		return nil
+	}
+	files, err := s.files()
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	slog.Info(fmt.Sprintf("[PROBE] Replaying %d spooled reports", len(files)))
+
+	minCheckedAt := time.Now().Add(-monero.ReportMaxAge).Unix()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var report monero.ProbeReport
+		if err := json.Unmarshal(data, &report); err != nil || report.CheckedAt < minCheckedAt {
+			// the server rejects reports older than monero.ReportMaxAge
+			slog.Warn(fmt.Sprintf("[PROBE] Dropping stale or invalid spooled report %s", file))
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := p.postReport(data); err != nil {
+			if _, rejected := err.(errRejected); !rejected {
+				return err
+			}
+			slog.Warn(fmt.Sprintf("[PROBE] Spooled report %s rejected: %s", file, err))
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -28,9 +28,15 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	Root.PersistentFlags().StringVarP(&configFile, "config-file", "c", "", "Default to .env")
 	Root.AddCommand(client.ProbeCmd)
-	client.ProbeCmd.Flags().StringP("endpoint", "e", "", "Server endpoint")
+	client.ProbeCmd.Flags().StringP("endpoint", "e", "", "Server endpoint, ignored with --prober-config")
+	client.ProbeCmd.Flags().StringP("prober-config", "p", "", "Prober TOML config file with multiple servers")
 	client.ProbeCmd.Flags().Bool("no-tor", false, "Do not probe tor nodes")
 	client.ProbeCmd.Flags().Bool("no-i2p", false, "Do not probe i2p nodes")
+	client.ProbeCmd.AddCommand(client.CheckCmd)
+	client.CheckCmd.Flags().Uint("zmq-port", 0, "Check ZMQ pub port")
+	client.CheckCmd.Flags().Uint("p2p-port", 0, "Check P2P port")
+	client.CheckCmd.Flags().StringP("rpc-username", "u", "", "Node RPC login username")
+	client.CheckCmd.Flags().StringP("rpc-password", "p", "", "Node RPC login password")
 }
 
 func initConfig() {
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/admin"
+	"github.com/ditatompel/xmr-remote-nodes/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "[Server] Add, delete, and show admin console users",
+	Long: `Command to administer users of the admin web console (/admin).
+
+This command should only be run on the server which directly connect to the MySQL database.
+	`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var listAdminCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print admin users",
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		admins, err := admin.New().Admins()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(admins) == 0 {
+			fmt.Println("No admin users found")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintf(w, "ID\t| Username\t| Last Login\t| Created\n")
+		for _, a := range admins {
+			lastLogin := "never"
+			if a.LastLoginTS > 0 {
+				lastLogin = time.Unix(a.LastLoginTS, 0).Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t| %s\t| %s\t| %s\n",
+				a.ID,
+				a.Username,
+				lastLogin,
+				time.Unix(a.DateCreated, 0).Format(time.RFC3339),
+			)
+		}
+		w.Flush()
+	},
+}
+
+var addAdminCmd = &cobra.Command{
+	Use:   "add [username]",
+	Short: "Add new admin user",
+	Long: `Create new admin user identified by [username] (if provided).
+
+The password must be at least 12 characters. This command will display the
+TOTP secret and provisioning URI which must be added to an authenticator app,
+the TOTP code is required to log in to the admin console.`,
+	Run: func(_ *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		username := ""
+		if len(args) > 0 {
+			username = strings.Join(args, " ")
+		} else {
+			username = stringPrompt("Username:")
+		}
+		password := stringPrompt("Password:")
+
+		a, err := admin.New().Add(username, password)
+		if err != nil {
+			fmt.Println("Failed to add admin user:", err)
+			return
+		}
+
+		fmt.Printf("Username: %s\nTOTP Secret: %s\nTOTP URI: %s\n",
+			a.Username,
+			a.TOTPSecret,
+			admin.OTPAuthURI(a.Username, a.TOTPSecret),
+		)
+	},
+}
+
+var deleteAdminCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete admin user",
+	Long:  `Delete admin user identified by id.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		adminID, err := strconv.ParseInt(stringPrompt("Admin ID:"), 10, 64)
+		if err != nil {
+			fmt.Println("Invalid ID:", err)
+			return
+		}
+
+		if err := admin.New().Delete(adminID); err != nil {
+			fmt.Println("Failed to delete admin user:", err)
+			return
+		}
+
+		fmt.Printf("Admin ID %d deleted\n", adminID)
+	},
+}
+
+var resetTOTPAdminCmd = &cobra.Command{
+	Use:   "reset-totp",
+	Short: "Generate new TOTP secret for admin user",
+	Long: `Generate new TOTP secret for admin user identified by id.
+
+The previous TOTP secret will no longer be accepted and existing sessions of
+the admin user are logged out.`,
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		adminID, err := strconv.ParseInt(stringPrompt("Admin ID:"), 10, 64)
+		if err != nil {
+			fmt.Println("Invalid ID:", err)
+			return
+		}
+
+		repo := admin.New()
+		secret, err := repo.ResetTOTP(adminID)
+		if err != nil {
+			fmt.Println("Failed to reset TOTP secret:", err)
+			return
+		}
+		a, err := repo.Admin(adminID)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		fmt.Printf("Username: %s\nTOTP Secret: %s\nTOTP URI: %s\n",
+			a.Username,
+			secret,
+			admin.OTPAuthURI(a.Username, secret),
+		)
+	},
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/database"
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+
+	"github.com/spf13/cobra"
+)
+
+var banListCmd = &cobra.Command{
+	Use:   "banlist",
+	Short: "[Server] Inspect stored ban list",
+	Long: `Command to inspect the stored IP addresses ban list and its sources.
+
+This command should only be run on the server which directly connect to the MySQL database.
+	`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var showBanListCmd = &cobra.Command{
+	Use:   "show [source]",
+	Short: "Print ban list entries",
+	Long: `Print ban list entries along with their source.
+
+Use [source] args to only show entries from the given source.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		source := ""
+		if len(args) > 0 {
+			source = args[0]
+		}
+		entries, err := monero.New().BanListEntries(source)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("No ban list entries found")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintf(w, "IP Address / Subnet\t| Source\n")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t| %s\n", e.IPAddr, e.Source)
+		}
+		w.Flush()
+	},
+}
+
+var diffBanListCmd = &cobra.Command{
+	Use:   "diff [version-id]",
+	Short: "Print changes of ban list version",
+	Long: `Print added and removed entries of the given ban list version.
+
+When [version-id] is not provided, the latest version is used. Use the
+"versions" subcommand to list available versions.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		var versionID int64
+		if len(args) > 0 {
+			var err error
+			versionID, err = strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				fmt.Println("Invalid version ID:", err)
+				return
+			}
+		}
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		v, changes, err := monero.New().BanListDiff(versionID)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Version %d (%s), sources: %s, %d entries, +%d -%d\n",
+			v.ID,
+			time.Unix(v.DateCreated, 0).Format(time.RFC3339),
+			v.Sources,
+			v.TotalEntries,
+			v.Added,
+			v.Removed,
+		)
+		for _, c := range changes {
+			sign := "+"
+			if c.Change == "removed" {
+				sign = "-"
+			}
+			fmt.Printf("%s %s (%s)\n", sign, c.IPAddr, c.Source)
+		}
+	},
+}
+
+var versionsBanListCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "Print ban list versions",
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		versions, err := monero.New().BanListVersions(limit)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(versions) == 0 {
+			fmt.Println("No ban list versions found")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintf(w, "ID\t| Date\t| Sources\t| Entries\t| Added\t| Removed\n")
+		for _, v := range versions {
+			fmt.Fprintf(w, "%d\t| %s\t| %s\t| %d\t| %d\t| %d\n",
+				v.ID,
+				time.Unix(v.DateCreated, 0).Format(time.RFC3339),
+				v.Sources,
+				v.TotalEntries,
+				v.Added,
+				v.Removed,
+			)
+		}
+		w.Flush()
+	},
+}
+
+var checkBanListCmd = &cobra.Command{
+	Use:   "check <ip>",
+	Short: "Check whether IP address is banned",
+	Long:  `Print ban list entries and their sources which contain the given IP address.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		matches, err := monero.New().CheckBanList(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(matches) == 0 {
+			fmt.Printf("%s is not banned\n", args[0])
+			return
+		}
+		for _, m := range matches {
+			fmt.Printf("%s is banned by %s (%s)\n", args[0], m.Source, m.IPAddr)
+		}
+	},
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"text/tabwriter"
 	"time"
 
@@ -14,8 +15,10 @@ import (
 
 var cronCmd = &cobra.Command{
 	Use:   "cron",
-	Short: "Print cron tasks",
-	Long:  `Print list of regular cron tasks running on the server.`,
+	Short: "Print and manage cron tasks",
+	Long: `Print list of regular cron tasks running on the server.
+
+Use the subcommands to enable, disable, reschedule, or run a task on demand.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := database.ConnectDB(); err != nil {
 			panic(err)
@@ -30,14 +33,206 @@ var cronCmd = &cobra.Command{
 			return
 		}
 		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
-		fmt.Fprintf(w, "ID\t| Name\t| Run Every\t| Last Run\t| Took Time\n")
+		fmt.Fprintf(w, "ID\t| Slug\t| Enabled\t| State\t| Schedule\t| Timeout\t| Last Run\t| Took Time\t| Last Error\n")
 		for _, cron := range crons {
-			fmt.Fprintf(w, "%d\t| %s\t| %ds\t| %s\t| %f\n",
+			schedule := cron.CronExpr
+			if schedule == "" {
+				schedule = fmt.Sprintf("every %ds", cron.RunEvery)
+			}
+			fmt.Fprintf(w, "%d\t| %s\t| %t\t| %s\t| %s\t| %ds\t| %s\t| %f\t| %s\n",
 				cron.ID,
-				cron.Title,
-				cron.RunEvery,
+				cron.Slug,
+				cron.IsEnabled == 1,
+				cronStateText(cron.CronState),
+				schedule,
+				cron.RunTimeout,
 				time.Unix(cron.LastRun, 0).Format(time.RFC3339),
 				cron.RunTime,
+				cron.LastError,
+			)
+		}
+		w.Flush()
+	},
+}
+
+func cronStateText(state int) string {
+	switch state {
+	case cron.StateRunning:
+		return "running"
+	case cron.StateError:
+		return "error"
+	default:
+		return "idle"
+	}
+}
+
+var enableCronCmd = &cobra.Command{
+	Use:   "enable <slug>",
+	Short: "Enable cron task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		setCronEnabled(args[0], true)
+	},
+}
+
+var disableCronCmd = &cobra.Command{
+	Use:   "disable <slug>",
+	Short: "Disable cron task",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		setCronEnabled(args[0], false)
+	},
+}
+
+func setCronEnabled(slug string, enabled bool) {
+	if err := database.ConnectDB(); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := cron.New().SetEnabled(slug, enabled); err != nil {
+		fmt.Println("Failed to update cron task:", err)
+		return
+	}
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	fmt.Printf("Cron task %s %s\n", slug, state)
+}
+
+var setIntervalCronCmd = &cobra.Command{
+	Use:   "set-interval <slug> <seconds>",
+	Short: "Change how often cron task runs",
+	Long: `Change how often (in seconds) cron task runs.
+
+This removes the task cron expression schedule (if any). The next run is
+rescheduled relative to the task last run.`,
+	Example: `# Run "check_mrl_ban_list" task every 10 minutes:
+xmr-nodes cron set-interval check_mrl_ban_list 600`,
+	Args: cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Println("Invalid interval:", err)
+			return
+		}
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := cron.New().SetInterval(args[0], seconds); err != nil {
+			fmt.Println("Failed to update cron task:", err)
+			return
+		}
+		fmt.Printf("Cron task %s now runs every %ds\n", args[0], seconds)
+	},
+}
+
+var setScheduleCronCmd = &cobra.Command{
+	Use:   "set-schedule <slug> <cron expression>",
+	Short: "Schedule cron task using cron expression",
+	Long: `Schedule cron task using standard 5-field cron expression (in UTC).
+
+The cron expression takes precedence over the "run every" interval. Use an
+empty expression ("") to go back to the interval schedule.`,
+	Example: `# Run "fetch_rucknium_node_data" task every day at 01:30 UTC:
+xmr-nodes cron set-schedule fetch_rucknium_node_data "30 1 * * *"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := cron.New().SetSchedule(args[0], args[1]); err != nil {
+			fmt.Println("Failed to update cron task:", err)
+			return
+		}
+		fmt.Printf("Cron task %s schedule updated\n", args[0])
+	},
+}
+
+var setTimeoutCronCmd = &cobra.Command{
+	Use:   "set-timeout <slug> <seconds>",
+	Short: "Change maximum run time of cron task",
+	Long:  `Change maximum run time (in seconds) of cron task, task exceeding its timeout is cancelled.`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Println("Invalid timeout:", err)
+			return
+		}
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := cron.New().SetTimeout(args[0], seconds); err != nil {
+			fmt.Println("Failed to update cron task:", err)
+			return
+		}
+		fmt.Printf("Cron task %s timeout set to %ds\n", args[0], seconds)
+	},
+}
+
+var runCronCmd = &cobra.Command{
+	Use:   "run <slug>",
+	Short: "Run cron task immediately",
+	Long: `Run cron task immediately regardless of its schedule and enabled state.
+
+The run is recorded in the task history and the next scheduled run is
+calculated from now.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		h, err := cron.New().Run(args[0])
+		if err != nil {
+			fmt.Println("Cron task failed:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Cron task %s done in %fs, %d rows affected\n", args[0], h.RunTime, h.RowsAffected)
+	},
+}
+
+var historyCronCmd = &cobra.Command{
+	Use:   "history [slug]",
+	Short: "Print cron task run history",
+	Long: `Print latest cron task run history.
+
+Use [slug] args to only show history of the given task.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		slug := ""
+		if len(args) > 0 {
+			slug = args[0]
+		}
+		history, err := cron.New().History(slug, limit)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(history) == 0 {
+			fmt.Println("No cron history found")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintf(w, "ID\t| Slug\t| Trigger\t| Start\t| Took Time\t| Rows\t| Error\n")
+		for _, h := range history {
+			fmt.Fprintf(w, "%d\t| %s\t| %s\t| %s\t| %f\t| %d\t| %s\n",
+				h.ID,
+				h.Slug,
+				h.TriggeredBy,
+				time.Unix(h.StartTS, 0).Format(time.RFC3339),
+				h.RunTime,
+				h.RowsAffected,
+				h.Error,
 			)
 		}
 		w.Flush()
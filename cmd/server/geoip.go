@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
+	"github.com/ditatompel/xmr-remote-nodes/internal/ip/geo"
+
+	"github.com/spf13/cobra"
+)
+
+var geoipCmd = &cobra.Command{
+	Use:   "geoip",
+	Short: "[Server] Manage GeoIP databases",
+	Long: `Command to download and inspect GeoIP City and ASN databases.
+
+Providers are configured with GEOIP_PROVIDERS and their data source paths
+with GEOIP_CITY_DB, GEOIP_ASN_DB, DBIP_CITY_DB, DBIP_ASN_DB, IPTOASN_TSV and
+GEOIP_OVERRIDE_CSV.`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var updateGeoIPCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download and install GeoIP databases",
+	Long: `Download, validate and install GeoIP City and ASN databases.
+
+MaxMind GeoLite2 databases require MAXMIND_ACCOUNT_ID and MAXMIND_LICENSE_KEY
+to be configured. DB-IP lite databases don't require an account.
+
+The running server reloads the databases automatically within a minute.`,
+	Example: `# Download both GeoLite2 City and ASN databases:
+xmr-nodes geoip update
+
+# Download DB-IP lite City database only:
+xmr-nodes geoip update --provider dbip --kind city`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		provider, _ := cmd.Flags().GetString("provider")
+		kinds, _ := cmd.Flags().GetStringSlice("kind")
+		cfg := config.AppCfg()
+
+		f := fetcher.New(
+			fetcher.WithTimeout(30*time.Minute),
+			fetcher.WithMaxBytes(512<<20),
+		)
+		failed := false
+		for _, kind := range kinds {
+			kind = strings.ToLower(strings.TrimSpace(kind))
+			dest := geo.Path(provider, kind)
+			err := geo.Update(context.Background(), f, geo.UpdateOptions{
+				Provider:   provider,
+				Kind:       kind,
+				Dest:       dest,
+				AccountID:  cfg.MaxMindAccountID,
+				LicenseKey: cfg.MaxMindLicenseKey,
+			})
+			if err != nil {
+				fmt.Printf("Failed to update %s database: %s\n", kind, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("GeoIP %s database installed to %s\n", kind, dest)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var lookupGeoIPCmd = &cobra.Command{
+	Use:   "lookup <ip>",
+	Short: "Print GeoIP information of IP address",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		info, err := geo.Info(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Country   : %s (%s)\n", info.CountryName, info.CountryCode)
+		fmt.Printf("City      : %s\n", info.City)
+		fmt.Printf("Location  : %f, %f (~%d km)\n", info.Latitude, info.Longitude, info.AccuracyRadius)
+		fmt.Printf("ASN       : AS%d %s\n", info.ASN, info.ASNOrg)
+		fmt.Printf("Provider  : %s\n", info.Provider)
+	},
+}
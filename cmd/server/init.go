@@ -1,17 +1,64 @@
 package server
 
-import "github.com/ditatompel/xmr-remote-nodes/cmd"
+import (
+	"github.com/ditatompel/xmr-remote-nodes/cmd"
+	"github.com/ditatompel/xmr-remote-nodes/internal/ip/geo"
+)
 
 func init() {
 	cmd.Root.AddCommand(serveCmd)
 	cmd.Root.AddCommand(cronCmd)
+	cronCmd.AddCommand(enableCronCmd)
+	cronCmd.AddCommand(disableCronCmd)
+	cronCmd.AddCommand(setIntervalCronCmd)
+	cronCmd.AddCommand(setScheduleCronCmd)
+	cronCmd.AddCommand(setTimeoutCronCmd)
+	cronCmd.AddCommand(runCronCmd)
+	cronCmd.AddCommand(historyCronCmd)
+	historyCronCmd.Flags().IntP("limit", "l", 20, "Number of history rows to show")
 	cmd.Root.AddCommand(probersCmd)
 	probersCmd.AddCommand(listProbersCmd)
 	probersCmd.AddCommand(addProbersCmd)
 	probersCmd.AddCommand(editProbersCmd)
+	probersCmd.AddCommand(regionProbersCmd)
 	probersCmd.AddCommand(deleteProbersCmd)
 	listProbersCmd.Flags().StringP("sort-by", "s", "last_submit_ts", "Sort by column name, can be id or last_submit_ts")
 	listProbersCmd.Flags().StringP("sort-dir", "d", "desc", "Sort direction, can be asc or desc")
 	cmd.Root.AddCommand(nodeCmd)
 	nodeCmd.AddCommand(deleteNodeCmd)
+	nodeCmd.AddCommand(portsNodeCmd)
+	portsNodeCmd.Flags().Uint("zmq", 0, "ZMQ pub port, 0 for nettype default")
+	portsNodeCmd.Flags().Uint("p2p", 0, "P2P port, 0 for nettype default")
+	nodeCmd.AddCommand(loginNodeCmd)
+	loginNodeCmd.Flags().StringP("username", "u", "", "RPC login username")
+	loginNodeCmd.Flags().StringP("password", "p", "", "RPC login password, prompted if empty")
+	loginNodeCmd.Flags().Bool("remove", false, "Remove the RPC login")
+	nodeCmd.AddCommand(scheduleNodeCmd)
+	scheduleNodeCmd.Flags().IntP("limit", "l", 20, "Number of nodes to show")
+	nodeCmd.AddCommand(intervalNodeCmd)
+	cmd.Root.AddCommand(banListCmd)
+	banListCmd.AddCommand(showBanListCmd)
+	banListCmd.AddCommand(diffBanListCmd)
+	banListCmd.AddCommand(versionsBanListCmd)
+	banListCmd.AddCommand(checkBanListCmd)
+	versionsBanListCmd.Flags().IntP("limit", "l", 20, "Number of versions to show")
+	cmd.Root.AddCommand(geoipCmd)
+	geoipCmd.AddCommand(updateGeoIPCmd)
+	geoipCmd.AddCommand(lookupGeoIPCmd)
+	updateGeoIPCmd.Flags().StringP("provider", "p", geo.ProviderMaxMind, "Database provider, can be maxmind or dbip")
+	updateGeoIPCmd.Flags().StringSliceP("kind", "k", []string{geo.KindCity, geo.KindASN}, "Database kinds to download, can be city and/or asn")
+	cmd.Root.AddCommand(releasesCmd)
+	releasesCmd.AddCommand(listReleasesCmd)
+	releasesCmd.AddCommand(setReleasesCmd)
+	releasesCmd.AddCommand(deleteReleasesCmd)
+	releasesCmd.AddCommand(refreshReleasesCmd)
+	setReleasesCmd.Flags().Bool("fork-minimum", false, "Minimum version required by the hard fork")
+	setReleasesCmd.Flags().Bool("vulnerable", false, "Known vulnerable version")
+	setReleasesCmd.Flags().String("note", "", "Release note")
+	setReleasesCmd.Flags().String("date", "", "Release date (YYYY-MM-DD)")
+	cmd.Root.AddCommand(adminCmd)
+	adminCmd.AddCommand(listAdminCmd)
+	adminCmd.AddCommand(addAdminCmd)
+	adminCmd.AddCommand(deleteAdminCmd)
+	adminCmd.AddCommand(resetTOTPAdminCmd)
 }
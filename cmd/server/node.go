@@ -5,7 +5,10 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"text/tabwriter"
+	"time"
 
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
 	"github.com/ditatompel/xmr-remote-nodes/internal/database"
 	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
 
@@ -57,3 +60,179 @@ To find out the node ID, visit frontend UI or from "/api/v1/nodes" endpoint.
 		fmt.Printf("Node ID %d deleted\n", nodeID)
 	},
 }
+
+var portsNodeCmd = &cobra.Command{
+	Use:   "ports <id>",
+	Short: "Set node ZMQ pub and P2P ports",
+	Long: `Set ZMQ pub and P2P ports of node identified by ID.
+
+Probers with CHECK_ZMQ or CHECK_P2P enabled check these ports. Use 0 to check
+the nettype default port (18083 and 18080 for mainnet). Previous check results
+are reset.
+
+To find out the node ID, visit frontend UI or from "/api/v1/nodes" endpoint.
+	`,
+	Example: `# Node publishes ZMQ on port 18084 and uses the default P2P port:
+xmr-nodes node ports 42 --zmq 18084 --p2p 0`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		nodeID, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Invalid ID:", err)
+			return
+		}
+		zmqPort, _ := cmd.Flags().GetUint("zmq")
+		p2pPort, _ := cmd.Flags().GetUint("p2p")
+
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := monero.New().SetPorts(uint(nodeID), zmqPort, p2pPort); err != nil {
+			fmt.Println("Failed to set node ports:", err)
+			return
+		}
+
+		fmt.Printf("Node ID %d ports updated\n", nodeID)
+	},
+}
+
+var loginNodeCmd = &cobra.Command{
+	Use:   "login <id>",
+	Short: "Set or remove node RPC login",
+	Long: `Set or remove monerod "--rpc-login" credentials of node identified by ID.
+
+The credentials are stored encrypted using APP_SECRET and sent to probers to
+probe the node using HTTP digest authentication. Changing APP_SECRET makes
+stored credentials unusable.
+
+To find out the node ID, visit frontend UI or from "/api/v1/nodes" endpoint.
+	`,
+	Example: `# Set RPC login:
+xmr-nodes node login 42 --username monero --password public
+
+# Remove RPC login:
+xmr-nodes node login 42 --remove`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		nodeID, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Invalid ID:", err)
+			return
+		}
+		var login monero.RPCLogin
+		if remove, _ := cmd.Flags().GetBool("remove"); !remove {
+			login.Username, _ = cmd.Flags().GetString("username")
+			login.Password, _ = cmd.Flags().GetString("password")
+			if login.Username == "" {
+				fmt.Println("Username is required, use --remove to remove the RPC login")
+				return
+			}
+			if login.Password == "" {
+				login.Password = stringPrompt("Password:")
+			}
+		}
+
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := monero.New().SetLogin(uint(nodeID), config.AppCfg().Secret, login); err != nil {
+			fmt.Println("Failed to set node RPC login:", err)
+			return
+		}
+
+		fmt.Printf("Node ID %d RPC login updated\n", nodeID)
+	},
+}
+
+var scheduleNodeCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Show node probe schedule",
+	Long: `Show non-archived nodes in the order they are given to probers.
+
+New nodes are probed first. Flapping nodes are probed 4 times as often as the
+target check interval of their network type (CHECK_INTERVAL_CLEARNET,
+CHECK_INTERVAL_TOR, CHECK_INTERVAL_I2P) and dead nodes (offline for 5
+consecutive probes) exponentially less often, up to CHECK_INTERVAL_MAX. The
+node minimum check interval takes precedence.
+	`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		entries, err := monero.New().Schedule(monero.DefaultSchedulePolicy(), limit)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintf(w, "ID\t| Node\t| State\t| Failures\t| Last Checked\t| Next Check\t| Interval\n")
+		for _, e := range entries {
+			next := "now"
+			if due := time.Unix(e.NextCheck, 0); due.After(now) {
+				next = "in " + due.Sub(now).Round(time.Second).String()
+			}
+			lastChecked := "never"
+			if e.LastChecked > 0 {
+				lastChecked = time.Unix(e.LastChecked, 0).Format(time.RFC3339)
+			}
+			interval := e.Interval.String()
+			if e.MinCheckInterval > 0 {
+				interval += " (min)"
+			}
+			fmt.Fprintf(w, "%d\t| %s:%d\t| %s\t| %d\t| %s\t| %s\t| %s\n",
+				e.ID,
+				e.Hostname,
+				e.Port,
+				e.State,
+				e.CheckFailures,
+				lastChecked,
+				next,
+				interval)
+		}
+		w.Flush()
+	},
+}
+
+var intervalNodeCmd = &cobra.Command{
+	Use:   "interval <id> <seconds>",
+	Short: "Set node minimum check interval",
+	Long: `Set minimum check interval (in seconds) of node identified by ID.
+
+The node is never probed more often than the minimum check interval, use 0 to
+follow the schedule policy. The new interval applies after the next probe.
+
+To find out the node ID, visit frontend UI or from "/api/v1/nodes" endpoint.
+	`,
+	Example: `# Probe node at most once every 6 hours:
+xmr-nodes node interval 42 21600`,
+	Args: cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		nodeID, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Invalid ID:", err)
+			return
+		}
+		seconds, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			fmt.Println("Invalid interval:", err)
+			return
+		}
+
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := monero.New().SetMinCheckInterval(uint(nodeID), uint(seconds)); err != nil {
+			fmt.Println("Failed to set node minimum check interval:", err)
+			return
+		}
+
+		fmt.Printf("Node ID %d minimum check interval updated\n", nodeID)
+	},
+}
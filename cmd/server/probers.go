@@ -64,11 +64,12 @@ xmr-nodes probers list -s last_submit_ts -d asc sin1`,
 			return
 		}
 		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
-		fmt.Fprintf(w, "ID\t| Name\t| Last Submit\t| API Key\n")
+		fmt.Fprintf(w, "ID\t| Name\t| Region\t| Last Submit\t| API Key\n")
 		for _, prober := range probers {
-			fmt.Fprintf(w, "%d\t| %s\t| %s\t| %s\n",
+			fmt.Fprintf(w, "%d\t| %s\t| %s\t| %s\t| %s\n",
 				prober.ID,
 				prober.Name,
+				prober.Region,
 				time.Unix(prober.LastSubmitTS, 0).Format(time.RFC3339),
 				prober.APIKey,
 			)
@@ -147,6 +148,39 @@ var editProbersCmd = &cobra.Command{
 	},
 }
 
+var regionProbersCmd = &cobra.Command{
+	Use:   "region <id> [region]",
+	Short: "Set prober region",
+	Long: `Set region of prober identified by ID, omit [region] to remove it.
+
+Node response time percentiles are also calculated per prober region.`,
+	Example: `# Prober located in Singapore:
+xmr-nodes probers region 1 ap-southeast`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(_ *cobra.Command, args []string) {
+		proberId, err := strconv.Atoi(args[0])
+		if err != nil {
+			fmt.Println("Invalid ID:", err)
+			return
+		}
+		region := ""
+		if len(args) > 1 {
+			region = args[1]
+		}
+
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := monero.NewProber().SetRegion(proberId, region); err != nil {
+			fmt.Println("Failed to update prober:", err)
+			return
+		}
+
+		fmt.Printf("Prober ID %d updated\n", proberId)
+	},
+}
+
 var deleteProbersCmd = &cobra.Command{
 	Use:   "delete",
 	Short: "Delete prober",
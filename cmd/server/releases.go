@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/database"
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+
+	"github.com/spf13/cobra"
+)
+
+var releasesCmd = &cobra.Command{
+	Use:   "releases",
+	Short: "[Server] Manage known monerod releases",
+	Long: `Command to manage known monerod releases, used to flag nodes running
+outdated, unsupported (older than the hard fork minimum) or vulnerable
+versions.
+
+Node version status is recalculated after every change.
+
+This command should only be run on the server which directly connect to the MySQL database.
+	`,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if err := cmd.Help(); err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var listReleasesCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print known releases",
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		releases, err := monero.New().Releases()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(releases) == 0 {
+			fmt.Println("No releases found")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintf(w, "Version\t| Release Date\t| Fork Minimum\t| Vulnerable\t| Note\n")
+		for _, r := range releases {
+			date := "-"
+			if r.ReleaseDate > 0 {
+				date = time.Unix(r.ReleaseDate, 0).UTC().Format(time.DateOnly)
+			}
+			fmt.Fprintf(w, "%s\t| %s\t| %t\t| %t\t| %s\n",
+				r.Version,
+				date,
+				r.IsForkMinimum,
+				r.IsVulnerable,
+				r.Note,
+			)
+		}
+		w.Flush()
+	},
+}
+
+var setReleasesCmd = &cobra.Command{
+	Use:   "set <version>",
+	Short: "Add or update known release",
+	Long: `Add or update known monerod release.
+
+The newest release flagged with "--fork-minimum" is the minimum version
+required by the current hard fork.`,
+	Example: `# Flag 0.18.3.1 as vulnerable:
+xmr-nodes releases set 0.18.3.1 --vulnerable --note "CVE-XXXX-XXXX"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		release := monero.MoneroRelease{Version: args[0]}
+		release.IsForkMinimum, _ = cmd.Flags().GetBool("fork-minimum")
+		release.IsVulnerable, _ = cmd.Flags().GetBool("vulnerable")
+		release.Note, _ = cmd.Flags().GetString("note")
+		if date, _ := cmd.Flags().GetString("date"); date != "" {
+			t, err := time.Parse(time.DateOnly, date)
+			if err != nil {
+				fmt.Println("Invalid release date:", err)
+				return
+			}
+			release.ReleaseDate = t.Unix()
+		}
+
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := monero.New().SetRelease(release); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Release %s saved\n", args[0])
+	},
+}
+
+var deleteReleasesCmd = &cobra.Command{
+	Use:   "delete <version>",
+	Short: "Delete known release",
+	Args:  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := monero.New().DeleteRelease(args[0]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Release %s deleted\n", args[0])
+	},
+}
+
+var refreshReleasesCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Recalculate node version status",
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := database.ConnectDB(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		n, err := monero.New().RefreshVersionStatus()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%d nodes updated\n", n)
+	},
+}
@@ -0,0 +1,185 @@
+// Package admin provides authentication and audit logging for the admin web
+// console.
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/database"
+)
+
+var errInvalidCredentials = errors.New("invalid username, password or TOTP code")
+
+type adminRepo struct {
+	db *database.DB
+}
+
+// Admin represents a single admin user
+type Admin struct {
+	ID           int64  `json:"id" db:"id"`
+	Username     string `json:"username" db:"username"`
+	PasswordHash string `json:"-" db:"password_hash"`
+	TOTPSecret   string `json:"-" db:"totp_secret"`
+	LastLoginTS  int64  `json:"last_login_ts" db:"last_login_ts"`
+	DateCreated  int64  `json:"date_created" db:"date_created"`
+
+	// SessionVersion is embedded in session tokens, bumping it revokes all
+	// existing sessions of the admin user.
+	SessionVersion int64 `json:"-" db:"session_version"`
+}
+
+func New() *adminRepo {
+	return &adminRepo{db: database.GetDB()}
+}
+
+// Add creates a new admin user and returns the generated TOTP secret
+func (r *adminRepo) Add(username, password string) (Admin, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return Admin{}, errors.New("username cannot be empty")
+	}
+	if len(password) < 12 {
+		return Admin{}, errors.New("password must be at least 12 characters")
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return Admin{}, err
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return Admin{}, err
+	}
+
+	a := Admin{
+		Username:     username,
+		PasswordHash: hash,
+		TOTPSecret:   secret,
+		DateCreated:  time.Now().Unix(),
+	}
+	res, err := r.db.Exec(`
+		INSERT INTO tbl_admin (
+			username,
+			password_hash,
+			totp_secret,
+			date_created
+		) VALUES (
+			?,
+			?,
+			?,
+			?
+		)`, a.Username, a.PasswordHash, a.TOTPSecret, a.DateCreated)
+	if err != nil {
+		return Admin{}, err
+	}
+	a.ID, err = res.LastInsertId()
+
+	return a, err
+}
+
+// Admin returns admin user by id
+func (r *adminRepo) Admin(id int64) (Admin, error) {
+	var a Admin
+	err := r.db.Get(&a, `SELECT * FROM tbl_admin WHERE id = ?`, id)
+	return a, err
+}
+
+// Admins returns list of admin users
+func (r *adminRepo) Admins() ([]Admin, error) {
+	var admins []Admin
+	err := r.db.Select(&admins, `
+		SELECT
+			id,
+			username,
+			last_login_ts,
+			date_created
+		FROM
+			tbl_admin
+		ORDER BY
+			id ASC`)
+	return admins, err
+}
+
+// Delete an existing admin user
+func (r *adminRepo) Delete(id int64) error {
+	res, err := r.db.Exec(`DELETE FROM tbl_admin WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		return errors.New("no rows affected")
+	}
+	return nil
+}
+
+// ResetTOTP generates a new TOTP secret for the given admin user
+func (r *adminRepo) ResetTOTP(id int64) (string, error) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return "", err
+	}
+	// existing sessions were authenticated with the previous secret
+	res, err := r.db.Exec(`
+		UPDATE tbl_admin
+		SET
+			totp_secret = ?,
+			session_version = session_version + 1
+		WHERE
+			id = ?`, secret, id)
+	if err != nil {
+		return "", err
+	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if row == 0 {
+		return "", errors.New("no rows affected")
+	}
+	return secret, nil
+}
+
+// RevokeSessions invalidates every session token issued for the given admin
+// user
+func (r *adminRepo) RevokeSessions(id int64) error {
+	_, err := r.db.Exec(`UPDATE tbl_admin SET session_version = session_version + 1 WHERE id = ?`, id)
+	return err
+}
+
+// Authenticate checks the given username, password and TOTP code.
+//
+// The same error is returned for every failure case so the login form does
+// not leak which part of the credentials is wrong.
+func (r *adminRepo) Authenticate(username, password, code string) (Admin, error) {
+	var a Admin
+	err := r.db.Get(&a, `SELECT * FROM tbl_admin WHERE username = ? LIMIT 1`, username)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error(err.Error())
+		}
+		return Admin{}, errInvalidCredentials
+	}
+
+	ok, err := verifyPassword(password, a.PasswordHash)
+	if err != nil {
+		slog.Error(err.Error())
+	}
+	if !ok || !validTOTP(a.TOTPSecret, code, time.Now()) {
+		return Admin{}, errInvalidCredentials
+	}
+
+	a.LastLoginTS = time.Now().Unix()
+	if _, err := r.db.Exec(`UPDATE tbl_admin SET last_login_ts = ? WHERE id = ?`, a.LastLoginTS, a.ID); err != nil {
+		slog.Warn(err.Error())
+	}
+
+	return a, nil
+}
@@ -0,0 +1,164 @@
+package admin
+
+import (
+	"encoding/base32"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/paging"
+)
+
+// Single test vector from RFC 6238 Appendix B (SHA1)
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidTOTP(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, _ := totpCode(secret, now)
+	prev, _ := totpCode(secret, now.Add(-30*time.Second))
+	old, _ := totpCode(secret, now.Add(-5*time.Minute))
+
+	if !validTOTP(secret, code, now) {
+		t.Error("current code should be valid")
+	}
+	if !validTOTP(secret, prev, now) {
+		t.Error("previous step code should be valid")
+	}
+	if old != code && old != prev && validTOTP(secret, old, now) {
+		t.Error("old code should be invalid")
+	}
+	if validTOTP(secret, "", now) {
+		t.Error("empty code should be invalid")
+	}
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := hashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := verifyPassword("correct horse battery staple", hash); !ok || err != nil {
+		t.Errorf("verifyPassword() = %v, %v, want true, nil", ok, err)
+	}
+	if ok, _ := verifyPassword("wrong password", hash); ok {
+		t.Error("verifyPassword() with wrong password = true, want false")
+	}
+	if _, err := verifyPassword("x", "invalid"); err == nil {
+		t.Error("verifyPassword() with invalid hash should return error")
+	}
+}
+
+func TestSessionToken(t *testing.T) {
+	now := time.Now()
+	token, err := NewSessionToken("secret", 42, 3, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, version, err := ParseSessionToken("secret", token, now)
+	if err != nil || id != 42 || version != 3 {
+		t.Errorf("ParseSessionToken() = %d, %d, %v, want 42, 3, nil", id, version, err)
+	}
+	if _, _, err := ParseSessionToken("other", token, now); err != errInvalidSession {
+		t.Errorf("ParseSessionToken() with wrong secret err = %v, want %v", err, errInvalidSession)
+	}
+	if _, _, err := ParseSessionToken("secret", token+"0", now); err != errInvalidSession {
+		t.Errorf("ParseSessionToken() with tampered token err = %v, want %v", err, errInvalidSession)
+	}
+	if _, _, err := ParseSessionToken("secret", token, now.Add(2*time.Hour)); err != errSessionExpired {
+		t.Errorf("ParseSessionToken() after expiry err = %v, want %v", err, errSessionExpired)
+	}
+	if _, err := NewSessionToken("", 42, 3, now); err != errNoSecret {
+		t.Errorf("NewSessionToken() without secret err = %v, want %v", err, errNoSecret)
+	}
+}
+
+func TestQueryAuditLogs_toSQL(t *testing.T) {
+	tests := []struct {
+		name              string
+		query             QueryAuditLogs
+		wantArgs          []interface{}
+		wantWhere         string
+		wantSortBy        string
+		wantSortDirection string
+	}{
+		{
+			name:              "Default query",
+			query:             QueryAuditLogs{},
+			wantArgs:          nil,
+			wantWhere:         "",
+			wantSortBy:        "id",
+			wantSortDirection: "DESC",
+		},
+		{
+			name: "With all filters",
+			query: QueryAuditLogs{
+				Paging: paging.Paging{
+					SortBy:        "date_created",
+					SortDirection: "asc",
+				},
+				AdminID: 1,
+				Action:  "node.delete",
+				Search:  "test",
+			},
+			wantArgs:          []interface{}{int64(1), "node.delete", "%test%", "%test%"},
+			wantWhere:         "WHERE admin_id = ? AND action = ? AND (target LIKE ? OR detail LIKE ?)",
+			wantSortBy:        "date_created",
+			wantSortDirection: "ASC",
+		},
+		{
+			name: "With invalid sort by name and direction",
+			query: QueryAuditLogs{
+				Paging: paging.Paging{
+					SortBy:        "invalid",
+					SortDirection: "invalid",
+				},
+			},
+			wantArgs:          nil,
+			wantWhere:         "",
+			wantSortBy:        "id",
+			wantSortDirection: "DESC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArgs, gotWhere, gotSortBy, gotSortDirection := tt.query.toSQL()
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("QueryAuditLogs.toSQL() gotArgs = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			if gotWhere != tt.wantWhere {
+				t.Errorf("QueryAuditLogs.toSQL() gotWhere = %v, want %v", gotWhere, tt.wantWhere)
+			}
+			if gotSortBy != tt.wantSortBy {
+				t.Errorf("QueryAuditLogs.toSQL() gotSortBy = %v, want %v", gotSortBy, tt.wantSortBy)
+			}
+			if gotSortDirection != tt.wantSortDirection {
+				t.Errorf("QueryAuditLogs.toSQL() gotSortDirection = %v, want %v", gotSortDirection, tt.wantSortDirection)
+			}
+		})
+	}
+}
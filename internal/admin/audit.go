@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/paging"
+)
+
+// AuditLog represents a single admin action
+type AuditLog struct {
+	ID          int64  `json:"id" db:"id"`
+	AdminID     int64  `json:"admin_id" db:"admin_id"`
+	Username    string `json:"username" db:"username"`
+	Action      string `json:"action" db:"action"`
+	Target      string `json:"target" db:"target"`
+	Detail      string `json:"detail" db:"detail"`
+	IPAddr      string `json:"ip_addr" db:"ip_addr"`
+	DateCreated int64  `json:"date_created" db:"date_created"`
+}
+
+// Audit records an admin action. Failing to write the audit log never stop
+// the action itself, but it will be logged.
+func (r *adminRepo) Audit(adminID int64, username, action, target, detail, ip string) {
+	_, err := r.db.Exec(`
+		INSERT INTO tbl_admin_audit_log (
+			admin_id,
+			username,
+			action,
+			target,
+			detail,
+			ip_addr,
+			date_created
+		) VALUES (
+			?,
+			?,
+			?,
+			?,
+			?,
+			?,
+			?
+		)`, adminID, username, action, target, detail, ip, time.Now().Unix())
+	if err != nil {
+		slog.Error(fmt.Sprintf("[ADMIN] Failed to write audit log: %s", err))
+	}
+}
+
+type QueryAuditLogs struct {
+	paging.Paging
+	AdminID int64  `url:"admin_id,omitempty"`
+	Action  string `url:"action,omitempty"`
+	Search  string `url:"search,omitempty"` // search in target and detail
+}
+
+func (q QueryAuditLogs) toSQL() (args []interface{}, where, sortBy, sortDirection string) {
+	wq := []string{}
+	if q.AdminID != 0 {
+		wq = append(wq, "admin_id = ?")
+		args = append(args, q.AdminID)
+	}
+	if q.Action != "" {
+		wq = append(wq, "action = ?")
+		args = append(args, q.Action)
+	}
+	if q.Search != "" {
+		wq = append(wq, "(target LIKE ? OR detail LIKE ?)")
+		args = append(args, "%"+q.Search+"%", "%"+q.Search+"%")
+	}
+
+	if len(wq) > 0 {
+		where = "WHERE " + strings.Join(wq, " AND ")
+	}
+
+	sortBy = "id"
+	if slices.Contains([]string{"date_created"}, q.SortBy) {
+		sortBy = q.SortBy
+	}
+	sortDirection = "DESC"
+	if q.SortDirection == "asc" {
+		sortDirection = "ASC"
+	}
+
+	return args, where, sortBy, sortDirection
+}
+
+type AuditLogs struct {
+	TotalRows   int         `json:"total_rows"`
+	TotalPages  int         `json:"total_pages"` // total pages
+	RowsPerPage int         `json:"rows_per_page"`
+	Items       []*AuditLog `json:"items"`
+}
+
+// AuditLogs returns list of admin audit log for given query
+func (r *adminRepo) AuditLogs(q QueryAuditLogs) (AuditLogs, error) {
+	args, where, sortBy, sortDirection := q.toSQL()
+
+	var logs AuditLogs
+	logs.RowsPerPage = q.Limit
+
+	qTotal := fmt.Sprintf(`SELECT COUNT(id) FROM tbl_admin_audit_log %s`, where)
+	if err := r.db.QueryRow(qTotal, args...).Scan(&logs.TotalRows); err != nil {
+		return logs, err
+	}
+	logs.TotalPages = int(math.Ceil(float64(logs.TotalRows) / float64(q.Limit)))
+	args = append(args, q.Limit, (q.Page-1)*q.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT
+			*
+		FROM
+			tbl_admin_audit_log
+		%s -- where query
+		ORDER BY
+			%s
+			%s
+		LIMIT ?
+		OFFSET ?`, where, sortBy, sortDirection)
+	err := r.db.Select(&logs.Items, query, args...)
+
+	return logs, err
+}
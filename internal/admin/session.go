@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionCookie is the cookie name used to store admin session token
+const SessionCookie = "xmr_nodes_admin"
+
+// SessionTTL is how long an admin session is valid after login
+const SessionTTL = 12 * time.Hour
+
+var (
+	errNoSecret       = errors.New("APP_SECRET is not configured")
+	errInvalidSession = errors.New("invalid session")
+	errSessionExpired = errors.New("session expired")
+)
+
+// NewSessionToken creates a session token for the given admin ID signed with
+// HMAC-SHA256 using the app secret.
+//
+// The token format is
+// `base64(<admin_id>:<session_version>:<expires_unix>).<hex signature>`.
+// The session version must match the admin's current session version, so
+// bumping it revokes every token issued before.
+func NewSessionToken(secret string, adminID, version int64, expires time.Time) (string, error) {
+	if secret == "" {
+		return "", errNoSecret
+	}
+	payload := base64.RawURLEncoding.EncodeToString(
+		[]byte(fmt.Sprintf("%d:%d:%d", adminID, version, expires.Unix())),
+	)
+	return payload + "." + signSession(secret, payload), nil
+}
+
+// ParseSessionToken verifies the session token signature and expiry, and
+// returns the admin ID and session version.
+func ParseSessionToken(secret, token string, now time.Time) (int64, int64, error) {
+	if secret == "" {
+		return 0, 0, errNoSecret
+	}
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, 0, errInvalidSession
+	}
+	if !hmac.Equal([]byte(sig), []byte(signSession(secret, payload))) {
+		return 0, 0, errInvalidSession
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, 0, errInvalidSession
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return 0, 0, errInvalidSession
+	}
+	adminID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, errInvalidSession
+	}
+	version, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, errInvalidSession
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, 0, errInvalidSession
+	}
+	if now.Unix() > expires {
+		return 0, 0, errSessionExpired
+	}
+
+	return adminID, version, nil
+}
+
+func signSession(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("admin-session:" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits = 6
+	totpPeriod = 30 // in seconds
+	totpIssuer = "XMR Nodes"
+)
+
+var b32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newTOTPSecret generates random 160-bit base32 encoded TOTP secret
+func newTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b32NoPadding.EncodeToString(b), nil
+}
+
+// totpCode generates RFC 6238 TOTP code (HMAC-SHA1, 6 digits, 30 seconds
+// period) for the given base32 secret and time.
+func totpCode(secret string, t time.Time) (string, error) {
+	key, err := b32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(t.Unix()/totpPeriod))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, see RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, code%1000000), nil
+}
+
+// validTOTP checks the given code against the secret, allowing one time step
+// clock drift in both directions.
+func validTOTP(secret, code string, t time.Time) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, skew := range []time.Duration{0, -totpPeriod * time.Second, totpPeriod * time.Second} {
+		want, err := totpCode(secret, t.Add(skew))
+		if err != nil {
+			return false
+		}
+		if hmac.Equal([]byte(want), []byte(code)) {
+			return true
+		}
+	}
+	return false
+}
+
+// OTPAuthURI returns `otpauth://` URI that can be imported to authenticator
+// apps (usually by converting it to QR code).
+func OTPAuthURI(username, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", totpIssuer)
+	v.Set("digits", fmt.Sprintf("%d", totpDigits))
+	v.Set("period", fmt.Sprintf("%d", totpPeriod))
+
+	label := url.PathEscape(totpIssuer + ":" + username)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, v.Encode())
+}
@@ -16,6 +16,38 @@ type App struct {
 	URL    string // URL where user can access the web UI, don't put trailing slash
 	Secret string // random 64-character hex string that give us 32 random bytes
 
+	// comma separated `name=location` ban list sources, location can be
+	// HTTP(S) URL or local file path
+	BanListSources string
+	// archive nodes whose IP address is found in the ban list
+	BanListAutoArchive bool
+	// Rucknium's Monero Network Scan `individual_node_data` API URL
+	RuckniumAPIURL string
+	// limits of external data (Rucknium's API, ban lists) downloads
+	FetchTimeout  int   // in seconds
+	FetchMaxBytes int64 // in bytes
+
+	// GeoIP providers and their data sources
+	GeoIPProviders    string // comma separated, in lookup order
+	GeoIPCityDB       string
+	GeoIPASNDB        string
+	DBIPCityDB        string
+	DBIPASNDB         string
+	IPtoASNTSV        string
+	GeoIPOverrideCSV  string
+	GeoIPCacheSize    int // number of cached IP address lookups
+	MaxMindAccountID  string
+	MaxMindLicenseKey string
+	// decimal places of public node coordinates (map and GeoJSON API)
+	MapCoordPrecision int
+	TLSExpiryDays     int // days before TLS certificate expiry to flag it
+	// target check interval of nodes per network type and maximum check
+	// interval of long-dead nodes, in seconds
+	CheckIntervalClearnet int
+	CheckIntervalTor      int
+	CheckIntervalI2P      int
+	CheckIntervalMax      int
+
 	// fiber specific config
 	Prefork     bool
 	Host        string
@@ -31,6 +63,15 @@ type App struct {
 	AcceptI2P      bool
 	I2PSOCKS       string
 	IPv6Capable    bool
+	CheckZMQ       bool // check ZMQ pub port of available nodes
+	CheckP2P       bool // check P2P port of available nodes
+	SOCKSIsolation bool // isolate Tor circuits per probed node
+	SOCKSRetries   int  // retries on Tor circuit or SOCKS proxy failure
+	Capacity       int  // probes per hour declared to the server, 0 = unknown
+	// failed reports are spooled to ReportSpoolDir and replayed later, at
+	// most ReportSpoolSize reports are kept (0 disables spooling)
+	ReportSpoolDir  string
+	ReportSpoolSize int
 }
 
 func init() {
@@ -63,6 +104,34 @@ func LoadApp() {
 	// server configuration
 	app.URL = os.Getenv("APP_URL")
 	app.Secret = os.Getenv("APP_SECRET")
+	app.BanListSources = os.Getenv("BAN_LIST_SOURCES")
+	app.BanListAutoArchive, _ = strconv.ParseBool(os.Getenv("BAN_LIST_AUTO_ARCHIVE"))
+	app.RuckniumAPIURL = os.Getenv("RUCKNIUM_API_URL")
+	app.FetchTimeout, _ = strconv.Atoi(os.Getenv("FETCH_TIMEOUT"))
+	app.FetchMaxBytes, _ = strconv.ParseInt(os.Getenv("FETCH_MAX_BYTES"), 10, 64)
+	app.GeoIPProviders = os.Getenv("GEOIP_PROVIDERS")
+	app.GeoIPCityDB = os.Getenv("GEOIP_CITY_DB")
+	app.GeoIPASNDB = os.Getenv("GEOIP_ASN_DB")
+	app.DBIPCityDB = os.Getenv("DBIP_CITY_DB")
+	app.DBIPASNDB = os.Getenv("DBIP_ASN_DB")
+	app.IPtoASNTSV = os.Getenv("IPTOASN_TSV")
+	app.GeoIPOverrideCSV = os.Getenv("GEOIP_OVERRIDE_CSV")
+	app.GeoIPCacheSize, _ = strconv.Atoi(os.Getenv("GEOIP_CACHE_SIZE"))
+	app.MaxMindAccountID = os.Getenv("MAXMIND_ACCOUNT_ID")
+	app.MaxMindLicenseKey = os.Getenv("MAXMIND_LICENSE_KEY")
+	app.MapCoordPrecision = 1
+	if v, err := strconv.Atoi(os.Getenv("MAP_COORDINATE_PRECISION")); err == nil {
+		app.MapCoordPrecision = v
+	}
+	app.TLSExpiryDays = 14
+	if v, err := strconv.Atoi(os.Getenv("TLS_EXPIRY_WARNING_DAYS")); err == nil && v > 0 {
+		app.TLSExpiryDays = v
+	}
+
+	app.CheckIntervalClearnet = envSeconds("CHECK_INTERVAL_CLEARNET", 3600)
+	app.CheckIntervalTor = envSeconds("CHECK_INTERVAL_TOR", 7200)
+	app.CheckIntervalI2P = envSeconds("CHECK_INTERVAL_I2P", 7200)
+	app.CheckIntervalMax = envSeconds("CHECK_INTERVAL_MAX", 604800)
 
 	// fiber specific config
 	app.Host = os.Getenv("APP_HOST")
@@ -79,4 +148,29 @@ func LoadApp() {
 	app.AcceptI2P, _ = strconv.ParseBool(os.Getenv("ACCEPT_I2P"))
 	app.I2PSOCKS = os.Getenv("I2P_SOCKS")
 	app.IPv6Capable, _ = strconv.ParseBool(os.Getenv("IPV6_CAPABLE"))
+	app.CheckZMQ, _ = strconv.ParseBool(os.Getenv("CHECK_ZMQ"))
+	app.CheckP2P, _ = strconv.ParseBool(os.Getenv("CHECK_P2P"))
+	app.SOCKSIsolation = true
+	if v, err := strconv.ParseBool(os.Getenv("SOCKS_ISOLATION")); err == nil {
+		app.SOCKSIsolation = v
+	}
+	app.SOCKSRetries = 1
+	if v, err := strconv.Atoi(os.Getenv("SOCKS_RETRIES")); err == nil && v >= 0 {
+		app.SOCKSRetries = v
+	}
+	app.Capacity, _ = strconv.Atoi(os.Getenv("PROBER_CAPACITY"))
+	app.ReportSpoolDir = os.Getenv("REPORT_SPOOL_DIR")
+	app.ReportSpoolSize = 1000
+	if v, err := strconv.Atoi(os.Getenv("REPORT_SPOOL_SIZE")); err == nil && v >= 0 {
+		app.ReportSpoolSize = v
+	}
+}
+
+// envSeconds returns positive number of seconds from the environment
+// variable, def if not set or invalid
+func envSeconds(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
 }
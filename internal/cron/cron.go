@@ -1,72 +1,302 @@
 package cron
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ditatompel/xmr-remote-nodes/internal/database"
-	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
 )
 
 type cronRepo struct {
 	db *database.DB
 }
 
+// Cron task states stored in `cron_state` column
+const (
+	StateIdle    = 0
+	StateRunning = 1
+	StateError   = 2 // last run returned an error, see `last_error` column
+)
+
 type Cron struct {
 	ID          int     `json:"id" db:"id"`
 	Title       string  `json:"title" db:"title"`
 	Slug        string  `json:"slug" db:"slug"`
 	Description string  `json:"description" db:"description"`
 	RunEvery    int     `json:"run_every" db:"run_every"`
+	CronExpr    string  `json:"cron_expr" db:"cron_expr"`     // takes precedence over run_every if not empty
+	RunTimeout  int     `json:"run_timeout" db:"run_timeout"` // in seconds
 	LastRun     int64   `json:"last_run" db:"last_run"`
 	NextRun     int64   `json:"next_run" db:"next_run"`
 	RunTime     float64 `json:"run_time" db:"run_time"`
 	CronState   int     `json:"cron_state" db:"cron_state"`
+	LastError   string  `json:"last_error" db:"last_error"`
+	LockedBy    string  `json:"locked_by" db:"locked_by"`
+	LockedUntil int64   `json:"locked_until" db:"locked_until"`
 	IsEnabled   int     `json:"is_enabled" db:"is_enabled"`
 }
 
-var rerunTimeout = 300
+// History represents a single cron task run
+type History struct {
+	ID           int64   `json:"id" db:"id"`
+	CronID       int     `json:"cron_id" db:"cron_id"`
+	Slug         string  `json:"slug" db:"slug"`
+	TriggeredBy  string  `json:"triggered_by" db:"triggered_by"` // schedule | manual
+	StartTS      int64   `json:"start_ts" db:"start_ts"`
+	EndTS        int64   `json:"end_ts" db:"end_ts"`
+	RunTime      float64 `json:"run_time" db:"run_time"`
+	RowsAffected int64   `json:"rows_affected" db:"rows_affected"`
+	Error        string  `json:"error" db:"error"`
+}
+
+var (
+	// cycleInterval is how often the cron process checks for due tasks
+	cycleInterval = 60 * time.Second
+	// defaultRunTimeout is used when task run_timeout is not set
+	defaultRunTimeout = 300 * time.Second
+	// historyRetention is how long cron run history is kept
+	historyRetention = 30 * 24 * time.Hour
+)
 
 func New() *cronRepo {
 	return &cronRepo{db: database.GetDB()}
 }
 
+// RunCronProcess runs due cron tasks every cycleInterval until c is closed.
+//
+// Multiple server replicas can run the cron process at the same time: only
+// the instance holding the leader lease queues tasks, and every task run is
+// guarded by its own DB lock so the same task never overlaps. Due tasks run
+// in parallel, except tasks registered with After which run after the tasks
+// they depend on. Each task is cancelled when it exceeds its run_timeout.
 func (r *cronRepo) RunCronProcess(c chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	holder := instanceID()
+	ticker := time.NewTicker(cycleInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
 	for {
 		select {
-		case <-time.After(60 * time.Second):
-			slog.Info("[CRON] Running cron cycle...")
-			list, err := r.queueList()
-			if err != nil {
-				slog.Warn(fmt.Sprintf("[CRON] Error parsing queue list to struct: %s", err))
-				continue
-			}
-			for _, task := range list {
-				startTime := time.Now()
-				currentTs := startTime.Unix()
-				delayedTask := currentTs - task.NextRun
-				if task.CronState == 1 && delayedTask <= int64(rerunTimeout) {
+		case <-ticker.C:
+			r.runCycle(ctx, &wg, holder)
+		case <-c:
+			slog.Info("[CRON] Shutting down cron...")
+			cancel()
+			wg.Wait()
+			r.releaseLeader(holder)
+			return
+		}
+	}
+}
+
+func (r *cronRepo) runCycle(ctx context.Context, wg *sync.WaitGroup, holder string) {
+	leader, err := r.acquireLeader(ctx, holder, time.Now())
+	if err != nil {
+		slog.Warn(fmt.Sprintf("[CRON] Failed to acquire leader lease: %s", err))
+		return
+	}
+	if !leader {
+		slog.Debug("[CRON] Another instance holds the leader lease, skipping cycle")
+		return
+	}
+
+	slog.Info("[CRON] Running cron cycle...")
+	list, err := r.queueList()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("[CRON] Error parsing queue list to struct: %s", err))
+		return
+	}
+	for _, group := range runGroups(list) {
+		wg.Add(1)
+		go func(group []Cron) {
+			defer wg.Done()
+			for _, task := range group {
+				// claim right before running so the lock of a task waiting
+				// for its dependencies does not expire while it waits
+				claimed, err := r.claimTask(ctx, task, holder, time.Now())
+				if err != nil {
+					slog.Warn(fmt.Sprintf("[CRON] Failed to lock task %s: %s", task.Slug, err))
+					continue
+				}
+				if !claimed {
 					slog.Debug(fmt.Sprintf("[CRON] Skipping task %s because it is already running", task.Slug))
 					continue
 				}
+				r.runTask(ctx, task, holder, "schedule")
+			}
+		}(group)
+	}
+	r.pruneHistory()
+}
 
-				r.preRunTask(task.ID, currentTs)
-				r.execCron(task.Slug)
+// runGroups splits due tasks into groups of related tasks (see After). Tasks
+// in a group are ordered so each task comes after the tasks it depends on,
+// different groups are unrelated and can run in parallel.
+func runGroups(list []Cron) [][]Cron {
+	due := make(map[string]Cron, len(list))
+	for _, task := range list {
+		due[task.Slug] = task
+	}
 
-				runTime := math.Ceil(time.Since(startTime).Seconds()*1000) / 1000
-				slog.Info(fmt.Sprintf("[CRON] Task %s done in %f seconds", task.Slug, runTime))
-				nextRun := currentTs + int64(task.RunEvery)
+	// order due tasks so dependencies come first, keeping the queue order
+	// otherwise
+	ordered := make([]Cron, 0, len(list))
+	visited := make(map[string]bool, len(list))
+	var visit func(task Cron)
+	visit = func(task Cron) {
+		if visited[task.Slug] {
+			return
+		}
+		visited[task.Slug] = true
+		for _, dep := range dependencies(task.Slug) {
+			if t, ok := due[dep]; ok {
+				visit(t)
+			}
+		}
+		ordered = append(ordered, task)
+	}
+	for _, task := range list {
+		visit(task)
+	}
 
-				r.postRunTask(task.ID, nextRun, runTime)
+	// tasks sharing a dependency chain end up in the same group
+	root := make(map[string]string, len(list))
+	var find func(slug string) string
+	find = func(slug string) string {
+		if p, ok := root[slug]; ok && p != slug {
+			root[slug] = find(p)
+			return root[slug]
+		}
+		return slug
+	}
+	for _, task := range ordered {
+		root[task.Slug] = find(task.Slug)
+		for _, dep := range dependencies(task.Slug) {
+			if _, ok := due[dep]; ok {
+				root[find(task.Slug)] = find(dep)
 			}
-			slog.Info("[CRON] Cron cycle done!")
-		case <-c:
-			slog.Info("[CRON] Shutting down cron...")
-			return
 		}
 	}
+
+	var groups [][]Cron
+	index := make(map[string]int)
+	for _, task := range ordered {
+		r := find(task.Slug)
+		i, ok := index[r]
+		if !ok {
+			i = len(groups)
+			index[r] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], task)
+	}
+
+	return groups
+}
+
+// timeout returns maximum duration of a single task run
+func (task Cron) timeout() time.Duration {
+	if task.RunTimeout <= 0 {
+		return defaultRunTimeout
+	}
+	return time.Duration(task.RunTimeout) * time.Second
+}
+
+// nextRun calculates next run timestamp of the task started at t
+func (task Cron) nextRun(t time.Time) int64 {
+	if task.CronExpr != "" {
+		sched, err := ParseSchedule(task.CronExpr)
+		if err == nil {
+			next, err := sched.Next(t)
+			if err == nil {
+				return next.Unix()
+			}
+		}
+		slog.Warn(fmt.Sprintf("[CRON] Invalid schedule %q for task %s, falling back to run_every: %s", task.CronExpr, task.Slug, err))
+	}
+	return t.Unix() + int64(task.RunEvery)
+}
+
+// runTask executes the registered task function, updates the task state and
+// stores the run history. The task must be claimed by holder before calling
+// runTask.
+func (r *cronRepo) runTask(ctx context.Context, task Cron, holder, trigger string) History {
+	startTime := time.Now()
+	h := History{
+		CronID:      task.ID,
+		Slug:        task.Slug,
+		TriggeredBy: trigger,
+		StartTS:     startTime.Unix(),
+	}
+	slog.Info(fmt.Sprintf("[CRON] Start running task: %s", task.Slug))
+
+	taskCtx, cancel := context.WithTimeout(ctx, task.timeout())
+	defer cancel()
+
+	var err error
+	if fn, ok := lookupTask(task.Slug); ok {
+		h.RowsAffected, err = safeRun(taskCtx, fn)
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", task.timeout(), err)
+		}
+	} else {
+		err = fmt.Errorf("task %q is not registered", task.Slug)
+	}
+
+	h.RunTime = math.Ceil(time.Since(startTime).Seconds()*1000) / 1000
+	h.EndTS = time.Now().Unix()
+	if err != nil {
+		h.Error = err.Error()
+		slog.Error(fmt.Sprintf("[CRON] Task %s failed: %s", task.Slug, err))
+	} else {
+		slog.Info(fmt.Sprintf("[CRON] Task %s done in %f seconds", task.Slug, h.RunTime))
+	}
+
+	r.postRunTask(task.ID, holder, task.nextRun(startTime), h.RunTime, h.Error)
+	r.addHistory(h)
+
+	return h
+}
+
+// safeRun runs the task function and converts panic to error so a single
+// broken task does not stop the cron process.
+func safeRun(ctx context.Context, fn TaskFunc) (rows int64, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Run immediately executes cron task identified by slug regardless of its
+// schedule and enabled state.
+func (r *cronRepo) Run(slug string) (History, error) {
+	task, err := r.Cron(slug)
+	if err != nil {
+		return History{}, err
+	}
+	holder := instanceID()
+	claimed, err := r.claimTask(context.Background(), task, holder, time.Now())
+	if err != nil {
+		return History{}, err
+	}
+	if !claimed {
+		return History{}, fmt.Errorf("task %q is already running on %s", slug, task.LockedBy)
+	}
+	h := r.runTask(context.Background(), task, holder, "manual")
+	if h.Error != "" {
+		return h, errors.New(h.Error)
+	}
+	return h, nil
 }
 
 func (r *cronRepo) Crons() ([]Cron, error) {
@@ -78,145 +308,256 @@ func (r *cronRepo) Crons() ([]Cron, error) {
 			slug,
 			description,
 			run_every,
+			cron_expr,
+			run_timeout,
 			last_run,
 			next_run,
 			run_time,
 			cron_state,
+			last_error,
+			locked_by,
+			locked_until,
 			is_enabled
 		FROM
 			tbl_cron`)
 	return tasks, err
 }
 
-func (r *cronRepo) queueList() ([]Cron, error) {
-	tasks := []Cron{}
-	query := `
+// Cron returns cron task identified by slug
+func (r *cronRepo) Cron(slug string) (Cron, error) {
+	var task Cron
+	err := r.db.Get(&task, `
 		SELECT
 			id,
+			title,
+			slug,
+			description,
 			run_every,
+			cron_expr,
+			run_timeout,
 			last_run,
-			slug,
 			next_run,
-			cron_state
+			run_time,
+			cron_state,
+			last_error,
+			locked_by,
+			locked_until,
+			is_enabled
 		FROM
 			tbl_cron
 		WHERE
-			is_enabled = ?
-			AND next_run <= ?`
-	err := r.db.Select(&tasks, query, 1, time.Now().Unix())
+			slug = ?`, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task, fmt.Errorf("no cron task with slug %q", slug)
+	}
+	return task, err
+}
 
-	return tasks, err
+// SetEnabled enables or disables cron task identified by slug
+func (r *cronRepo) SetEnabled(slug string, enabled bool) error {
+	isEnabled := 0
+	if enabled {
+		isEnabled = 1
+	}
+	res, err := r.db.Exec(`UPDATE tbl_cron SET is_enabled = ? WHERE slug = ?`, isEnabled, slug)
+	if err != nil {
+		return err
+	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		return fmt.Errorf("no cron task with slug %q or state unchanged", slug)
+	}
+	return nil
 }
 
-func (r *cronRepo) preRunTask(id int, lastRunTs int64) {
-	query := `
+// SetInterval changes how often (in seconds) cron task identified by slug
+// runs and removes its cron expression schedule (if any). The next run is
+// rescheduled relative to the last run.
+func (r *cronRepo) SetInterval(slug string, seconds int) error {
+	if seconds < 60 {
+		return errors.New("interval must be at least 60 seconds")
+	}
+	res, err := r.db.Exec(`
 		UPDATE tbl_cron
 		SET
-			cron_state = ?,
-			last_run = ?
+			run_every = ?,
+			cron_expr = '',
+			next_run = last_run + ?
 		WHERE
-			id = ?`
-	row, err := r.db.Query(query, 1, lastRunTs, id)
+			slug = ?`, seconds, seconds, slug)
+	if err != nil {
+		return err
+	}
+	row, err := res.RowsAffected()
 	if err != nil {
-		slog.Error(fmt.Sprintf("[CRON] Failed to update pre cron state: %s", err))
+		return err
+	}
+	if row == 0 {
+		return fmt.Errorf("no cron task with slug %q or interval unchanged", slug)
+	}
+	return nil
+}
+
+// SetSchedule sets cron expression schedule of task identified by slug, see
+// Schedule for supported syntax. Empty expr removes the schedule so the task
+// runs every `run_every` seconds again.
+func (r *cronRepo) SetSchedule(slug, expr string) error {
+	task, err := r.Cron(slug)
+	if err != nil {
+		return err
+	}
+	task.CronExpr = strings.TrimSpace(expr)
+	if task.CronExpr != "" {
+		if _, err := ParseSchedule(task.CronExpr); err != nil {
+			return err
+		}
+	}
+	// interval schedule is relative to the last run, cron expression is
+	// relative to now
+	base := time.Unix(task.LastRun, 0)
+	if task.CronExpr != "" {
+		base = time.Now()
+	}
+	_, err = r.db.Exec(`
+		UPDATE tbl_cron
+		SET
+			cron_expr = ?,
+			next_run = ?
+		WHERE
+			id = ?`, task.CronExpr, task.nextRun(base), task.ID)
+	return err
+}
+
+// SetTimeout changes maximum run time (in seconds) of task identified by
+// slug. Task exceeding its timeout is cancelled.
+func (r *cronRepo) SetTimeout(slug string, seconds int) error {
+	if seconds < 1 {
+		return errors.New("timeout must be at least 1 second")
+	}
+	res, err := r.db.Exec(`UPDATE tbl_cron SET run_timeout = ? WHERE slug = ?`, seconds, slug)
+	if err != nil {
+		return err
+	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		return fmt.Errorf("no cron task with slug %q or timeout unchanged", slug)
 	}
-	defer row.Close()
+	return nil
 }
 
-func (r *cronRepo) postRunTask(id int, nextRun int64, runtime float64) {
+// History returns latest run history of cron task identified by slug. If slug
+// is empty, run history of all tasks is returned.
+func (r *cronRepo) History(slug string, limit int) ([]History, error) {
+	args := []interface{}{}
+	where := ""
+	if slug != "" {
+		where = "WHERE c.slug = ?"
+		args = append(args, slug)
+	}
+	args = append(args, limit)
+
+	history := []History{}
+	err := r.db.Select(&history, fmt.Sprintf(`
+		SELECT
+			h.id,
+			h.cron_id,
+			c.slug,
+			h.triggered_by,
+			h.start_ts,
+			h.end_ts,
+			h.run_time,
+			h.rows_affected,
+			h.error
+		FROM
+			tbl_cron_history h
+			JOIN tbl_cron c ON c.id = h.cron_id
+		%s
+		ORDER BY
+			h.id DESC
+		LIMIT ?`, where), args...)
+	return history, err
+}
+
+func (r *cronRepo) queueList() ([]Cron, error) {
+	tasks := []Cron{}
+	now := time.Now().Unix()
+	query := `
+		SELECT
+			id,
+			run_every,
+			cron_expr,
+			run_timeout,
+			last_run,
+			slug,
+			next_run,
+			cron_state,
+			locked_until
+		FROM
+			tbl_cron
+		WHERE
+			is_enabled = ?
+			AND next_run <= ?
+			AND locked_until < ?`
+	err := r.db.Select(&tasks, query, 1, now, now)
+
+	return tasks, err
+}
+
+func (r *cronRepo) postRunTask(id int, holder string, nextRun int64, runtime float64, lastError string) {
+	state := StateIdle
+	if lastError != "" {
+		state = StateError
+	}
 	query := `
 		UPDATE tbl_cron
 		SET
 			cron_state = ?,
+			last_error = ?,
 			next_run = ?,
-			run_time = ?
+			run_time = ?,
+			locked_by = '',
+			locked_until = 0
 		WHERE
-			id = ?`
-	row, err := r.db.Query(query, 0, nextRun, runtime, id)
-	if err != nil {
+			id = ?
+			AND locked_by = ?`
+	if _, err := r.db.Exec(query, state, lastError, nextRun, runtime, id, holder); err != nil {
 		slog.Error(fmt.Sprintf("[CRON] Failed to update post cron state: %s", err))
 	}
-	defer row.Close()
-}
-
-func (r *cronRepo) execCron(slug string) {
-	switch slug {
-	case "delete_old_probe_logs":
-		slog.Info(fmt.Sprintf("[CRON] Start running task: %s", slug))
-		r.deleteOldProbeLogs()
-	case "calculate_majority_fee":
-		slog.Info(fmt.Sprintf("[CRON] Start running task: %s", slug))
-		r.calculateMajorityFee()
-	case "fetch_rucknium_node_data":
-		slog.Info(fmt.Sprintf("[CRON] Start running task: %s", slug))
-		if err := monero.New().FetchRuckniumNodeData(); err != nil {
-			slog.Error(fmt.Sprintf("[CRON] Failed to fetch Rucknium's API: %s", err))
-		}
-	case "check_mrl_ban_list":
-		slog.Info(fmt.Sprintf("[CRON] Start running task: %s", slug))
-		if err := monero.New().CheckMRLBan(); err != nil {
-			slog.Error(fmt.Sprintf("[CRON] Error when running MRL check ban list: %s", err))
-		}
-	case "fetch_static_mrl_ban_list":
-		slog.Info(fmt.Sprintf("[CRON] Start running task: %s", slug))
-		if err := monero.New().FetchBoog900BanList(); err != nil {
-			slog.Error(fmt.Sprintf("[CRON] Failed to fetch static MRL ban list: %s", err))
-		}
-
-	}
 }
 
-func (r *cronRepo) deleteOldProbeLogs() {
-	// for now, we only delete stats older than 1 month +2 days
-	startTs := time.Now().AddDate(0, -1, -2).Unix()
-	query := `DELETE FROM tbl_probe_log WHERE date_checked < ?`
-	_, err := r.db.Exec(query, startTs)
+func (r *cronRepo) addHistory(h History) {
+	_, err := r.db.Exec(`
+		INSERT INTO tbl_cron_history (
+			cron_id,
+			triggered_by,
+			start_ts,
+			end_ts,
+			run_time,
+			rows_affected,
+			error
+		) VALUES (
+			?,
+			?,
+			?,
+			?,
+			?,
+			?,
+			?
+		)`, h.CronID, h.TriggeredBy, h.StartTS, h.EndTS, h.RunTime, h.RowsAffected, h.Error)
 	if err != nil {
-		slog.Error(fmt.Sprintf("[CRON] Failed to delete old probe logs: %s", err))
-	}
-}
-
-func (r *cronRepo) calculateMajorityFee() {
-	netTypes := [3]string{"mainnet", "stagenet", "testnet"}
-	for _, net := range netTypes {
-		row, err := r.db.Query(`
-			SELECT
-				COUNT(id) AS node_count,
-				nettype,
-				estimate_fee
-			FROM
-				tbl_node
-			WHERE
-				nettype = ?
-			GROUP BY
-				estimate_fee
-			ORDER BY
-				node_count DESC
-			LIMIT 1`, net)
-		if err != nil {
-			slog.Error(fmt.Sprintf("[CRON] Failed to calculate majority fee: %s", err))
-		}
-		defer row.Close()
-
-		var (
-			nettype     string
-			estimateFee int
-			nodeCount   int
-		)
-
-		for row.Next() {
-			err = row.Scan(&nodeCount, &nettype, &estimateFee)
-			if err != nil {
-				slog.Error(fmt.Sprintf("[CRON] Failed to calculate majority fee: %s", err))
-				continue
-			}
+		slog.Error(fmt.Sprintf("[CRON] Failed to store cron history: %s", err))
+	}
+}
 
-			query := `UPDATE tbl_fee SET estimate_fee = ?, node_count = ? WHERE nettype = ?`
-			_, err = r.db.Exec(query, estimateFee, nodeCount, nettype)
-			if err != nil {
-				slog.Error(fmt.Sprintf("[CRON] Failed to update majority fee: %s", err))
-				continue
-			}
-		}
+func (r *cronRepo) pruneHistory() {
+	startTs := time.Now().Add(-historyRetention).Unix()
+	if _, err := r.db.Exec(`DELETE FROM tbl_cron_history WHERE start_ts < ?`, startTs); err != nil {
+		slog.Error(fmt.Sprintf("[CRON] Failed to delete old cron history: %s", err))
 	}
 }
@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		due  []string
+		want [][]string
+	}{
+		{
+			name: "Unrelated tasks run in parallel",
+			due:  []string{"delete_old_probe_logs", "calculate_majority_fee"},
+			want: [][]string{{"delete_old_probe_logs"}, {"calculate_majority_fee"}},
+		},
+		{
+			name: "Dependent task runs after its dependency",
+			due:  []string{"check_mrl_ban_list", "calculate_majority_fee", "fetch_rucknium_node_data"},
+			want: [][]string{{"fetch_rucknium_node_data", "check_mrl_ban_list"}, {"calculate_majority_fee"}},
+		},
+		{
+			name: "Each chain is its own group",
+			due: []string{
+				"recheck_banned_nodes",
+				"check_mrl_ban_list",
+				"fetch_static_mrl_ban_list",
+				"fetch_rucknium_node_data",
+			},
+			want: [][]string{
+				{"fetch_static_mrl_ban_list", "recheck_banned_nodes"},
+				{"fetch_rucknium_node_data", "check_mrl_ban_list"},
+			},
+		},
+		{
+			name: "Dependency not due",
+			due:  []string{"check_mrl_ban_list"},
+			want: [][]string{{"check_mrl_ban_list"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := make([]Cron, len(tt.due))
+			for i, slug := range tt.due {
+				list[i] = Cron{Slug: slug}
+			}
+			var got [][]string
+			for _, group := range runGroups(list) {
+				var slugs []string
+				for _, task := range group {
+					slugs = append(slugs, task.Slug)
+				}
+				got = append(got, slugs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runGroups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const leaderLockName = "cron_leader"
+
+var (
+	// leaderLeaseTTL must be longer than cycleInterval so the leader keeps
+	// its lease between cycles.
+	leaderLeaseTTL = 3 * time.Minute
+	// lockGrace is added to task timeout before the task lock expires, so
+	// the task has time to record its result after being cancelled.
+	lockGrace = 60 * time.Second
+)
+
+// instanceID identifies the current process as lease and lock holder
+func instanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
+// acquireLeader acquires or renews the cron leader lease for holder and
+// reports whether holder is the current leader.
+//
+// The lease is taken over only when it is expired, so if the leader dies
+// another instance becomes the leader after leaderLeaseTTL.
+func (r *cronRepo) acquireLeader(ctx context.Context, holder string, now time.Time) (bool, error) {
+	// MySQL evaluates single table UPDATE assignments from left to right, so
+	// `expires_ts` is only renewed when `holder` is (now) ours.
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tbl_cron_lock (
+			name,
+			holder,
+			expires_ts
+		) VALUES (
+			?,
+			?,
+			?
+		)
+		ON DUPLICATE KEY UPDATE
+			holder = IF(expires_ts < ? OR holder = VALUES(holder), VALUES(holder), holder),
+			expires_ts = IF(holder = VALUES(holder), VALUES(expires_ts), expires_ts)`,
+		leaderLockName, holder, now.Add(leaderLeaseTTL).Unix(), now.Unix())
+	if err != nil {
+		return false, err
+	}
+
+	var current string
+	err = r.db.GetContext(ctx, &current, `SELECT holder FROM tbl_cron_lock WHERE name = ?`, leaderLockName)
+	if err != nil {
+		return false, err
+	}
+
+	return current == holder, nil
+}
+
+// releaseLeader expires the leader lease (if held by holder) so another
+// instance can take over immediately.
+func (r *cronRepo) releaseLeader(holder string) {
+	_, err := r.db.Exec(`
+		UPDATE tbl_cron_lock
+		SET
+			expires_ts = 0
+		WHERE
+			name = ?
+			AND holder = ?`, leaderLockName, holder)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("[CRON] Failed to release leader lease: %s", err))
+	}
+}
+
+// claimTask atomically locks the task for holder and marks it as running.
+// It reports false if the task is locked by another run which has not yet
+// expired.
+func (r *cronRepo) claimTask(ctx context.Context, task Cron, holder string, now time.Time) (bool, error) {
+	lockedUntil := now.Add(task.timeout() + lockGrace).Unix()
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE tbl_cron
+		SET
+			cron_state = ?,
+			last_run = ?,
+			locked_by = ?,
+			locked_until = ?
+		WHERE
+			id = ?
+			AND locked_until < ?`, StateRunning, now.Unix(), holder, lockedUntil, task.ID, now.Unix())
+	if err != nil {
+		return false, err
+	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return row == 1, nil
+}
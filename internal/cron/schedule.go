@@ -0,0 +1,175 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed standard 5-field cron expression:
+//
+//	┌───────────── minute (0-59)
+//	│ ┌───────────── hour (0-23)
+//	│ │ ┌───────────── day of the month (1-31)
+//	│ │ │ ┌───────────── month (1-12)
+//	│ │ │ │ ┌───────────── day of the week (0-6, Sunday = 0 or 7)
+//	│ │ │ │ │
+//	* * * * *
+//
+// Each field supports `*`, single values, lists (`1,15`), ranges (`1-5`) and
+// steps (`*/10`, `0-30/5`). The `@hourly`, `@daily`, `@weekly`, `@monthly`
+// and `@yearly` macros are also accepted. Schedules are evaluated in UTC.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // bitsets
+	domStar, dowStar              bool
+}
+
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteBounds = fieldBounds{"minute", 0, 59}
+	hourBounds   = fieldBounds{"hour", 0, 23}
+	domBounds    = fieldBounds{"day of month", 1, 31}
+	monthBounds  = fieldBounds{"month", 1, 12}
+	dowBounds    = fieldBounds{"day of week", 0, 7}
+)
+
+var scheduleMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses cron expression, see Schedule for supported syntax.
+func ParseSchedule(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if m, ok := scheduleMacros[expr]; ok {
+		expr = m
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return Schedule{}, err
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return Schedule{}, err
+	}
+	// Sunday can be written as 0 or 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+func parseField(field string, b fieldBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepStr, b.name)
+			}
+		}
+
+		lo, hi := b.min, b.max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s field", from, b.name)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s field", to, b.name)
+				}
+			} else if hasStep {
+				// `5/15` means starting at 5 until the end of the range
+				hi = b.max
+			}
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("%s field value %q out of range %d-%d", b.name, part, b.min, b.max)
+		}
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// errNoNextRun should never happen with valid schedule, but protects Next
+// from looping forever (eg. `0 0 31 2 *`).
+var errNoNextRun = errors.New("cron expression never matches")
+
+// Next returns the next activation time after t (truncated to minutes) in
+// UTC.
+func (s Schedule) Next(t time.Time) (time.Time, error) {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// 5 years is more than enough to find any valid match, including leap day
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+
+	return time.Time{}, errNoNextRun
+}
+
+// dayMatches follows the standard cron behaviour: if both day of month and
+// day of week are restricted, the day matches when either of them matches.
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
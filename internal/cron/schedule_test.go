@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"*/5 * * * *", false},
+		{"0,30 1-5 * * 1-5", false},
+		{"5/15 * * * *", false},
+		{"0 0 * * 7", false},
+		{"@daily", false},
+		{"* * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"*/0 * * * *", true},
+		{"5-1 * * * *", true},
+		{"a * * * *", true},
+		{"@every 1h", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseSchedule(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSchedule(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday, 15 January 2025 10:07:30 UTC
+	from := time.Date(2025, 1, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2025, 1, 15, 10, 10, 0, 0, time.UTC)},
+		{"7 * * * *", time.Date(2025, 1, 15, 11, 7, 0, 0, time.UTC)},
+		{"30 1 * * *", time.Date(2025, 1, 16, 1, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		// day of month OR day of week when both are restricted
+		{"0 0 20 * 5", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Next(from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Schedule(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+
+	s, _ := ParseSchedule("0 0 31 2 *")
+	if _, err := s.Next(from); err == nil {
+		t.Error("Schedule.Next() for impossible date should return error")
+	}
+}
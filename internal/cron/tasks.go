@@ -0,0 +1,200 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+)
+
+// TaskFunc is a function executed by the cron runner. The context is
+// cancelled when the task exceeds its timeout or the server is shutting down.
+// It returns the number of rows affected by the task, which is stored in the
+// task run history.
+type TaskFunc func(ctx context.Context) (rowsAffected int64, err error)
+
+var (
+	tasksMu sync.RWMutex
+	tasks   = make(map[string]task)
+)
+
+type task struct {
+	fn    TaskFunc
+	after []string
+}
+
+// TaskOption configures a task registered with Register
+type TaskOption func(*task)
+
+// After makes the task run after the given tasks when they are due in the same
+// cron cycle. Related tasks run one after another, unrelated tasks run in
+// parallel.
+func After(slugs ...string) TaskOption {
+	return func(t *task) {
+		t.after = append(t.after, slugs...)
+	}
+}
+
+// Register makes a cron task available to the cron runner by its slug.
+//
+// The slug must match the `slug` column of `tbl_cron`. Register panics if the
+// same slug is registered twice.
+func Register(slug string, fn TaskFunc, opts ...TaskOption) {
+	tasksMu.Lock()
+	defer tasksMu.Unlock()
+
+	if fn == nil {
+		panic("cron: Register task func is nil")
+	}
+	if _, dup := tasks[slug]; dup {
+		panic("cron: Register called twice for task " + slug)
+	}
+	t := task{fn: fn}
+	for _, opt := range opts {
+		opt(&t)
+	}
+	tasks[slug] = t
+}
+
+// Registered returns sorted list of registered task slugs
+func Registered() []string {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+
+	slugs := make([]string, 0, len(tasks))
+	for slug := range tasks {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	return slugs
+}
+
+func lookupTask(slug string) (TaskFunc, bool) {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+
+	t, ok := tasks[slug]
+	return t.fn, ok
+}
+
+// dependencies returns slugs of tasks the given task runs after
+func dependencies(slug string) []string {
+	tasksMu.RLock()
+	defer tasksMu.RUnlock()
+
+	return tasks[slug].after
+}
+
+func init() {
+	Register("delete_old_probe_logs", func(ctx context.Context) (int64, error) {
+		return New().deleteOldProbeLogs(ctx)
+	})
+	Register("calculate_majority_fee", func(ctx context.Context) (int64, error) {
+		return New().calculateMajorityFee(ctx)
+	})
+	Register("fetch_rucknium_node_data", func(ctx context.Context) (int64, error) {
+		url := config.AppCfg().RuckniumAPIURL
+		if url == "" {
+			url = monero.DefaultRuckniumAPIURL
+		}
+		return monero.New().FetchRuckniumNodeData(ctx, externalFetcher(), url)
+	})
+	Register("check_mrl_ban_list", func(ctx context.Context) (int64, error) {
+		return monero.New().CheckMRLBan(ctx)
+	}, After("fetch_rucknium_node_data"))
+	Register("fetch_static_mrl_ban_list", func(ctx context.Context) (int64, error) {
+		sources := config.AppCfg().BanListSources
+		if sources == "" {
+			sources = monero.DefaultBanListSources
+		}
+		list, err := monero.ParseBanListSources(sources)
+		if err != nil {
+			return 0, err
+		}
+		moneroRepo := monero.New()
+		rows, err := moneroRepo.FetchBanLists(ctx, externalFetcher(), list)
+		if err != nil {
+			return rows, err
+		}
+		// re-evaluate monitored nodes right away against the new list
+		if _, err := moneroRepo.RecheckBannedNodes(ctx, config.AppCfg().BanListAutoArchive); err != nil {
+			return rows, fmt.Errorf("recheck banned nodes: %w", err)
+		}
+		return rows, nil
+	})
+	Register("recheck_banned_nodes", func(ctx context.Context) (int64, error) {
+		return monero.New().RecheckBannedNodes(ctx, config.AppCfg().BanListAutoArchive)
+	}, After("fetch_static_mrl_ban_list"))
+	Register("calculate_node_latency", func(ctx context.Context) (int64, error) {
+		return monero.New().CalculateLatency(ctx)
+	})
+}
+
+// externalFetcher returns fetcher for external data sources, configured
+// from the app config. Cache validators are stored in the database.
+func externalFetcher() *fetcher.Fetcher {
+	cfg := config.AppCfg()
+	return fetcher.New(
+		fetcher.WithCache(fetcher.NewDBCache()),
+		fetcher.WithTimeout(time.Duration(cfg.FetchTimeout)*time.Second),
+		fetcher.WithMaxBytes(cfg.FetchMaxBytes),
+	)
+}
+
+func (r *cronRepo) deleteOldProbeLogs(ctx context.Context) (int64, error) {
+	// for now, we only delete stats older than 1 month +2 days
+	startTs := time.Now().AddDate(0, -1, -2).Unix()
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tbl_probe_log WHERE date_checked < ?`, startTs)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *cronRepo) calculateMajorityFee(ctx context.Context) (int64, error) {
+	var affected int64
+	netTypes := [3]string{"mainnet", "stagenet", "testnet"}
+	for _, net := range netTypes {
+		var (
+			nodeCount   int
+			nettype     string
+			estimateFee int
+		)
+		err := r.db.QueryRowContext(ctx, `
+			SELECT
+				COUNT(id) AS node_count,
+				nettype,
+				estimate_fee
+			FROM
+				tbl_node
+			WHERE
+				nettype = ?
+			GROUP BY
+				estimate_fee
+			ORDER BY
+				node_count DESC
+			LIMIT 1`, net).Scan(&nodeCount, &nettype, &estimateFee)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("[CRON] Failed to calculate %s majority fee: %s", net, err))
+			continue
+		}
+
+		query := `UPDATE tbl_fee SET estimate_fee = ?, node_count = ? WHERE nettype = ?`
+		res, err := r.db.ExecContext(ctx, query, estimateFee, nodeCount, nettype)
+		if err != nil {
+			return affected, err
+		}
+		if row, err := res.RowsAffected(); err == nil {
+			affected += row
+		}
+	}
+
+	return affected, nil
+}
@@ -7,7 +7,7 @@ import (
 
 type migrateFn func(*DB) error
 
-var dbMigrate = [...]migrateFn{v1, v2, v3, v4, v5, v6}
+var dbMigrate = [...]migrateFn{v1, v2, v3, v4, v5, v6, v7, v8, v9, v10, v11, v12, v13, v14, v15, v16, v17, v18, v19, v20, v21, v22, v23, v24, v25, v26, v27, v28}
 
 func MigrateDb(db *DB) error {
 	version := getSchemaVersion(db)
@@ -409,3 +409,650 @@ func v6(db *DB) error {
 
 	return nil
 }
+
+func v7(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 7")
+
+	// table: tbl_admin
+	slog.Debug("[DB] Creating table: tbl_admin")
+	_, err := db.Exec(`
+		CREATE TABLE tbl_admin (
+			id INT(9) UNSIGNED NOT NULL AUTO_INCREMENT,
+			username VARCHAR(100) NOT NULL,
+			password_hash VARCHAR(255) NOT NULL,
+			totp_secret VARCHAR(64) NOT NULL,
+			last_login_ts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			date_created INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (id),
+			UNIQUE KEY (username)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_admin_audit_log
+	slog.Debug("[DB] Creating table: tbl_admin_audit_log")
+	_, err = db.Exec(`
+		CREATE TABLE tbl_admin_audit_log (
+			id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+			admin_id INT(9) UNSIGNED NOT NULL DEFAULT 0,
+			username VARCHAR(100) NOT NULL DEFAULT '',
+			action VARCHAR(100) NOT NULL,
+			target VARCHAR(255) NOT NULL DEFAULT '',
+			detail TEXT NOT NULL DEFAULT '',
+			ip_addr VARCHAR(200) NOT NULL DEFAULT '',
+			date_created INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (id),
+			KEY (admin_id),
+			KEY (action)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v8(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 8")
+
+	// table: tbl_cron
+	// cron_state 2 means the last run returned an error
+	slog.Debug("[DB] Adding last_error column to tbl_cron")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_cron
+		MODIFY COLUMN cron_state TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 COMMENT '0 = idle, 1 = running, 2 = error',
+		ADD COLUMN last_error TEXT NOT NULL DEFAULT '' AFTER cron_state
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_cron_history
+	slog.Debug("[DB] Creating table: tbl_cron_history")
+	_, err = db.Exec(`
+		CREATE TABLE tbl_cron_history (
+			id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+			cron_id INT(8) UNSIGNED NOT NULL,
+			triggered_by VARCHAR(20) NOT NULL DEFAULT 'schedule' COMMENT 'schedule | manual',
+			start_ts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			end_ts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			run_time FLOAT(7,3) UNSIGNED NOT NULL DEFAULT 0.000,
+			rows_affected BIGINT(20) UNSIGNED NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (id),
+			KEY (cron_id),
+			KEY (start_ts)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v9(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 9")
+
+	// table: tbl_cron
+	// locked_by and locked_until prevent the same task from running on more
+	// than one server instance at the same time.
+	slog.Debug("[DB] Adding schedule and lock columns to tbl_cron")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_cron
+		ADD COLUMN cron_expr VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'takes precedence over run_every' AFTER run_every,
+		ADD COLUMN run_timeout INT(8) UNSIGNED NOT NULL DEFAULT 300 COMMENT 'in seconds' AFTER cron_expr,
+		ADD COLUMN locked_by VARCHAR(255) NOT NULL DEFAULT '' AFTER last_error,
+		ADD COLUMN locked_until INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER locked_by
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// Rucknium's API and static ban list may take a while to download
+	slog.Debug("[DB] Updating run_timeout of fetch cron tasks")
+	_, err = db.Exec(`
+		UPDATE tbl_cron
+		SET
+			run_timeout = 900
+		WHERE
+			slug IN ('fetch_rucknium_node_data', 'fetch_static_mrl_ban_list')
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_cron_lock
+	// Leader lease, only the instance holding the lease runs cron cycle.
+	slog.Debug("[DB] Creating table: tbl_cron_lock")
+	_, err = db.Exec(`
+		CREATE TABLE tbl_cron_lock (
+			name VARCHAR(100) NOT NULL,
+			holder VARCHAR(255) NOT NULL DEFAULT '',
+			expires_ts INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (name)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v10(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 10")
+
+	// table: tbl_ban_list
+	// The same IP address or subnet may be listed by more than one source.
+	slog.Debug("[DB] Adding source column to tbl_ban_list")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_ban_list
+		ADD COLUMN source VARCHAR(100) NOT NULL DEFAULT 'boog900' AFTER ip_addr,
+		DROP PRIMARY KEY,
+		ADD PRIMARY KEY (ip_addr, source),
+		ADD KEY (source)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_ban_list_version
+	slog.Debug("[DB] Creating table: tbl_ban_list_version")
+	_, err = db.Exec(`
+		CREATE TABLE tbl_ban_list_version (
+			id INT(11) UNSIGNED NOT NULL AUTO_INCREMENT,
+			sources VARCHAR(255) NOT NULL DEFAULT '',
+			total_entries INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			added INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			removed INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			date_created INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (id)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_ban_list_diff
+	slog.Debug("[DB] Creating table: tbl_ban_list_diff")
+	_, err = db.Exec(`
+		CREATE TABLE tbl_ban_list_diff (
+			id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+			version_id INT(11) UNSIGNED NOT NULL,
+			ip_addr VARCHAR(200) NOT NULL,
+			source VARCHAR(100) NOT NULL,
+			change_type VARCHAR(10) NOT NULL COMMENT 'added | removed',
+			PRIMARY KEY (id),
+			KEY (version_id)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("[DB] Updating ban list cron task")
+	_, err = db.Exec(`
+		UPDATE tbl_cron
+		SET
+			title = 'Fetch ban lists',
+			description = 'Fetch configured ban list sources and store changes to database'
+		WHERE
+			slug = 'fetch_static_mrl_ban_list'
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v11(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 11")
+
+	// table: tbl_node
+	slog.Debug("[DB] Adding ban columns to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN is_banned TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER is_archived,
+		ADD COLUMN ban_reason VARCHAR(255) NOT NULL DEFAULT '' AFTER is_banned,
+		ADD KEY (is_banned)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// Node IP addresses may change after submission and the ban list is
+	// updated regularly, so nodes need to be rechecked periodically.
+	slog.Debug("[DB] Adding recheck banned nodes cron task to tbl_cron")
+	_, err = db.Exec(`
+		INSERT INTO tbl_cron (
+			title,
+			slug,
+			description,
+			run_every
+		) VALUES (
+			'Recheck banned nodes',
+			'recheck_banned_nodes',
+			'Re-evaluate monitored node IP addresses against the ban list',
+			3600
+		);`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v12(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 12")
+
+	// table: tbl_node
+	slog.Debug("[DB] Adding spy_node_ip column to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN spy_node_ip VARCHAR(200) NOT NULL DEFAULT '' AFTER is_spy_node
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v13(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 13")
+
+	// table: tbl_rucknium_scan
+	// Used to look up node scan history across scan dates.
+	slog.Debug("[DB] Adding connected_node_ip key to tbl_rucknium_scan")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_rucknium_scan
+		ADD KEY connected_node_ip (connected_node_ip)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v14(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 14")
+
+	// table: tbl_fetch_cache
+	// HTTP cache validators of external data sources, used to skip importing
+	// unchanged data.
+	slog.Debug("[DB] Creating table: tbl_fetch_cache")
+	_, err := db.Exec(`
+		CREATE TABLE tbl_fetch_cache (
+			location_hash CHAR(64) NOT NULL COMMENT 'sha256 of location',
+			location TEXT NOT NULL,
+			etag VARCHAR(255) NOT NULL DEFAULT '',
+			last_modified VARCHAR(100) NOT NULL DEFAULT '',
+			date_updated INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (location_hash)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v15(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 15")
+
+	// table: tbl_node
+	// GeoIP providers which answered the node IP address lookup
+	slog.Debug("[DB] Adding geo_provider column to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN geo_provider VARCHAR(100) NOT NULL DEFAULT '' AFTER lon
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v16(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 16")
+
+	// table: tbl_node
+	// continent code is used by the network diversity analytics
+	slog.Debug("[DB] Adding continent column to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN continent VARCHAR(2) NOT NULL DEFAULT '' AFTER country_name
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v17(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 17")
+
+	// table: tbl_monero_release
+	// known monerod releases, used to flag outdated and vulnerable nodes
+	slog.Debug("[DB] Creating table: tbl_monero_release")
+	_, err := db.Exec(`
+		CREATE TABLE tbl_monero_release (
+			version VARCHAR(20) NOT NULL COMMENT 'eg. 0.18.3.4',
+			release_date INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			is_fork_minimum TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 COMMENT 'minimum version to follow the current hard fork',
+			is_vulnerable TINYINT(1) UNSIGNED NOT NULL DEFAULT 0,
+			note VARCHAR(255) NOT NULL DEFAULT '',
+			PRIMARY KEY (version)
+		)`)
+	if err != nil {
+		return err
+	}
+	slog.Debug("[DB] Adding default releases to tbl_monero_release")
+	_, err = db.Exec(`
+		INSERT INTO tbl_monero_release (
+			version,
+			release_date,
+			is_fork_minimum,
+			note
+		) VALUES
+			('0.18.0.0', 0, 1, 'Fluorine Fermi, hard fork v15 and v16'),
+			('0.18.3.4', 0, 0, '')`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_node
+	slog.Debug("[DB] Adding version_status column to tbl_node")
+	_, err = db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN version_status VARCHAR(20) NOT NULL DEFAULT '' AFTER version,
+		ADD KEY version_status (version_status)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v18(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 18")
+
+	// table: tbl_node
+	// hard fork version followed and voted by the node (`hard_fork_info` RPC)
+	slog.Debug("[DB] Adding hf_version and hf_voting columns to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN hf_version TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER version_status,
+		ADD COLUMN hf_voting TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER hf_version
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v19(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 19")
+
+	// table: tbl_node
+	// operator declared ZMQ pub and P2P ports (0 = nettype default) and their
+	// check results (0 = no, 1 = yes, 2 = not checked)
+	slog.Debug("[DB] Adding ZMQ and P2P columns to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN zmq_port INT(6) UNSIGNED NOT NULL DEFAULT 0 AFTER port,
+		ADD COLUMN p2p_port INT(6) UNSIGNED NOT NULL DEFAULT 0 AFTER zmq_port,
+		ADD COLUMN zmq_capable TINYINT(1) UNSIGNED NOT NULL DEFAULT 2 AFTER cors_capable,
+		ADD COLUMN p2p_reachable TINYINT(1) UNSIGNED NOT NULL DEFAULT 2 AFTER zmq_capable
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v20(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 20")
+
+	// table: tbl_node_tls
+	// the latest TLS certificate details of HTTPS nodes reported by probers
+	slog.Debug("[DB] Creating table: tbl_node_tls")
+	_, err := db.Exec(`
+		CREATE TABLE tbl_node_tls (
+			node_id INT(11) UNSIGNED NOT NULL,
+			subject VARCHAR(255) NOT NULL DEFAULT '',
+			issuer VARCHAR(255) NOT NULL DEFAULT '',
+			dns_names TEXT NOT NULL DEFAULT '' COMMENT 'comma separated SANs',
+			not_before INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			not_after INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			is_valid TINYINT(1) UNSIGNED NOT NULL DEFAULT 0,
+			is_self_signed TINYINT(1) UNSIGNED NOT NULL DEFAULT 0,
+			hostname_mismatch TINYINT(1) UNSIGNED NOT NULL DEFAULT 0,
+			tls_version VARCHAR(20) NOT NULL DEFAULT '',
+			cipher_suite VARCHAR(100) NOT NULL DEFAULT '',
+			last_checked INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (node_id),
+			KEY not_after (not_after)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_node
+	// 0 = invalid, 1 = valid, 2 = not applicable (HTTP) or not checked
+	slog.Debug("[DB] Adding tls_valid column to tbl_node")
+	_, err = db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN tls_valid TINYINT(1) UNSIGNED NOT NULL DEFAULT 2 AFTER cors_capable,
+		ADD KEY tls_valid (tls_valid)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v21(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 21")
+
+	// table: tbl_node
+	// monerod `--rpc-login` credentials shared by the node operator, AES-GCM
+	// encrypted using key derived from APP_SECRET
+	slog.Debug("[DB] Adding RPC login columns to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN rpc_login TEXT NOT NULL DEFAULT '' AFTER protocol,
+		ADD COLUMN login_required TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER rpc_login
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v22(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 22")
+
+	// table: tbl_probe_log
+	// failure classification reported by the prober, see monero.FailureCodes
+	slog.Debug("[DB] Adding failure_code column to tbl_probe_log")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_probe_log
+		ADD COLUMN failure_code VARCHAR(32) NOT NULL DEFAULT '' AFTER failed_reason,
+		ADD KEY failure_code (failure_code)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v23(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 23")
+
+	// table: tbl_node
+	// job scheduling, see monero.SchedulePolicy
+	slog.Debug("[DB] Adding scheduling columns to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN next_check INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER last_checked,
+		ADD COLUMN min_check_interval INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER next_check,
+		ADD COLUMN check_failures INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER min_check_interval,
+		ADD KEY next_check (next_check)
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v24(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 24")
+
+	// table: tbl_prober
+	// capabilities and capacity declared by the prober when fetching a job,
+	// used to balance jobs between probers
+	slog.Debug("[DB] Adding capability columns to tbl_prober")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_prober
+		ADD COLUMN accept_tor TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER last_submit_ts,
+		ADD COLUMN accept_i2p TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER accept_tor,
+		ADD COLUMN accept_ipv6 TINYINT(1) UNSIGNED NOT NULL DEFAULT 0 AFTER accept_i2p,
+		ADD COLUMN capacity INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER accept_ipv6,
+		ADD COLUMN last_job_ts INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER capacity
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v25(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 25")
+
+	// table: tbl_prober
+	slog.Debug("[DB] Adding region column to tbl_prober")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_prober
+		ADD COLUMN region VARCHAR(64) NOT NULL DEFAULT '' AFTER name
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_node
+	// response time percentiles of the last 24 hours from all probers, see
+	// tbl_node_latency
+	slog.Debug("[DB] Adding latency columns to tbl_node")
+	_, err = db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN latency_p50 FLOAT(8,3) UNSIGNED NOT NULL DEFAULT 0.000 AFTER uptime,
+		ADD COLUMN latency_p90 FLOAT(8,3) UNSIGNED NOT NULL DEFAULT 0.000 AFTER latency_p50,
+		ADD COLUMN latency_p99 FLOAT(8,3) UNSIGNED NOT NULL DEFAULT 0.000 AFTER latency_p90
+		;`)
+	if err != nil {
+		return err
+	}
+
+	// table: tbl_node_latency
+	// response time percentiles per rolling window and prober region, empty
+	// region is all probers
+	slog.Debug("[DB] Creating table: tbl_node_latency")
+	_, err = db.Exec(`
+		CREATE TABLE tbl_node_latency (
+			node_id INT(11) UNSIGNED NOT NULL,
+			region VARCHAR(64) NOT NULL DEFAULT '',
+			window_hours SMALLINT(5) UNSIGNED NOT NULL,
+			p50 FLOAT(8,3) UNSIGNED NOT NULL DEFAULT 0.000,
+			p90 FLOAT(8,3) UNSIGNED NOT NULL DEFAULT 0.000,
+			p99 FLOAT(8,3) UNSIGNED NOT NULL DEFAULT 0.000,
+			samples INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			updated_at INT(11) UNSIGNED NOT NULL DEFAULT 0,
+			PRIMARY KEY (node_id, region, window_hours)
+		)`)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("[DB] Adding calculate node latency cron task to tbl_cron")
+	_, err = db.Exec(`
+		INSERT INTO tbl_cron (
+			title,
+			slug,
+			description,
+			run_every
+		) VALUES (
+			'Calculate node latency',
+			'calculate_node_latency',
+			'Calculate response time percentiles of nodes from the probe logs',
+			900
+		);`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v26(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 26")
+
+	// table: tbl_node
+	// nodes archived before this version have no recorded reason
+	slog.Debug("[DB] Adding archived_reason column to tbl_node")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_node
+		ADD COLUMN archived_reason VARCHAR(32) NOT NULL DEFAULT '' AFTER is_archived
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v27(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 27")
+
+	// table: tbl_admin
+	// session tokens embed the version, bumping it revokes issued sessions
+	slog.Debug("[DB] Adding session_version column to tbl_admin")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_admin
+		ADD COLUMN session_version INT(11) UNSIGNED NOT NULL DEFAULT 0 AFTER date_created
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func v28(db *DB) error {
+	slog.Debug("[DB] Migrating database schema version 28")
+
+	// table: tbl_probe_log
+	// get_info request time used for latency percentiles, fetch_runtime also
+	// includes retries, port checks and other RPC calls. Older probe logs
+	// have no value and are not counted.
+	slog.Debug("[DB] Adding rpc_runtime column to tbl_probe_log")
+	_, err := db.Exec(`
+		ALTER TABLE tbl_probe_log
+		ADD COLUMN rpc_runtime FLOAT(7,3) UNSIGNED NOT NULL DEFAULT 0.000 AFTER fetch_runtime
+		;`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package digest implements HTTP digest access authentication (RFC 7616)
+// client used by monerod `--rpc-login`.
+package digest
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Transport is http.RoundTripper that answers digest authentication
+// challenge. A request is sent without credentials first, when the server
+// responds with 401 digest challenge, the request is sent again with the
+// Authorization header.
+type Transport struct {
+	Username string
+	Password string
+
+	// Base is the underlying round tripper, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	first := req.Clone(req.Context())
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		first.Body = body
+	}
+
+	resp, err := t.base().RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	c, err := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		// not a digest challenge, let the caller handle the 401 response
+		return resp, nil
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	second := req.Clone(req.Context())
+	if req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		second.Body = body
+	}
+	auth, err := c.authorize(t.Username, t.Password, req.Method, req.URL.RequestURI())
+	if err != nil {
+		return nil, err
+	}
+	second.Header.Set("Authorization", auth)
+	return t.base().RoundTrip(second)
+}
+
+// IsChallenge reports whether the response is 401 digest authentication
+// challenge
+func IsChallenge(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	_, err := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	return err == nil
+}
+
+type challenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+var errNoDigest = errors.New("not a digest challenge")
+
+// parseChallenge parses WWW-Authenticate header value. Servers may offer
+// multiple challenges in separate headers, monerod sends one per algorithm,
+// the first digest challenge is used.
+func parseChallenge(header string) (*challenge, error) {
+	scheme, params, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Digest") {
+		return nil, errNoDigest
+	}
+	c := &challenge{algorithm: "MD5"}
+	for _, p := range splitParams(params) {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			continue
+		}
+		v = strings.Trim(strings.TrimSpace(v), `"`)
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "realm":
+			c.realm = v
+		case "nonce":
+			c.nonce = v
+		case "opaque":
+			c.opaque = v
+		case "algorithm":
+			c.algorithm = v
+		case "qop":
+			// prefer "auth", "auth-int" is not supported
+			for _, q := range strings.Split(v, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					c.qop = "auth"
+				}
+			}
+		}
+	}
+	if c.nonce == "" {
+		return nil, errNoDigest
+	}
+	return c, nil
+}
+
+// splitParams splits comma separated auth params, ignoring commas inside
+// quoted strings
+func splitParams(s string) []string {
+	var (
+		params  []string
+		start   int
+		inQuote bool
+	)
+	for i, r := range s {
+		switch r {
+		case '"':
+			inQuote = !inQuote
+		case ',':
+			if !inQuote {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(params, s[start:])
+}
+
+func (c *challenge) authorize(username, password, method, uri string) (string, error) {
+	var h func() hash.Hash
+	switch strings.ToUpper(c.algorithm) {
+	case "MD5":
+		h = md5.New
+	case "SHA-256":
+		h = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", c.algorithm)
+	}
+	sum := func(s string) string {
+		d := h()
+		d.Write([]byte(s))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+
+	ha1 := sum(username + ":" + c.realm + ":" + password)
+	ha2 := sum(method + ":" + uri)
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`nonce="%s"`, c.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`algorithm=%s`, c.algorithm),
+	}
+	if c.qop == "" {
+		parts = append(parts, fmt.Sprintf(`response="%s"`, sum(ha1+":"+c.nonce+":"+ha2)))
+	} else {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		cnonce := hex.EncodeToString(b)
+		const nc = "00000001"
+		parts = append(parts,
+			fmt.Sprintf(`response="%s"`, sum(ha1+":"+c.nonce+":"+nc+":"+cnonce+":"+c.qop+":"+ha2)),
+			"qop="+c.qop,
+			"nc="+nc,
+			fmt.Sprintf(`cnonce="%s"`, cnonce),
+		)
+	}
+	if c.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
@@ -0,0 +1,109 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func md5hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// digestServer is a minimal monerod-like digest auth server
+func digestServer(t *testing.T, username, password string) *httptest.Server {
+	t.Helper()
+	const (
+		realm = "monero-rpc"
+		nonce = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest qop="auth",algorithm=MD5,realm="%s",nonce="%s"`, realm, nonce))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		params := map[string]string{}
+		for _, p := range splitParams(strings.TrimPrefix(auth, "Digest ")) {
+			k, v, _ := strings.Cut(p, "=")
+			params[strings.TrimSpace(k)] = strings.Trim(v, `"`)
+		}
+		ha1 := md5hex(username + ":" + realm + ":" + password)
+		ha2 := md5hex(r.Method + ":" + params["uri"])
+		want := md5hex(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":" + params["qop"] + ":" + ha2)
+		if params["username"] != username || params["response"] != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write(body)
+	}))
+}
+
+// Single test:
+// go test -race ./internal/digest -run=TestTransport -v
+func TestTransport(t *testing.T) {
+	srv := digestServer(t, "user", "s3cret")
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		password string
+		want     int
+	}{
+		{"valid credentials", "s3cret", http.StatusOK},
+		{"invalid credentials", "wrong", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := http.Client{Transport: &Transport{Username: "user", Password: tt.password}}
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/json_rpc", bytes.NewBufferString(`{"method":"get_info"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status code = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusOK {
+				// the request body must be replayed after the challenge
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != `{"method":"get_info"}` {
+					t.Errorf("body = %q", body)
+				}
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/digest -run=TestIsChallenge -v
+func TestIsChallenge(t *testing.T) {
+	tests := []struct {
+		status int
+		header string
+		want   bool
+	}{
+		{http.StatusUnauthorized, `Digest realm="monero-rpc", nonce="abc"`, true},
+		{http.StatusUnauthorized, `Basic realm="x"`, false},
+		{http.StatusOK, `Digest realm="monero-rpc", nonce="abc"`, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{"Www-Authenticate": {tt.header}}}
+		if got := IsChallenge(resp); got != tt.want {
+			t.Errorf("IsChallenge(%d, %q) = %v, want %v", tt.status, tt.header, got, tt.want)
+		}
+	}
+}
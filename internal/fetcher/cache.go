@@ -0,0 +1,92 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/database"
+)
+
+// dbCache stores validators in `tbl_fetch_cache`
+type dbCache struct {
+	db *database.DB
+}
+
+// NewDBCache returns Cache backed by the database
+func NewDBCache() Cache {
+	return &dbCache{db: database.GetDB()}
+}
+
+// locations can be longer than the maximum key length, so the table is keyed
+// by location hash
+func locationHash(location string) string {
+	sum := sha256.Sum256([]byte(location))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *dbCache) Validators(ctx context.Context, location string) (Validators, error) {
+	var v Validators
+	err := c.db.QueryRowContext(ctx, `
+		SELECT
+			etag,
+			last_modified
+		FROM
+			tbl_fetch_cache
+		WHERE
+			location_hash = ?`, locationHash(location)).Scan(&v.ETag, &v.LastModified)
+	if err == sql.ErrNoRows {
+		return Validators{}, nil
+	}
+	return v, err
+}
+
+func (c *dbCache) SetValidators(ctx context.Context, location string, v Validators) error {
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO tbl_fetch_cache (
+			location_hash,
+			location,
+			etag,
+			last_modified,
+			date_updated
+		) VALUES (
+			?, ?, ?, ?, ?
+		)
+		ON DUPLICATE KEY UPDATE
+			etag = VALUES(etag),
+			last_modified = VALUES(last_modified),
+			date_updated = VALUES(date_updated)`,
+		locationHash(location),
+		location,
+		v.ETag,
+		v.LastModified,
+		time.Now().Unix())
+	return err
+}
+
+// memoryCache is in-memory Cache, used by one-off commands and tests
+type memoryCache struct {
+	mu sync.Mutex
+	m  map[string]Validators
+}
+
+// NewMemoryCache returns Cache which only lives as long as the process
+func NewMemoryCache() Cache {
+	return &memoryCache{m: make(map[string]Validators)}
+}
+
+func (c *memoryCache) Validators(_ context.Context, location string) (Validators, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m[location], nil
+}
+
+func (c *memoryCache) SetValidators(_ context.Context, location string, v Validators) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[location] = v
+	return nil
+}
@@ -0,0 +1,252 @@
+// Package fetcher downloads external data sources, such as Rucknium's
+// Monero Network Scan data and IP addresses ban lists.
+//
+// Each download is limited in size and time. When a Cache is configured,
+// HTTP requests are made conditional (ETag / If-Modified-Since) so unchanged
+// data don't need to be imported again.
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+)
+
+const (
+	DefaultMaxBytes int64 = 64 << 20 // 64 MiB
+	DefaultTimeout        = 5 * time.Minute
+)
+
+// ErrTooLarge is returned when the response body exceeds the configured
+// size limit
+var ErrTooLarge = errors.New("response exceeds size limit")
+
+// Validators are cache validators of previously fetched resource. For local
+// files, LastModified holds the file modification time.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Cache stores Validators of fetched locations
+type Cache interface {
+	Validators(ctx context.Context, location string) (Validators, error)
+	SetValidators(ctx context.Context, location string, v Validators) error
+}
+
+// Response is the result of a single fetch
+type Response struct {
+	Location   string
+	Body       []byte // nil when NotModified
+	Validators Validators
+	// NotModified reports whether the resource is unchanged since the last
+	// committed fetch
+	NotModified bool
+}
+
+// Fetcher downloads HTTP(S) URLs and local files
+type Fetcher struct {
+	client    *http.Client
+	cache     Cache
+	maxBytes  int64
+	timeout   time.Duration
+	userAgent string
+}
+
+// Option configures Fetcher
+type Option func(*Fetcher)
+
+// WithClient sets the HTTP client used for requests
+func WithClient(c *http.Client) Option {
+	return func(f *Fetcher) { f.client = c }
+}
+
+// WithCache enables conditional requests using the given Cache
+func WithCache(c Cache) Option {
+	return func(f *Fetcher) { f.cache = c }
+}
+
+// WithMaxBytes sets the maximum response body size, zero or negative value
+// keeps the default
+func WithMaxBytes(n int64) Option {
+	return func(f *Fetcher) {
+		if n > 0 {
+			f.maxBytes = n
+		}
+	}
+}
+
+// WithTimeout sets the maximum duration of a single fetch, zero or negative
+// value keeps the default
+func WithTimeout(d time.Duration) Option {
+	return func(f *Fetcher) {
+		if d > 0 {
+			f.timeout = d
+		}
+	}
+}
+
+// WithUserAgent sets User-Agent header of HTTP requests
+func WithUserAgent(ua string) Option {
+	return func(f *Fetcher) { f.userAgent = ua }
+}
+
+// New returns Fetcher with the given options applied. Without options, it
+// uses a new HTTP client, no cache, DefaultMaxBytes and DefaultTimeout.
+func New(opts ...Option) *Fetcher {
+	f := &Fetcher{
+		client:    &http.Client{},
+		maxBytes:  DefaultMaxBytes,
+		timeout:   DefaultTimeout,
+		userAgent: "xmr-nodes/" + config.Version,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Fetch downloads the location, which can be HTTP(S) URL or local file path
+// (optionally prefixed with `file://`).
+//
+// If the location is unchanged since the last committed fetch, the returned
+// Response has NotModified set and no body.
+func (f *Fetcher) Fetch(ctx context.Context, location string) (*Response, error) {
+	return f.fetch(ctx, location, true)
+}
+
+// FetchUnconditional is like Fetch, but always downloads the location
+// regardless of the cached validators.
+func (f *Fetcher) FetchUnconditional(ctx context.Context, location string) (*Response, error) {
+	return f.fetch(ctx, location, false)
+}
+
+// Commit stores the response validators, so the next Fetch of the same
+// location is conditional. It should only be called once the response body
+// is successfully imported.
+func (f *Fetcher) Commit(ctx context.Context, resp *Response) error {
+	if f.cache == nil || resp == nil || resp.NotModified {
+		return nil
+	}
+	if resp.Validators == (Validators{}) {
+		return nil
+	}
+	return f.cache.SetValidators(ctx, resp.Location, resp.Validators)
+}
+
+func (f *Fetcher) fetch(ctx context.Context, location string, conditional bool) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	var cached Validators
+	if conditional && f.cache != nil {
+		var err error
+		cached, err = f.cache.Validators(ctx, location)
+		if err != nil {
+			return nil, fmt.Errorf("read fetch cache: %w", err)
+		}
+	}
+
+	if !isHTTP(location) {
+		return f.fetchFile(location, cached)
+	}
+	return f.fetchHTTP(ctx, location, cached)
+}
+
+func isHTTP(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+func (f *Fetcher) fetchHTTP(ctx context.Context, location string, cached Validators) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return &Response{Location: location, Validators: cached, NotModified: true}, nil
+	default:
+		return nil, fmt.Errorf("HTTP request return with status code: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > f.maxBytes {
+		return nil, ErrTooLarge
+	}
+	body, err := f.readAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{
+		Location: location,
+		Body:     body,
+		Validators: Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
+func (f *Fetcher) fetchFile(location string, cached Validators) (*Response, error) {
+	path := strings.TrimPrefix(location, "file://")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	v := Validators{LastModified: info.ModTime().UTC().Format(time.RFC3339Nano)}
+	if cached.LastModified != "" && cached.LastModified == v.LastModified {
+		return &Response{Location: location, Validators: v, NotModified: true}, nil
+	}
+	if info.Size() > f.maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	body, err := f.readAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Location: location, Body: body, Validators: v}, nil
+}
+
+// readAll reads r until EOF, failing with ErrTooLarge if r is larger than
+// the size limit
+func (f *Fetcher) readAll(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, f.maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > f.maxBytes {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
@@ -0,0 +1,152 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Single test:
+// go test -race ./internal/fetcher -run=TestFetchHTTPConditional -v
+func TestFetchHTTPConditional(t *testing.T) {
+	const etag = `"v1"`
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	f := New(WithClient(srv.Client()), WithCache(NewMemoryCache()))
+
+	resp, err := f.Fetch(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.NotModified || string(resp.Body) != "hello" || resp.Validators.ETag != etag {
+		t.Fatalf("first Fetch() = %+v", resp)
+	}
+
+	// not committed yet, must download again
+	resp, err = f.Fetch(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.NotModified {
+		t.Fatal("Fetch() before Commit() must not be conditional")
+	}
+	if err := f.Commit(ctx, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = f.Fetch(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.NotModified || resp.Body != nil {
+		t.Errorf("Fetch() after Commit() = %+v, want not modified", resp)
+	}
+
+	resp, err = f.FetchUnconditional(ctx, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.NotModified || string(resp.Body) != "hello" {
+		t.Errorf("FetchUnconditional() = %+v", resp)
+	}
+
+	if requests != 4 || notModified != 1 {
+		t.Errorf("got %d requests, %d not modified, want 4 and 1", requests, notModified)
+	}
+}
+
+// Single test:
+// go test -race ./internal/fetcher -run=TestFetchHTTPErrors -v
+func TestFetchHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("late"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	f := New(WithClient(srv.Client()), WithMaxBytes(10), WithTimeout(50*time.Millisecond))
+
+	if _, err := f.Fetch(ctx, srv.URL+"/large"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Fetch() large response error = %v, want %v", err, ErrTooLarge)
+	}
+	if _, err := f.Fetch(ctx, srv.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch() slow response error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := f.Fetch(ctx, srv.URL+"/error"); err == nil {
+		t.Error("Fetch() with status code 500 must return error")
+	}
+}
+
+// Single test:
+// go test -race ./internal/fetcher -run=TestFetchFile -v
+func TestFetchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ban_list.txt")
+	if err := os.WriteFile(path, []byte("192.0.2.1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	f := New(WithCache(NewMemoryCache()))
+
+	resp, err := f.Fetch(ctx, "file://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.NotModified || string(resp.Body) != "192.0.2.1\n" {
+		t.Fatalf("Fetch() = %+v", resp)
+	}
+	if err := f.Commit(ctx, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = f.Fetch(ctx, "file://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.NotModified {
+		t.Error("Fetch() of unchanged file must be not modified")
+	}
+
+	// touching the file invalidates the cached modification time
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = f.Fetch(ctx, "file://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.NotModified {
+		t.Error("Fetch() of modified file must not be not modified")
+	}
+
+	if _, err := New(WithMaxBytes(4)).Fetch(ctx, path); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Fetch() large file error = %v, want %v", err, ErrTooLarge)
+	}
+}
@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/ditatompel/xmr-remote-nodes/internal/admin"
+	"github.com/ditatompel/xmr-remote-nodes/internal/cron"
+	"github.com/ditatompel/xmr-remote-nodes/internal/handler/views"
+	"github.com/ditatompel/xmr-remote-nodes/internal/ip/geo"
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+	"github.com/ditatompel/xmr-remote-nodes/internal/paging"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// adminMeta returns page meta for admin pages. Admin pages should never be
+// indexed by search engines.
+func (s *fiberServer) adminMeta(title, identifier string) views.Meta {
+	return views.Meta{
+		Title:       title,
+		Description: "XMR Nodes admin console",
+		Robots:      "NOINDEX,NOFOLLOW",
+		Permalink:   s.url + identifier,
+		Identifier:  identifier,
+	}
+}
+
+// adminUser returns logged in admin user set by `s.checkAdminMW` middleware
+func adminUser(c *fiber.Ctx) admin.Admin {
+	a, _ := c.Locals("admin").(admin.Admin)
+	return a
+}
+
+// audit writes admin action to the audit log
+func (s *fiberServer) audit(c *fiber.Ctx, action, target, detail string) {
+	a := adminUser(c)
+	admin.New().Audit(a.ID, a.Username, action, target, detail, c.IP())
+}
+
+// adminAlert renders alert component for HTMX admin actions
+func adminAlert(c *fiber.Ctx, status, message string) error {
+	if status == "success" {
+		// reload the current page so the tables reflect the changes
+		c.Set("HX-Refresh", "true")
+	}
+	handler := adaptor.HTTPHandler(templ.Handler(views.Alert(status, message)))
+	return handler(c)
+}
+
+// Render admin login page and handles login form submission
+func (s *fiberServer) adminLoginHandler(c *fiber.Ctx) error {
+	p := s.adminMeta("Admin Login", "/admin/login")
+
+	if c.Method() == fiber.MethodPost {
+		username := c.FormValue("username")
+		a, err := admin.New().Authenticate(username, c.FormValue("password"), c.FormValue("totp"))
+		if err != nil {
+			admin.New().Audit(0, username, "login_failed", "", "", c.IP())
+			cmp := views.BaseLayout(p, views.AdminLogin(err.Error()))
+			handler := adaptor.HTTPHandler(templ.Handler(cmp, templ.WithStatus(fiber.StatusUnauthorized)))
+			return handler(c)
+		}
+
+		expires := time.Now().Add(admin.SessionTTL)
+		token, err := admin.NewSessionToken(s.secret, a.ID, a.SessionVersion, expires)
+		if err != nil {
+			cmp := views.BaseLayout(p, views.AdminLogin(err.Error()))
+			handler := adaptor.HTTPHandler(templ.Handler(cmp, templ.WithStatus(fiber.StatusInternalServerError)))
+			return handler(c)
+		}
+		c.Cookie(&fiber.Cookie{
+			Name:     admin.SessionCookie,
+			Value:    token,
+			Path:     "/admin",
+			Expires:  expires,
+			Secure:   strings.HasPrefix(s.url, "https://"),
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteStrictMode,
+		})
+		admin.New().Audit(a.ID, a.Username, "login", "", "", c.IP())
+
+		return c.Redirect("/admin", fiber.StatusSeeOther)
+	}
+
+	cmp := views.BaseLayout(p, views.AdminLogin(""))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Handles admin logout
+func (s *fiberServer) adminLogoutHandler(c *fiber.Ctx) error {
+	if err := admin.New().RevokeSessions(adminUser(c).ID); err != nil {
+		slog.Error(err.Error())
+	}
+	s.audit(c, "logout", "", "")
+	c.Cookie(&fiber.Cookie{
+		Name:     admin.SessionCookie,
+		Value:    "",
+		Path:     "/admin",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+	return c.Redirect("/admin/login", fiber.StatusSeeOther)
+}
+
+// Render admin dashboard page
+func (s *fiberServer) adminDashboardHandler(c *fiber.Ctx) error {
+	moneroRepo := monero.New()
+	summary, err := moneroRepo.Summary()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	probers, err := monero.NewProber().Probers(monero.QueryProbers{})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	banList, err := moneroRepo.BanList()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	crons, err := cron.New().Crons()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	p := s.adminMeta("Admin Dashboard", "/admin")
+	cmp := views.BaseLayout(p, views.AdminDashboard(adminUser(c).Username, summary, len(probers), len(banList), crons, geo.Default().Stats()))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Render registered probers page
+func (s *fiberServer) adminProbersHandler(c *fiber.Ctx) error {
+	probers, err := monero.NewProber().Probers(monero.QueryProbers{
+		Search:        c.Query("search"),
+		SortBy:        "id",
+		SortDirection: "asc",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	p := s.adminMeta("Probers", "/admin/probers")
+	cmp := views.BaseLayout(p, views.AdminProbers(adminUser(c).Username, probers))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Handles add new prober form
+func (s *fiberServer) adminAddProberHandler(c *fiber.Ctx) error {
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return adminAlert(c, "error", "Prober name cannot be empty")
+	}
+	prober, err := monero.NewProber().Add(name)
+	if err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	s.audit(c, "prober_add", name, "")
+
+	// Do not refresh the page, the API key is only displayed once.
+	handler := adaptor.HTTPHandler(templ.Handler(views.Alert("success", fmt.Sprintf("Prober %q added with API key: %s", prober.Name, prober.APIKey))))
+	return handler(c)
+}
+
+// Handles edit prober name form
+func (s *fiberServer) adminEditProberHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id", 0)
+	if err != nil || id == 0 {
+		return adminAlert(c, "error", "Invalid prober id")
+	}
+	name := strings.TrimSpace(c.FormValue("name"))
+	if name == "" {
+		return adminAlert(c, "error", "Prober name cannot be empty")
+	}
+	if err := monero.NewProber().Edit(id, name); err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	s.audit(c, "prober_edit", fmt.Sprintf("prober:%d", id), name)
+
+	return adminAlert(c, "success", fmt.Sprintf("Prober ID %d updated", id))
+}
+
+// Handles delete prober
+func (s *fiberServer) adminDeleteProberHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id", 0)
+	if err != nil || id == 0 {
+		return adminAlert(c, "error", "Invalid prober id")
+	}
+	if err := monero.NewProber().Delete(id); err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	s.audit(c, "prober_delete", fmt.Sprintf("prober:%d", id), "")
+
+	return adminAlert(c, "success", fmt.Sprintf("Prober ID %d deleted", id))
+}
+
+// Render monitored nodes page, including pending submissions
+func (s *fiberServer) adminNodesHandler(c *fiber.Ctx) error {
+	p := s.adminMeta("Nodes", "/admin/nodes")
+
+	query := monero.QueryNodes{
+		Paging: paging.Paging{
+			Limit:         c.QueryInt("limit", 20), // rows per page
+			Page:          c.QueryInt("page", 1),
+			SortBy:        c.Query("sort_by", "last_checked"),
+			SortDirection: c.Query("sort_direction", "desc"),
+		},
+		Host:       c.Query("host"),
+		Nettype:    c.Query("nettype", "any"),
+		Protocol:   c.Query("protocol", "any"),
+		CC:         "any",
+		Status:     c.QueryInt("status", -1),
+		IsArchived: c.QueryInt("archived", -1),
+		IsSpyNode:  c.QueryInt("spynode", -1),
+		IsBanned:   c.QueryInt("banned", -1),
+		Pending:    c.Query("pending"),
+	}
+
+	nodes, err := monero.New().Nodes(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	pagination := paging.NewPagination(query.Page, nodes.TotalPages)
+
+	// handle request from HTMX
+	if c.Get("HX-Target") == "tbl_admin_nodes" {
+		cmp := views.BlankLayout(views.TableAdminNodes(p, nodes, query, pagination))
+		handler := adaptor.HTTPHandler(templ.Handler(cmp))
+		return handler(c)
+	}
+
+	cmp := views.BaseLayout(p, views.AdminNodes(p, adminUser(c).Username, nodes, query, pagination))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Handles archive node action
+func (s *fiberServer) adminArchiveNodeHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id", 0)
+	if err != nil || id == 0 {
+		return adminAlert(c, "error", "Invalid node id")
+	}
+	if err := monero.New().Archive(uint(id), monero.ArchivedByAdmin); err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	s.audit(c, "node_archive", fmt.Sprintf("node:%d", id), "")
+
+	return adminAlert(c, "success", fmt.Sprintf("Node ID %d archived", id))
+}
+
+// Handles delete node action
+func (s *fiberServer) adminDeleteNodeHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id", 0)
+	if err != nil || id == 0 {
+		return adminAlert(c, "error", "Invalid node id")
+	}
+	moneroRepo := monero.New()
+	node, err := moneroRepo.Node(id)
+	if err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	if err := moneroRepo.Delete(uint(id)); err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	s.audit(c, "node_delete", fmt.Sprintf("node:%d", id), fmt.Sprintf("%s://%s:%d", node.Protocol, node.Hostname, node.Port))
+
+	return adminAlert(c, "success", fmt.Sprintf("Node ID %d deleted", id))
+}
+
+// Render cron tasks page
+func (s *fiberServer) adminCronsHandler(c *fiber.Ctx) error {
+	crons, err := cron.New().Crons()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	p := s.adminMeta("Cron Tasks", "/admin/crons")
+	cmp := views.BaseLayout(p, views.AdminCrons(adminUser(c).Username, crons))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Handles enable or disable cron task action
+func (s *fiberServer) adminToggleCronHandler(c *fiber.Ctx) error {
+	slug := c.Params("slug")
+	enabled := c.Params("state") == "enable"
+	if err := cron.New().SetEnabled(slug, enabled); err != nil {
+		return adminAlert(c, "error", err.Error())
+	}
+	s.audit(c, "cron_"+c.Params("state"), "cron:"+slug, "")
+
+	return adminAlert(c, "success", fmt.Sprintf("Cron task %s updated", slug))
+}
+
+// Render ban list page
+func (s *fiberServer) adminBanListHandler(c *fiber.Ctx) error {
+	entries, err := monero.New().BanListEntries(c.Query("source"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	search := c.Query("search")
+	if search != "" {
+		filtered := []monero.BanListEntry{}
+		for _, entry := range entries {
+			if strings.Contains(entry.IPAddr, search) {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	p := s.adminMeta("Ban List", "/admin/ban-list")
+	cmp := views.BaseLayout(p, views.AdminBanList(adminUser(c).Username, entries, search))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Render admin audit logs page
+func (s *fiberServer) adminAuditLogsHandler(c *fiber.Ctx) error {
+	p := s.adminMeta("Audit Logs", "/admin/audit-logs")
+
+	query := admin.QueryAuditLogs{
+		Paging: paging.Paging{
+			Limit:         c.QueryInt("limit", 20), // rows per page
+			Page:          c.QueryInt("page", 1),
+			SortBy:        c.Query("sort_by", "id"),
+			SortDirection: c.Query("sort_direction", "desc"),
+		},
+		Action: c.Query("action"),
+		Search: c.Query("search"),
+	}
+
+	logs, err := admin.New().AuditLogs(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	pagination := paging.NewPagination(query.Page, logs.TotalPages)
+
+	// handle request from HTMX
+	if c.Get("HX-Target") == "tbl_audit_logs" {
+		cmp := views.BlankLayout(views.TableAuditLogs(p, logs, query, pagination))
+		handler := adaptor.HTTPHandler(templ.Handler(cmp))
+		return handler(c)
+	}
+
+	cmp := views.BaseLayout(p, views.AdminAuditLogs(p, adminUser(c).Username, logs, query, pagination))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/admin"
 	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
 
 	"github.com/gofiber/fiber/v2"
@@ -29,3 +32,29 @@ func (s *fiberServer) checkProberMW(c *fiber.Ctx) error {
 	c.Locals("prober_id", prober.ID)
 	return c.Next()
 }
+
+// checkAdminMW is a middleware to check admin session cookie
+//
+// Unauthenticated GET requests are redirected to the login page, other
+// methods get 401 response.
+func (s *fiberServer) checkAdminMW(c *fiber.Ctx) error {
+	unauthorized := func() error {
+		if c.Method() == fiber.MethodGet {
+			return c.Redirect("/admin/login", fiber.StatusSeeOther)
+		}
+		return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized")
+	}
+
+	adminID, version, err := admin.ParseSessionToken(s.secret, c.Cookies(admin.SessionCookie), time.Now())
+	if err != nil {
+		return unauthorized()
+	}
+
+	a, err := admin.New().Admin(adminID)
+	if err != nil || a.SessionVersion != version {
+		return unauthorized()
+	}
+
+	c.Locals("admin", a)
+	return c.Next()
+}
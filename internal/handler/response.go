@@ -2,9 +2,13 @@ package handler
 
 import (
 	"fmt"
+	"log/slog"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/a-h/templ"
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
 	"github.com/ditatompel/xmr-remote-nodes/internal/handler/views"
 	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
 	"github.com/ditatompel/xmr-remote-nodes/internal/paging"
@@ -57,6 +61,10 @@ func (s *fiberServer) addNodeHandler(c *fiber.Ctx) error {
 			Protocol string `form:"protocol"`
 			Hostname string `form:"hostname"`
 			Port     int    `form:"port"`
+			ZMQPort  int    `form:"zmq_port"`
+			P2PPort  int    `form:"p2p_port"`
+			Username string `form:"rpc_username"`
+			Password string `form:"rpc_password"`
 		}
 		var f formData
 
@@ -66,7 +74,7 @@ func (s *fiberServer) addNodeHandler(c *fiber.Ctx) error {
 		}
 
 		moneroRepo := monero.New()
-		if err := moneroRepo.Add(c.IP(), s.secret, f.Protocol, f.Hostname, uint(f.Port)); err != nil {
+		if err := moneroRepo.Add(c.IP(), s.secret, f.Protocol, f.Hostname, uint(f.Port), uint(max(f.ZMQPort, 0)), uint(max(f.P2PPort, 0)), monero.RPCLogin{Username: f.Username, Password: f.Password}); err != nil {
 			handler := adaptor.HTTPHandler(templ.Handler(views.Alert("error", err.Error())))
 			return handler(c)
 		}
@@ -126,6 +134,45 @@ func (s *fiberServer) nodeAPI(c *fiber.Ctx) error {
 	})
 }
 
+// Returns TLS certificate details of HTTPS node (API endpoint, JSON data)
+func (s *fiberServer) nodeTLSAPI(c *fiber.Ctx) error {
+	nodeID, err := c.ParamsInt("id", 0)
+	if err != nil || nodeID <= 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid node id",
+			"data":    nil,
+		})
+	}
+
+	cert, err := monero.New().NodeTLS(uint(nodeID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	if cert == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "No TLS certificate details for this node",
+			"data":    nil,
+		})
+	}
+
+	now := time.Now()
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data": fiber.Map{
+			"certificate":   cert,
+			"days_left":     cert.DaysLeft(now),
+			"expiring_soon": cert.ExpiresWithin(config.AppCfg().TLSExpiryDays, now),
+		},
+	})
+}
+
 // Render Remote Nodes Page
 func (s *fiberServer) remoteNodesHandler(c *fiber.Ctx) error {
 	p := views.Meta{
@@ -146,16 +193,22 @@ func (s *fiberServer) remoteNodesHandler(c *fiber.Ctx) error {
 			SortDirection: c.Query("sort_direction", "desc"),
 			Refresh:       c.Query("refresh"),
 		},
-		Host:       c.Query("host"),
-		Nettype:    c.Query("nettype", "any"),
-		Protocol:   c.Query("protocol", "any"),
-		CC:         c.Query("cc", "any"),
-		Status:     c.QueryInt("status", -1),
-		CORS:       c.Query("cors"),
-		IsArchived: c.QueryInt("archived", 0),
-		IsSpyNode:  c.QueryInt("spynode", -1),
-		MRLBan:     c.Query("mrlban"),
-		DNSBan:     c.Query("dnsban"),
+		Host:          c.Query("host"),
+		Nettype:       c.Query("nettype", "any"),
+		Protocol:      c.Query("protocol", "any"),
+		CC:            c.Query("cc", "any"),
+		Status:        c.QueryInt("status", -1),
+		CORS:          c.Query("cors"),
+		IsArchived:    c.QueryInt("archived", 0),
+		IsSpyNode:     c.QueryInt("spynode", -1),
+		IsBanned:      c.QueryInt("banned", -1),
+		MRLBan:        c.Query("mrlban"),
+		DNSBan:        c.Query("dnsban"),
+		VersionStatus: c.Query("version_status"),
+		ZMQ:           c.Query("zmq"),
+		P2P:           c.Query("p2p"),
+		TLS:           c.Query("tls_valid"),
+		TLSExpiryDays: config.AppCfg().TLSExpiryDays,
 	}
 
 	nodes, err := moneroRepo.Nodes(query)
@@ -220,6 +273,7 @@ func (s *fiberServer) banListEnabledHandler(c *fiber.Ctx) error {
 		CORS:       c.Query("cors"),
 		IsArchived: c.QueryInt("archived", 0),
 		IsSpyNode:  0,
+		IsBanned:   0,
 		MRLBan:     "on",
 		DNSBan:     "on",
 	}
@@ -258,6 +312,160 @@ func (s *fiberServer) banListEnabledHandler(c *fiber.Ctx) error {
 	return handler(c)
 }
 
+// Render Monero Network Scan Trends Page
+func (s *fiberServer) scanTrendsHandler(c *fiber.Ctx) error {
+	p := views.Meta{
+		Title:       "Monero Network Scan Trends",
+		Description: "Daily number of spy nodes and nodes with MRL and DNS ban list enabled from Rucknium's Monero Network Scan.",
+		Keywords:    "monero spy node,monero ban list,mrl ban list,dns blocklist,monero network scan",
+		Robots:      "INDEX,FOLLOW",
+		Permalink:   s.url + "/remote-nodes/scan-trends",
+		Identifier:  "/remote-nodes/scan-trends",
+	}
+
+	trends, err := monero.New().RuckniumTrends(c.QueryInt("limit", 30))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	c.Set("Link", fmt.Sprintf(`<%s>; rel="canonical"`, p.Permalink))
+	cmp := views.BaseLayout(p, views.ScanTrends(p, trends))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Render Monero Network Diversity Page
+func (s *fiberServer) networkDiversityHandler(c *fiber.Ctx) error {
+	p := views.Meta{
+		Title:       "Monero Network Diversity",
+		Description: "Distribution of online Monero public remote nodes by ASN, hosting provider, country and continent, including concentration index per nettype.",
+		Keywords:    "monero network diversity,monero node centralization,monero node asn,monero node hosting provider,monero node countries",
+		Robots:      "INDEX,FOLLOW",
+		Permalink:   s.url + "/remote-nodes/network-diversity",
+		Identifier:  "/remote-nodes/network-diversity",
+	}
+
+	nettype := c.Query("nettype", "mainnet")
+	diversity, err := monero.New().NetworkDiversity(monero.QueryDiversity{
+		Nettype: nettype,
+		TopN:    c.QueryInt("top", 10),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	data := monero.NetworkDiversity{Nettype: nettype}
+	if len(diversity) > 0 {
+		data = diversity[0]
+	}
+
+	c.Set("Link", fmt.Sprintf(`<%s>; rel="canonical"`, p.Permalink))
+	cmp := views.BaseLayout(p, views.NetworkDiversity(p, data))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Render Monero Daemon Version Adoption Page
+func (s *fiberServer) versionsHandler(c *fiber.Ctx) error {
+	p := views.Meta{
+		Title:       "Monero Node Versions",
+		Description: "Monero daemon version adoption of online public remote nodes, including outdated and vulnerable versions.",
+		Keywords:    "monero node version,monerod version,monero outdated node,monero hard fork",
+		Robots:      "INDEX,FOLLOW",
+		Permalink:   s.url + "/remote-nodes/versions",
+		Identifier:  "/remote-nodes/versions",
+	}
+
+	moneroRepo := monero.New()
+	nettype := c.Query("nettype", "mainnet")
+	if !slices.Contains([]string{"mainnet", "stagenet", "testnet"}, nettype) {
+		nettype = "mainnet"
+	}
+	adoption, err := moneroRepo.VersionAdoption(nettype)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	releases, err := moneroRepo.Releases()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	c.Set("Link", fmt.Sprintf(`<%s>; rel="canonical"`, p.Permalink))
+	cmp := views.BaseLayout(p, views.VersionAdoption(p, nettype, adoption, releases))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Render Monero Hard Fork Readiness Page
+func (s *fiberServer) hardForkReadinessHandler(c *fiber.Ctx) error {
+	p := views.Meta{
+		Title:       "Monero Hard Fork Readiness",
+		Description: "Upgrade readiness of online public Monero remote nodes for the network upgrade, including nodes that will fall off the chain.",
+		Keywords:    "monero hard fork,monero network upgrade,monero node version,monero hard fork voting",
+		Robots:      "INDEX,FOLLOW",
+		Permalink:   s.url + "/remote-nodes/hardfork-readiness",
+		Identifier:  "/remote-nodes/hardfork-readiness",
+	}
+
+	nettype := c.Query("nettype", "mainnet")
+	if !slices.Contains([]string{"mainnet", "stagenet", "testnet"}, nettype) {
+		nettype = "mainnet"
+	}
+	readiness, err := monero.New().HardForkReadiness(nettype)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	data := monero.HardForkReadiness{Nettype: nettype}
+	if len(readiness) > 0 {
+		data = readiness[0]
+	}
+
+	c.Set("Link", fmt.Sprintf(`<%s>; rel="canonical"`, p.Permalink))
+	cmp := views.BaseLayout(p, views.HardForkReadiness(p, data))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
+// Render Monero Remote Nodes Map Page
+func (s *fiberServer) mapHandler(c *fiber.Ctx) error {
+	p := views.Meta{
+		Title:       "Monero Remote Nodes Map",
+		Description: "World map of public Monero remote nodes locations, coloured by availability.",
+		Keywords:    "monero remote nodes map,monero nodes location,monero node countries",
+		Robots:      "INDEX,FOLLOW",
+		Permalink:   s.url + "/map",
+		Identifier:  "/map",
+	}
+	query := monero.QueryNodes{
+		Nettype: c.Query("nettype", "any"),
+		Status:  c.QueryInt("status", -1),
+	}
+
+	c.Set("Link", fmt.Sprintf(`<%s>; rel="canonical"`, p.Permalink))
+	cmp := views.BaseLayout(p, views.NodesMap(p, query))
+	handler := adaptor.HTTPHandler(templ.Handler(cmp))
+	return handler(c)
+}
+
 // Returns a single node information based on `id` query param.
 // This used for node modal and node details page including node probe logs.
 func (s *fiberServer) nodeHandler(c *fiber.Ctx) error {
@@ -304,6 +512,7 @@ func (s *fiberServer) nodeHandler(c *fiber.Ctx) error {
 		NodeID:       int(node.ID),
 		Status:       c.QueryInt("status", -1),
 		FailedReason: c.Query("failed_reason"),
+		FailureCode:  c.Query("failure_code"),
 	}
 
 	logs, err := moneroRepo.Logs(queryLogs)
@@ -324,6 +533,50 @@ func (s *fiberServer) nodeHandler(c *fiber.Ctx) error {
 		return handler(c)
 	}
 
+	scanHistory, err := moneroRepo.RuckniumHistory(node.IPAddresses, 30)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	failures, err := moneroRepo.FailureSummary(monero.QueryLogs{NodeID: int(node.ID)})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	cert, err := moneroRepo.NodeTLS(node.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	latency, err := moneroRepo.NodeLatency(node.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	trend, err := moneroRepo.LatencyTrend(node.ID, 30)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
 	p := views.Meta{
 		Title:       fmt.Sprintf("%s on Port %d", node.Hostname, node.Port),
 		Description: fmt.Sprintf("Monero %s remote node %s running on port %d", node.Nettype, node.Hostname, node.Port),
@@ -334,7 +587,7 @@ func (s *fiberServer) nodeHandler(c *fiber.Ctx) error {
 	}
 
 	c.Set("Link", fmt.Sprintf(`<%s>; rel="canonical"`, p.Permalink))
-	cmp := views.BaseLayout(p, views.NodeDetails(node, logs, queryLogs, pagination))
+	cmp := views.BaseLayout(p, views.NodeDetails(node, cert, config.AppCfg().TLSExpiryDays, scanHistory, latency, trend, failures, logs, queryLogs, pagination))
 	handler := adaptor.HTTPHandler(templ.Handler(cmp))
 	return handler(c)
 }
@@ -350,16 +603,22 @@ func (s *fiberServer) nodesAPI(c *fiber.Ctx) error {
 			SortDirection: c.Query("sort_direction", "desc"),
 			Refresh:       c.Query("refresh"),
 		},
-		Host:       c.Query("host"),
-		Nettype:    c.Query("nettype", "any"),
-		Protocol:   c.Query("protocol", "any"),
-		CC:         c.Query("cc", "any"),
-		Status:     c.QueryInt("status", -1),
-		CORS:       c.Query("cors"),
-		IsArchived: c.QueryInt("archived", 0),
-		IsSpyNode:  c.QueryInt("spynode", -1),
-		MRLBan:     c.Query("mrlban"),
-		DNSBan:     c.Query("dnsban"),
+		Host:          c.Query("host"),
+		Nettype:       c.Query("nettype", "any"),
+		Protocol:      c.Query("protocol", "any"),
+		CC:            c.Query("cc", "any"),
+		Status:        c.QueryInt("status", -1),
+		CORS:          c.Query("cors"),
+		IsArchived:    c.QueryInt("archived", 0),
+		IsSpyNode:     c.QueryInt("spynode", -1),
+		IsBanned:      c.QueryInt("banned", -1),
+		MRLBan:        c.Query("mrlban"),
+		DNSBan:        c.Query("dnsban"),
+		VersionStatus: c.Query("version_status"),
+		ZMQ:           c.Query("zmq"),
+		P2P:           c.Query("p2p"),
+		TLS:           c.Query("tls_valid"),
+		TLSExpiryDays: config.AppCfg().TLSExpiryDays,
 	}
 
 	nodes, err := moneroRepo.Nodes(query)
@@ -378,6 +637,41 @@ func (s *fiberServer) nodesAPI(c *fiber.Ctx) error {
 	})
 }
 
+// Returns node locations as GeoJSON feature collection (API endpoint,
+// GeoJSON data). Accepts the same filters as `/api/v1/nodes`, coordinates
+// are rounded to `MAP_COORDINATE_PRECISION` decimal places.
+func (s *fiberServer) nodesGeoJSONAPI(c *fiber.Ctx) error {
+	query := monero.QueryNodes{
+		Host:          c.Query("host"),
+		Nettype:       c.Query("nettype", "any"),
+		Protocol:      c.Query("protocol", "any"),
+		CC:            c.Query("cc", "any"),
+		Status:        c.QueryInt("status", -1),
+		CORS:          c.Query("cors"),
+		IsArchived:    c.QueryInt("archived", 0),
+		IsSpyNode:     c.QueryInt("spynode", -1),
+		IsBanned:      c.QueryInt("banned", -1),
+		MRLBan:        c.Query("mrlban"),
+		DNSBan:        c.Query("dnsban"),
+		VersionStatus: c.Query("version_status"),
+		ZMQ:           c.Query("zmq"),
+		P2P:           c.Query("p2p"),
+		TLS:           c.Query("tls_valid"),
+		TLSExpiryDays: config.AppCfg().TLSExpiryDays,
+	}
+
+	locations, err := monero.New().NodeLocations(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(monero.NodesGeoJSON(locations, config.AppCfg().MapCoordPrecision), "application/geo+json")
+}
+
 // Returns probe logs reported by nodes (API endpoint, JSON data)
 func (s *fiberServer) probeLogsAPI(c *fiber.Ctx) error {
 	moneroRepo := monero.New()
@@ -392,6 +686,7 @@ func (s *fiberServer) probeLogsAPI(c *fiber.Ctx) error {
 		NodeID:       c.QueryInt("node_id", 0),
 		Status:       c.QueryInt("status", -1),
 		FailedReason: c.Query("failed_reason"),
+		FailureCode:  c.Query("failure_code"),
 	}
 
 	logs, err := moneroRepo.Logs(query)
@@ -410,6 +705,50 @@ func (s *fiberServer) probeLogsAPI(c *fiber.Ctx) error {
 	})
 }
 
+// Returns the number of failed probes per failure code (API endpoint, JSON
+// data)
+func (s *fiberServer) probeFailuresAPI(c *fiber.Ctx) error {
+	query := monero.QueryLogs{
+		NodeID:       c.QueryInt("node_id", 0),
+		FailedReason: c.Query("failed_reason"),
+		FailureCode:  c.Query("failure_code"),
+	}
+
+	summary, err := monero.New().FailureSummary(query)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data":    summary,
+	})
+}
+
+// Returns probe backlog and prober capacity per network type (API endpoint,
+// JSON data)
+func (s *fiberServer) schedulerStatusAPI(c *fiber.Ctx) error {
+	status, err := monero.New().SchedulerStatus(monero.DefaultSchedulePolicy())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data":    status,
+	})
+}
+
 // Handles `POST /nodes` request to add a new node
 //
 // Deprecated: AddNode is deprecated, use s.addNodeHandler with put method instead
@@ -424,11 +763,19 @@ func (s *fiberServer) addNodeAPI(c *fiber.Ctx) error {
 		})
 	}
 
+	// optional ZMQ pub and P2P ports, 0 means nettype default
+	zmqPort, _ := strconv.Atoi(c.FormValue("zmq_port", "0"))
+	p2pPort, _ := strconv.Atoi(c.FormValue("p2p_port", "0"))
+
 	protocol := c.FormValue("protocol")
 	hostname := c.FormValue("hostname")
+	login := monero.RPCLogin{
+		Username: c.FormValue("rpc_username"),
+		Password: c.FormValue("rpc_password"),
+	}
 
 	moneroRepo := monero.New()
-	if err := moneroRepo.Add(c.IP(), s.secret, protocol, hostname, uint(port)); err != nil {
+	if err := moneroRepo.Add(c.IP(), s.secret, protocol, hostname, uint(port), uint(max(zmqPort, 0)), uint(max(p2pPort, 0)), login); err != nil {
 		return c.JSON(fiber.Map{
 			"status":  "error",
 			"message": err.Error(),
@@ -443,6 +790,174 @@ func (s *fiberServer) addNodeAPI(c *fiber.Ctx) error {
 	})
 }
 
+// Returns node spy node and ban list flags across Rucknium's scan dates
+// (API endpoint, JSON data)
+func (s *fiberServer) nodeScanHistoryAPI(c *fiber.Ctx) error {
+	nodeID, err := c.ParamsInt("id", 0)
+	if err != nil || nodeID == 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid node id",
+			"data":    nil,
+		})
+	}
+
+	moneroRepo := monero.New()
+	node, err := moneroRepo.Node(nodeID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	history, err := moneroRepo.RuckniumHistory(node.IPAddresses, c.QueryInt("limit", 30))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data":    history,
+	})
+}
+
+// Returns response time percentiles and daily latency trend of a node (API
+// endpoint, JSON data)
+func (s *fiberServer) nodeLatencyAPI(c *fiber.Ctx) error {
+	nodeID, err := c.ParamsInt("id", 0)
+	if err != nil || nodeID <= 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid node id",
+			"data":    nil,
+		})
+	}
+
+	moneroRepo := monero.New()
+	stats, err := moneroRepo.NodeLatency(uint(nodeID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	trend, err := moneroRepo.LatencyTrend(uint(nodeID), c.QueryInt("days", 30))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data": fiber.Map{
+			"stats": stats,
+			"trend": trend,
+		},
+	})
+}
+
+// Returns network-wide spy node and ban list counts per Rucknium's scan date
+// (API endpoint, JSON data)
+func (s *fiberServer) scanTrendsAPI(c *fiber.Ctx) error {
+	trends, err := monero.New().RuckniumTrends(c.QueryInt("limit", 30))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data":    trends,
+	})
+}
+
+// Returns online node distribution by ASN, hosting provider, country and
+// continent per nettype (API endpoint, JSON data)
+func (s *fiberServer) networkDiversityAPI(c *fiber.Ctx) error {
+	diversity, err := monero.New().NetworkDiversity(monero.QueryDiversity{
+		Nettype: c.Query("nettype"),
+		TopN:    c.QueryInt("top", 10),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data":    diversity,
+	})
+}
+
+// Returns known monerod releases and online node count by version per
+// nettype (API endpoint, JSON data)
+func (s *fiberServer) versionsAPI(c *fiber.Ctx) error {
+	moneroRepo := monero.New()
+	adoption, err := moneroRepo.VersionAdoption(c.Query("nettype"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+	releases, err := moneroRepo.Releases()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data": fiber.Map{
+			"releases": releases,
+			"adoption": adoption,
+		},
+	})
+}
+
+// Returns hard fork readiness report of online nodes per nettype (API
+// endpoint, JSON data)
+func (s *fiberServer) hardForkReadinessAPI(c *fiber.Ctx) error {
+	readiness, err := monero.New().HardForkReadiness(c.Query("nettype"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"message": "Success",
+		"data":    readiness,
+	})
+}
+
 // Returns majority network fees (API endpoint, JSON data)
 func (s *fiberServer) netFeesAPI(c *fiber.Ctx) error {
 	moneroRepo := monero.New()
@@ -475,12 +990,19 @@ func (s *fiberServer) countriesAPI(c *fiber.Ctx) error {
 //
 // This handler should protected by `s.checkProberMW` middleware.
 func (s *fiberServer) giveJobAPI(c *fiber.Ctx) error {
-	acceptTor := c.QueryInt("accept_tor", 0)
-	acceptI2P := c.QueryInt("accept_i2p", 0)
-	acceptIPv6 := c.QueryInt("accept_ipv6", 0)
+	caps := monero.ProberCapabilities{
+		AcceptTor:  c.QueryInt("accept_tor", 0) == 1,
+		AcceptI2P:  c.QueryInt("accept_i2p", 0) == 1,
+		AcceptIPv6: c.QueryInt("accept_ipv6", 0) == 1,
+		Capacity:   c.QueryInt("capacity", 0),
+	}
+	proberID := c.Locals("prober_id").(int64)
+	if err := monero.NewProber().Declare(proberID, caps); err != nil {
+		slog.Warn(fmt.Sprintf("[JOB] Prober %d capabilities: %s", proberID, err))
+	}
 
 	moneroRepo := monero.New()
-	node, err := moneroRepo.GiveJob(acceptTor, acceptI2P, acceptIPv6)
+	node, err := moneroRepo.GiveJob(caps)
 	if err != nil {
 		return c.JSON(fiber.Map{
 			"status":  "error",
@@ -488,6 +1010,10 @@ func (s *fiberServer) giveJobAPI(c *fiber.Ctx) error {
 			"data":    nil,
 		})
 	}
+	if err := node.DecryptLogin(s.secret); err != nil {
+		// probe without credentials, the node will be reported as offline
+		slog.Warn(fmt.Sprintf("[JOB] Node %d RPC login: %s", node.ID, err))
+	}
 
 	return c.JSON(fiber.Map{
 		"status":  "ok",
@@ -510,6 +1036,15 @@ func (s *fiberServer) processJobAPI(c *fiber.Ctx) error {
 		})
 	}
 
+	// probers drop spooled reports rejected with 4xx status code
+	if _, err := report.CheckedTime(time.Now()); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+
 	moneroRepo := monero.New()
 
 	if err := moneroRepo.ProcessJob(report, c.Locals("prober_id").(int64)); err != nil {
@@ -1,11 +1,22 @@
 package handler
 
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
 func (s *fiberServer) Routes() {
 	s.Get("/", s.homeHandler)
 	s.Get("/robots.txt", s.robotsTxtHandler)
 	s.Get("/remote-nodes", s.remoteNodesHandler)
 	s.Get("/remote-nodes/id/:id", s.nodeHandler)
 	s.Get("/remote-nodes/ban-list-enabled", s.banListEnabledHandler)
+	s.Get("/remote-nodes/scan-trends", s.scanTrendsHandler)
+	s.Get("/remote-nodes/network-diversity", s.networkDiversityHandler)
+	s.Get("/remote-nodes/versions", s.versionsHandler)
+	s.Get("/remote-nodes/hardfork-readiness", s.hardForkReadinessHandler)
+	s.Get("/map", s.mapHandler)
 	s.Get("/add-node", s.addNodeHandler)
 	s.Put("/add-node", s.addNodeHandler)
 
@@ -13,16 +24,48 @@ func (s *fiberServer) Routes() {
 	// engine results updated to the new path, this route should be removed.
 	s.Get("/remote-nodes/logs", s.redirectLogs)
 
+	// Admin console routes, login attempts are rate limited per IP address
+	s.Get("/admin/login", s.adminLoginHandler)
+	s.Post("/admin/login", limiter.New(limiter.Config{
+		Max:        5,
+		Expiration: 1 * time.Minute,
+	}), s.adminLoginHandler)
+
+	adm := s.Group("/admin", s.checkAdminMW)
+	adm.Get("/", s.adminDashboardHandler)
+	adm.Post("/logout", s.adminLogoutHandler)
+	adm.Get("/probers", s.adminProbersHandler)
+	adm.Post("/probers", s.adminAddProberHandler)
+	adm.Post("/probers/:id", s.adminEditProberHandler)
+	adm.Delete("/probers/:id", s.adminDeleteProberHandler)
+	adm.Get("/nodes", s.adminNodesHandler)
+	adm.Post("/nodes/:id/archive", s.adminArchiveNodeHandler)
+	adm.Delete("/nodes/:id", s.adminDeleteNodeHandler)
+	adm.Get("/crons", s.adminCronsHandler)
+	adm.Post("/crons/:slug/:state<regex(^(enable|disable)$)>", s.adminToggleCronHandler)
+	adm.Get("/ban-list", s.adminBanListHandler)
+	adm.Get("/audit-logs", s.adminAuditLogsHandler)
+
 	// V1 API routes
 	v1 := s.Group("/api/v1")
 
 	// these routes are public, they don't require a prober api key
 	v1.Get("/nodes", s.nodesAPI)
+	v1.Get("/nodes.geojson", s.nodesGeoJSONAPI)
 	v1.Post("/nodes", s.addNodeAPI) // old add node form action endpoint. Deprecated: Use PUT /add-node instead
 	v1.Get("/nodes/id/:id", s.nodeAPI)
+	v1.Get("/nodes/id/:id/scan-history", s.nodeScanHistoryAPI)
+	v1.Get("/nodes/id/:id/tls", s.nodeTLSAPI)
+	v1.Get("/nodes/id/:id/latency", s.nodeLatencyAPI)
 	v1.Get("/nodes/logs", s.probeLogsAPI)
+	v1.Get("/nodes/logs/failures", s.probeFailuresAPI)
 	v1.Get("/fees", s.netFeesAPI)
+	v1.Get("/scan-trends", s.scanTrendsAPI)
 	v1.Get("/countries", s.countriesAPI)
+	v1.Get("/network-diversity", s.networkDiversityAPI)
+	v1.Get("/versions", s.versionsAPI)
+	v1.Get("/hardfork-readiness", s.hardForkReadinessAPI)
+	v1.Get("/scheduler/status", s.schedulerStatusAPI)
 
 	// these routes are for prober, they require a prober api key
 	v1.Get("/job", s.checkProberMW, s.giveJobAPI)
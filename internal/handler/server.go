@@ -24,8 +24,9 @@ func NewServer() *fiberServer {
 			ProxyHeader: config.AppCfg().ProxyHeader,
 			AppName:     "XMR Nodes Aggregator " + config.Version,
 		}),
-		db:  database.GetDB(),
-		url: config.AppCfg().URL,
+		db:     database.GetDB(),
+		url:    config.AppCfg().URL,
+		secret: config.AppCfg().Secret,
 	}
 
 	return server
@@ -0,0 +1,236 @@
+// Remote nodes world map.
+//
+// Draws node locations from `/api/v1/nodes.geojson` on a plain canvas using
+// equirectangular projection, so no third-party tile server learns who is
+// viewing the map. Nearby locations are clustered on the screen and coloured
+// by the share of online nodes.
+(function () {
+  const el = document.getElementById("node-map");
+  if (el === null || el.dataset.initialized === "1") {
+    return;
+  }
+  el.dataset.initialized = "1";
+
+  const canvas = el.querySelector("canvas");
+  const info = document.getElementById("node-map-info");
+  const ctx = canvas.getContext("2d");
+  const clusterRadius = 28; // in CSS pixels
+  const maxZoom = 32;
+
+  let features = [];
+  let clusters = [];
+  let view = { zoom: 1, lon: 0, lat: 20 };
+  let drag = null;
+
+  // Projects longitude/latitude to canvas pixel
+  function project(lon, lat) {
+    const scale = (canvas.clientWidth / 360) * view.zoom;
+    return [
+      canvas.clientWidth / 2 + (lon - view.lon) * scale,
+      canvas.clientHeight / 2 - (lat - view.lat) * scale,
+    ];
+  }
+
+  function unproject(x, y) {
+    const scale = (canvas.clientWidth / 360) * view.zoom;
+    return [
+      view.lon + (x - canvas.clientWidth / 2) / scale,
+      view.lat - (y - canvas.clientHeight / 2) / scale,
+    ];
+  }
+
+  function clamp(v, lo, hi) {
+    return Math.min(Math.max(v, lo), hi);
+  }
+
+  function colour(online, total) {
+    if (online === total) {
+      return "#16a34a";
+    }
+    if (online === 0) {
+      return "#e11d48";
+    }
+    return "#f59e0b";
+  }
+
+  // Greedy screen-space clustering of the visible features
+  function buildClusters() {
+    clusters = [];
+    for (const f of features) {
+      const [lon, lat] = f.geometry.coordinates;
+      const [x, y] = project(lon, lat);
+      let c = clusters.find(
+        (c) => Math.hypot(c.x - x, c.y - y) < clusterRadius,
+      );
+      if (c === undefined) {
+        c = { x: x, y: y, lon: lon, lat: lat, total: 0, online: 0, items: [] };
+        clusters.push(c);
+      }
+      c.total += f.properties.total;
+      c.online += f.properties.online;
+      c.items.push(f);
+    }
+  }
+
+  function draw() {
+    const ratio = window.devicePixelRatio || 1;
+    canvas.width = canvas.clientWidth * ratio;
+    canvas.height = canvas.clientHeight * ratio;
+    ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+    ctx.clearRect(0, 0, canvas.clientWidth, canvas.clientHeight);
+
+    // graticule
+    ctx.strokeStyle = "#404040";
+    ctx.lineWidth = 1;
+    ctx.beginPath();
+    for (let lon = -180; lon <= 180; lon += 30) {
+      const [x1, y1] = project(lon, 90);
+      const [x2, y2] = project(lon, -90);
+      ctx.moveTo(x1, y1);
+      ctx.lineTo(x2, y2);
+    }
+    for (let lat = -90; lat <= 90; lat += 30) {
+      const [x1, y1] = project(-180, lat);
+      const [x2, y2] = project(180, lat);
+      ctx.moveTo(x1, y1);
+      ctx.lineTo(x2, y2);
+    }
+    ctx.stroke();
+
+    buildClusters();
+    ctx.font = "bold 11px sans-serif";
+    ctx.textAlign = "center";
+    ctx.textBaseline = "middle";
+    for (const c of clusters) {
+      const r = 6 + Math.sqrt(c.total) * 3;
+      ctx.beginPath();
+      ctx.arc(c.x, c.y, r, 0, 2 * Math.PI);
+      ctx.fillStyle = colour(c.online, c.total);
+      ctx.globalAlpha = 0.8;
+      ctx.fill();
+      ctx.globalAlpha = 1;
+      if (c.total > 1) {
+        ctx.fillStyle = "#fff";
+        ctx.fillText(c.total, c.x, c.y);
+      }
+    }
+  }
+
+  function zoomAt(x, y, factor) {
+    const [lon, lat] = unproject(x, y);
+    view.zoom = clamp(view.zoom * factor, 1, maxZoom);
+    // keep the point under the cursor in place
+    const scale = (canvas.clientWidth / 360) * view.zoom;
+    view.lon = lon - (x - canvas.clientWidth / 2) / scale;
+    view.lat = lat + (y - canvas.clientHeight / 2) / scale;
+    draw();
+  }
+
+  function showInfo(c) {
+    info.replaceChildren();
+    const title = document.createElement("p");
+    title.className = "font-bold text-neutral-200";
+    title.textContent = c.online + " of " + c.total + " nodes online";
+    info.appendChild(title);
+
+    const list = document.createElement("ul");
+    list.className = "mt-2 space-y-1";
+    for (const f of c.items) {
+      const place = [f.properties.city, f.properties.country_name]
+        .filter((s) => s !== "")
+        .join(", ");
+      for (const n of f.properties.nodes) {
+        const li = document.createElement("li");
+        const a = document.createElement("a");
+        a.href = "/remote-nodes/id/" + n.id;
+        a.className = n.is_available ? "link" : "text-rose-400";
+        a.textContent =
+          n.protocol + "://" + n.hostname + ":" + n.port + " (" + n.nettype + ")";
+        li.appendChild(a);
+        if (place !== "") {
+          li.appendChild(document.createTextNode(" " + place));
+        }
+        list.appendChild(li);
+      }
+    }
+    info.appendChild(list);
+  }
+
+  function clusterAt(x, y) {
+    return clusters.find(
+      (c) => Math.hypot(c.x - x, c.y - y) <= 6 + Math.sqrt(c.total) * 3,
+    );
+  }
+
+  canvas.addEventListener(
+    "wheel",
+    (e) => {
+      e.preventDefault();
+      zoomAt(e.offsetX, e.offsetY, e.deltaY < 0 ? 1.5 : 1 / 1.5);
+    },
+    { passive: false },
+  );
+
+  canvas.addEventListener("pointerdown", (e) => {
+    drag = { x: e.offsetX, y: e.offsetY, lon: view.lon, lat: view.lat, moved: false };
+    canvas.setPointerCapture(e.pointerId);
+  });
+
+  canvas.addEventListener("pointermove", (e) => {
+    if (drag === null) {
+      canvas.style.cursor = clusterAt(e.offsetX, e.offsetY) ? "pointer" : "grab";
+      return;
+    }
+    const scale = (canvas.clientWidth / 360) * view.zoom;
+    const dx = e.offsetX - drag.x;
+    const dy = e.offsetY - drag.y;
+    drag.moved = drag.moved || Math.hypot(dx, dy) > 3;
+    view.lon = clamp(drag.lon - dx / scale, -180, 180);
+    view.lat = clamp(drag.lat + dy / scale, -90, 90);
+    draw();
+  });
+
+  canvas.addEventListener("pointerup", (e) => {
+    const moved = drag !== null && drag.moved;
+    drag = null;
+    if (moved) {
+      return;
+    }
+    const c = clusterAt(e.offsetX, e.offsetY);
+    if (c === undefined) {
+      return;
+    }
+    showInfo(c);
+    // zoom into clusters of several locations
+    if (c.items.length > 1 && view.zoom < maxZoom) {
+      zoomAt(c.x, c.y, 2);
+    }
+  });
+
+  el.querySelectorAll("[data-zoom]").forEach((btn) => {
+    btn.addEventListener("click", () => {
+      const factor = parseFloat(btn.dataset.zoom);
+      if (factor === 0) {
+        view = { zoom: 1, lon: 0, lat: 20 };
+        draw();
+        return;
+      }
+      zoomAt(canvas.clientWidth / 2, canvas.clientHeight / 2, factor);
+    });
+  });
+
+  window.addEventListener("resize", draw);
+
+  fetch("/api/v1/nodes.geojson" + window.location.search)
+    .then((resp) => resp.json())
+    .then((data) => {
+      features = data.features || [];
+      draw();
+    })
+    .catch((err) => {
+      info.textContent = "Failed to load node locations.";
+      console.error(err);
+    });
+
+  draw();
+})();
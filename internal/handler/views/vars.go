@@ -1,5 +1,14 @@
 package views
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/monero"
+)
+
 type link struct {
 	Text string
 	URI  string
@@ -40,6 +49,16 @@ var spyNodeOpts = []selectOpts{
 	{2, "N/A"},
 }
 
+// yesNoOpts is a list of possible yes / no filter representation in the UI
+//
+// The "Archived" and "Banned" filter select option in the admin UI is
+// populated from this list.
+var yesNoOpts = []selectOpts{
+	{-1, "ANY"},
+	{0, "NO"},
+	{1, "YES"},
+}
+
 // refreshIntevals, nettypes, and protocols are used to populate the refresh
 // interval, Monero network types, and protocols filter select options in the
 // UI
@@ -48,3 +67,100 @@ var (
 	nettypes        = []string{"mainnet", "stagenet", "testnet"}
 	protocols       = []string{"tor", "i2p", "http", "https"}
 )
+
+// versionStatusColor returns the version adoption chart bar color of the
+// given version status
+func versionStatusColor(status string) string {
+	switch status {
+	case monero.VersionCurrent:
+		return "bg-green-600"
+	case monero.VersionOutdated:
+		return "bg-amber-600"
+	case monero.VersionUnsupported, monero.VersionVulnerable:
+		return "bg-rose-600"
+	default:
+		return "bg-neutral-500"
+	}
+}
+
+// fmtLatency returns the response time in seconds, N/A if there is no latency
+// data
+func fmtLatency(seconds float64) string {
+	if seconds <= 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.3fs", seconds)
+}
+
+// fmtLatencyRegion returns the prober region of latency stats
+func fmtLatencyRegion(region string) string {
+	if region == "" {
+		return "All probers"
+	}
+	return region
+}
+
+// fmtLatencyWindow returns the latency rolling window in hours as text
+func fmtLatencyWindow(hours int) string {
+	if hours%24 == 0 {
+		if hours == 24 {
+			return "24 hours"
+		}
+		return fmt.Sprintf("%d days", hours/24)
+	}
+	return fmt.Sprintf("%d hours", hours)
+}
+
+// latencyChartWidth and latencyChartHeight are the latency trend chart SVG
+// viewBox size
+const (
+	latencyChartWidth  = 600
+	latencyChartHeight = 160
+)
+
+// latencyChartMax returns the latency trend chart y axis maximum in seconds
+func latencyChartMax(trend []monero.LatencyPoint) float64 {
+	if len(trend) == 0 {
+		return 0
+	}
+	p := slices.MaxFunc(trend, func(a, b monero.LatencyPoint) int {
+		switch {
+		case a.P90 < b.P90:
+			return -1
+		case a.P90 > b.P90:
+			return 1
+		}
+		return 0
+	})
+	return p.P90 * 1.1
+}
+
+// latencyChartPoints returns the latency trend chart SVG polyline points of
+// the p50 or p90 response time. The x axis spans from the oldest to the
+// newest trend day.
+func latencyChartPoints(trend []monero.LatencyPoint, p90 bool) string {
+	yMax := latencyChartMax(trend)
+	if yMax == 0 {
+		return ""
+	}
+	first, last := trend[0].Time, trend[len(trend)-1].Time
+	points := make([]string, 0, len(trend))
+	for _, p := range trend {
+		x := float64(latencyChartWidth) / 2
+		if last > first {
+			x = float64(p.Time-first) / float64(last-first) * latencyChartWidth
+		}
+		v := p.P50
+		if p90 {
+			v = p.P90
+		}
+		y := latencyChartHeight - v/yMax*latencyChartHeight
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+	return strings.Join(points, " ")
+}
+
+// fmtTrendDay returns the date of a latency trend day
+func fmtTrendDay(t int64) string {
+	return time.Unix(t, 0).UTC().Format("Jan 2")
+}
@@ -2,12 +2,31 @@ package geo
 
 import (
 	"errors"
-	"net"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/oschwald/geoip2-golang"
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
 )
 
-// IPInfo represents IP address information from Maxmind's GeoLite2 database
+const (
+	DefaultCityDB    = "./assets/geoip/GeoLite2-City.mmdb"
+	DefaultASNDB     = "./assets/geoip/GeoLite2-ASN.mmdb"
+	DefaultCacheSize = 4096
+
+	// how often database files are checked for changes
+	reloadCheckInterval = time.Minute
+)
+
+// ErrUnavailable is returned when none of the providers has information
+// about the IP address
+var ErrUnavailable = errors.New("GeoIP information is not available")
+
+// IPInfo represents IP address geolocation and ASN information
 type IPInfo struct {
 	IP                  string  `json:"ip"`
 	IsAnonymousProxy    bool    `json:"is_anonymous_proxy"`
@@ -24,53 +43,219 @@ type IPInfo struct {
 	AccuracyRadius      uint16  `json:"accuracy_radius"`
 	ASNOrg              string  `json:"asn_org"`
 	ASN                 uint    `json:"asn"`
+	Provider            string  `json:"provider"` // comma separated providers which answered
+}
+
+// Stats holds GeoIP service counters since the service started
+type Stats struct {
+	Providers   []ProviderStatus `json:"providers"`
+	Lookups     uint64           `json:"lookups"`
+	CacheHits   uint64           `json:"cache_hits"`
+	Unavailable uint64           `json:"unavailable"` // lookups without any provider answer
+	Errors      uint64           `json:"errors"`
+}
+
+// Service looks up IP addresses using a chain of providers.
+//
+// Providers are asked in order, each one only fills location or ASN
+// information not provided by the previous ones. Data sources are reloaded
+// when their files change (eg. after running `xmr-nodes geoip update`), and
+// lookup results are cached in memory. A missing data source is not fatal,
+// lookups return whatever information is available.
+type Service struct {
+	providers []IPInfoProvider
+	cache     *lruCache
+	reloads   atomic.Uint64 // provider reloads the cache content is from
+
+	lookups, cacheHits, unavailable, errors atomic.Uint64
+}
+
+// NewService returns Service using the given providers
+func NewService(providers []IPInfoProvider, cacheSize int) *Service {
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	return &Service{
+		providers: providers,
+		cache:     newLRUCache(cacheSize),
+	}
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultService *Service
+)
+
+// Default returns the process-wide Service, configured from the app config
+func Default() *Service {
+	defaultOnce.Do(func() {
+		cfg := config.AppCfg()
+		names := cfg.GeoIPProviders
+		if names == "" {
+			names = DefaultProviders
+		}
+		providers, err := NewProviders(names)
+		if err != nil {
+			slog.Error(fmt.Sprintf("[GeoIP] %s, using %s provider", err, DefaultProviders))
+			providers, _ = NewProviders(DefaultProviders)
+		}
+		defaultService = NewService(providers, cfg.GeoIPCacheSize)
+	})
+	return defaultService
 }
 
-// Info returns GeoIP information from given IP address
+// Info returns GeoIP information from given IP address using the default
+// Service
 func Info(ipAddr string) (*IPInfo, error) {
-	ip := net.ParseIP(ipAddr)
-	if ip == nil {
+	return Default().Lookup(ipAddr)
+}
+
+// Lookup returns GeoIP information of the given IP address. The Provider
+// field lists the providers which answered.
+func (s *Service) Lookup(ipAddr string) (*IPInfo, error) {
+	addr, err := netip.ParseAddr(ipAddr)
+	if err != nil {
 		return nil, errors.New("invalid IP address")
 	}
-	dbCity, err := geoip2.Open("./assets/geoip/GeoLite2-City.mmdb")
-	if err != nil {
-		return nil, errors.New("cannot open GeoIP City database")
+	addr = addr.Unmap()
+	s.lookups.Add(1)
+
+	reloads := s.checkReload()
+	if info, ok := s.cache.get(addr.String()); ok {
+		s.cacheHits.Add(1)
+		info.IP = ipAddr
+		return &info, nil
 	}
-	defer dbCity.Close()
 
-	dbAsn, err := geoip2.Open("./assets/geoip/GeoLite2-ASN.mmdb")
-	if err != nil {
-		return nil, errors.New("cannot open GeoIP ASN database")
+	info := IPInfo{IP: ipAddr}
+	var (
+		answered []string
+		lastErr  error
+	)
+	for _, p := range s.providers {
+		if info.complete() {
+			break
+		}
+		res, ok, err := p.Lookup(addr)
+		if err != nil {
+			s.errors.Add(1)
+			lastErr = fmt.Errorf("%s provider: %w", p.Name(), err)
+			continue
+		}
+		if ok && mergeIPInfo(&info, res) {
+			answered = append(answered, p.Name())
+		}
+	}
+	if len(answered) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		s.unavailable.Add(1)
+		return nil, ErrUnavailable
+	}
+	info.Provider = strings.Join(answered, ",")
+	if info.ContinentCode == "" {
+		info.ContinentCode, info.ContinentName = continentOf(info.CountryCode)
 	}
-	defer dbAsn.Close()
 
-	city, err := dbCity.City(ip)
-	if err != nil {
-		return nil, errors.New("cannot read GeoIP City database")
+	// partial results are not cached, so they are completed once the missing
+	// data source is available. Results of data reloaded meanwhile are not
+	// cached either.
+	if info.CountryCode != "" && info.ASN != 0 && s.reloads.Load() == reloads {
+		s.cache.add(addr.String(), info)
 	}
+	return &info, nil
+}
 
-	asn, err := dbAsn.ASN(ip)
-	if err != nil {
-		return nil, errors.New("cannot read GeoIP ASN database")
-	}
-
-	qip := IPInfo{
-		IP:                  ipAddr,
-		IsAnonymousProxy:    city.Traits.IsAnonymousProxy,
-		IsSatelliteProvider: city.Traits.IsSatelliteProvider,
-		City:                city.City.Names["en"],
-		ContinentName:       city.Continent.Names["en"],
-		ContinentCode:       city.Continent.Code,
-		IsInEuropeanUnion:   city.Country.IsInEuropeanUnion,
-		CountryName:         city.Country.Names["en"],
-		CountryCode:         city.Country.IsoCode,
-		TimeZone:            city.Location.TimeZone,
-		Latitude:            city.Location.Latitude,
-		Longitude:           city.Location.Longitude,
-		AccuracyRadius:      city.Location.AccuracyRadius,
-		ASNOrg:              asn.AutonomousSystemOrganization,
-		ASN:                 asn.AutonomousSystemNumber,
-	}
-
-	return &qip, nil
+// checkReload lets providers reload their changed data sources, also on
+// cache hits, and purges the cache if any of them reloaded. Returns the
+// number of provider reloads.
+func (s *Service) checkReload() uint64 {
+	var reloads uint64
+	for _, p := range s.providers {
+		if r, ok := p.(reloader); ok {
+			reloads += r.checkReload()
+		}
+	}
+	if s.reloads.Swap(reloads) != reloads {
+		s.cache.purge()
+	}
+	return reloads
+}
+
+// mergeIPInfo fills fields of dst which are still empty from src. Fields
+// tied to the country (country name, time zone, continent and EU membership)
+// are only taken from src reporting the same country, so a corrected
+// country is never mixed with another country's details. Returns true if any
+// field is taken from src.
+func mergeIPInfo(dst *IPInfo, src IPInfo) bool {
+	merged := false
+	fill := func(d *string, v string) {
+		if *d == "" && v != "" {
+			*d = v
+			merged = true
+		}
+	}
+
+	fill(&dst.CountryCode, src.CountryCode)
+	if dst.CountryCode == src.CountryCode {
+		fill(&dst.CountryName, src.CountryName)
+		fill(&dst.TimeZone, src.TimeZone)
+		if dst.ContinentCode == "" && src.ContinentCode != "" {
+			dst.ContinentCode = src.ContinentCode
+			dst.ContinentName = src.ContinentName
+			dst.IsInEuropeanUnion = src.IsInEuropeanUnion
+			merged = true
+		}
+	}
+	fill(&dst.City, src.City)
+	if dst.Latitude == 0 && dst.Longitude == 0 && (src.Latitude != 0 || src.Longitude != 0) {
+		dst.Latitude = src.Latitude
+		dst.Longitude = src.Longitude
+		dst.AccuracyRadius = src.AccuracyRadius
+		merged = true
+	}
+	if src.IsAnonymousProxy && !dst.IsAnonymousProxy {
+		dst.IsAnonymousProxy = true
+		merged = true
+	}
+	if src.IsSatelliteProvider && !dst.IsSatelliteProvider {
+		dst.IsSatelliteProvider = true
+		merged = true
+	}
+	if dst.ASN == 0 && src.ASN != 0 {
+		dst.ASN = src.ASN
+		dst.ASNOrg = src.ASNOrg
+		merged = true
+	}
+	return merged
+}
+
+// complete reports whether no further provider is needed
+func (info IPInfo) complete() bool {
+	return info.CountryCode != "" && info.ASN != 0 &&
+		info.City != "" && (info.Latitude != 0 || info.Longitude != 0)
+}
+
+// Stats returns service counters and provider states
+func (s *Service) Stats() Stats {
+	st := Stats{
+		Lookups:     s.lookups.Load(),
+		CacheHits:   s.cacheHits.Load(),
+		Unavailable: s.unavailable.Load(),
+		Errors:      s.errors.Load(),
+	}
+	for _, p := range s.providers {
+		st.Providers = append(st.Providers, p.Status())
+	}
+	return st
+}
+
+// Close closes opened databases
+func (s *Service) Close() {
+	for _, p := range s.providers {
+		if c, ok := p.(interface{ Close() }); ok {
+			c.Close()
+		}
+	}
 }
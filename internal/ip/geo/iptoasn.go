@@ -0,0 +1,128 @@
+package geo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// asnRange is a single IPtoASN (https://iptoasn.com) IP address range
+type asnRange struct {
+	start, end netip.Addr
+	asn        uint
+	cc         string
+	org        string
+}
+
+// ipToASNProvider reads IPtoASN `ip2asn-combined.tsv` (or the v4/v6 only
+// variants) dump. It provides ASN and country code only.
+type ipToASNProvider struct {
+	fileProvider
+	ranges []asnRange
+}
+
+func newIPtoASNProvider(path string) *ipToASNProvider {
+	p := &ipToASNProvider{}
+	p.name = ProviderIPtoASN
+	p.files = []*watchedFile{{path: path}}
+	p.load = func(_ int, path string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		ranges, err := parseIPtoASN(f)
+		if err != nil {
+			return err
+		}
+		p.ranges = ranges
+		return nil
+	}
+	p.maybeReload()
+	return p
+}
+
+// parseIPtoASN parses tab separated `range_start range_end AS_number
+// country_code AS_description` lines. Not routed ranges (AS0) are skipped.
+func parseIPtoASN(r io.Reader) ([]asnRange, error) {
+	var ranges []asnRange
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %d: expected 5 tab separated fields", line)
+		}
+		start, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid AS number %q", line, fields[2])
+		}
+		if asn == 0 {
+			continue
+		}
+		cc := fields[3]
+		if cc == "None" {
+			cc = ""
+		}
+		ranges = append(ranges, asnRange{
+			start: start.Unmap(),
+			end:   end.Unmap(),
+			asn:   uint(asn),
+			cc:    cc,
+			org:   fields[4],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no IP address range found")
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start.Less(ranges[j].start) })
+	return ranges, nil
+}
+
+// findASNRange returns the range containing addr. Ranges must be sorted by
+// their start address and must not overlap.
+func findASNRange(ranges []asnRange, addr netip.Addr) (asnRange, bool) {
+	// first range starting after addr, the candidate is the one before it
+	i := sort.Search(len(ranges), func(i int) bool { return addr.Less(ranges[i].start) })
+	if i == 0 {
+		return asnRange{}, false
+	}
+	r := ranges[i-1]
+	if r.end.Less(addr) || r.start.Is4() != addr.Is4() {
+		return asnRange{}, false
+	}
+	return r, true
+}
+
+func (p *ipToASNProvider) Lookup(addr netip.Addr) (IPInfo, bool, error) {
+	p.maybeReload()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	r, ok := findASNRange(p.ranges, addr.Unmap())
+	if !ok {
+		return IPInfo{}, false, nil
+	}
+	return IPInfo{CountryCode: r.cc, ASN: r.asn, ASNOrg: r.org}, true, nil
+}
@@ -0,0 +1,93 @@
+package geo
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// mmdbProvider reads City and ASN databases in MaxMind DB format, which is
+// used by both MaxMind GeoLite2 and DB-IP lite databases
+type mmdbProvider struct {
+	fileProvider
+	city, asn *geoip2.Reader
+}
+
+func newMMDBProvider(name, cityPath, asnPath string) *mmdbProvider {
+	p := &mmdbProvider{}
+	p.name = name
+	p.files = []*watchedFile{{path: cityPath}, {path: asnPath}}
+	p.load = func(idx int, path string) error {
+		reader, err := geoip2.Open(path)
+		if err != nil {
+			return err
+		}
+		// no lookup is using the old reader, the provider lock is held
+		old := &p.city
+		if idx == 1 {
+			old = &p.asn
+		}
+		if *old != nil {
+			(*old).Close()
+		}
+		*old = reader
+		return nil
+	}
+	p.maybeReload()
+	return p
+}
+
+func (p *mmdbProvider) Lookup(addr netip.Addr) (IPInfo, bool, error) {
+	p.maybeReload()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.city == nil && p.asn == nil {
+		return IPInfo{}, false, nil
+	}
+
+	ip := net.IP(addr.AsSlice())
+	var info IPInfo
+	if p.city != nil {
+		city, err := p.city.City(ip)
+		if err != nil {
+			return IPInfo{}, false, errors.New("cannot read GeoIP City database")
+		}
+		info.IsAnonymousProxy = city.Traits.IsAnonymousProxy
+		info.IsSatelliteProvider = city.Traits.IsSatelliteProvider
+		info.City = city.City.Names["en"]
+		info.ContinentName = city.Continent.Names["en"]
+		info.ContinentCode = city.Continent.Code
+		info.IsInEuropeanUnion = city.Country.IsInEuropeanUnion
+		info.CountryName = city.Country.Names["en"]
+		info.CountryCode = city.Country.IsoCode
+		info.TimeZone = city.Location.TimeZone
+		info.Latitude = city.Location.Latitude
+		info.Longitude = city.Location.Longitude
+		info.AccuracyRadius = city.Location.AccuracyRadius
+	}
+	if p.asn != nil {
+		asn, err := p.asn.ASN(ip)
+		if err != nil {
+			return IPInfo{}, false, errors.New("cannot read GeoIP ASN database")
+		}
+		info.ASNOrg = asn.AutonomousSystemOrganization
+		info.ASN = asn.AutonomousSystemNumber
+	}
+
+	return info, info.CountryCode != "" || info.ASN != 0, nil
+}
+
+// Close closes opened databases
+func (p *mmdbProvider) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, r := range []**geoip2.Reader{&p.city, &p.asn} {
+		if *r != nil {
+			(*r).Close()
+			*r = nil
+		}
+	}
+}
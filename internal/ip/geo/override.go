@@ -0,0 +1,148 @@
+package geo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// overrideEntry is a single manually corrected network
+type overrideEntry struct {
+	prefix netip.Prefix
+	info   IPInfo
+}
+
+// overrideProvider reads local CSV file used to correct misattributed hosts.
+// It should be the first configured provider, so its entries take
+// precedence.
+//
+// Each line is `network,country_code,country_name,city,latitude,longitude,asn,asn_org`
+// where network is IP address or CIDR subnet. Empty columns are filled by
+// the next providers, eg. `203.0.113.7,DE,Germany,,,,,` corrects the country
+// (and the continent derived from it) while city, coordinates and AS number
+// still come from the next providers.
+type overrideProvider struct {
+	fileProvider
+	entries []overrideEntry
+}
+
+func newOverrideProvider(path string) *overrideProvider {
+	p := &overrideProvider{}
+	p.name = ProviderOverride
+	p.files = []*watchedFile{{path: path}}
+	p.load = func(_ int, path string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		entries, err := parseOverrideCSV(f)
+		if err != nil {
+			return err
+		}
+		p.entries = entries
+		return nil
+	}
+	p.maybeReload()
+	return p
+}
+
+// parseOverrideCSV parses override entries, sorted from the most specific
+// network. Lines starting with `#` and the optional `network,...` header are
+// ignored.
+func parseOverrideCSV(r io.Reader) ([]overrideEntry, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var entries []overrideEntry
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rec) > 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "network") {
+			continue // header
+		}
+		if len(rec) != 8 {
+			return nil, fmt.Errorf("line %d: expected 8 columns, got %d", line, len(rec))
+		}
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+
+		prefix, ok := parseOverrideNetwork(rec[0])
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid network %q", line, rec[0])
+		}
+		e := overrideEntry{prefix: prefix, info: IPInfo{
+			CountryCode: strings.ToUpper(rec[1]),
+			CountryName: rec[2],
+			City:        rec[3],
+			ASNOrg:      rec[7],
+		}}
+		if rec[4] != "" || rec[5] != "" {
+			if e.info.Latitude, err = strconv.ParseFloat(rec[4], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid latitude %q", line, rec[4])
+			}
+			if e.info.Longitude, err = strconv.ParseFloat(rec[5], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid longitude %q", line, rec[5])
+			}
+		}
+		if rec[6] != "" {
+			asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(rec[6]), "AS"), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid AS number %q", line, rec[6])
+			}
+			e.info.ASN = uint(asn)
+		}
+		if e.info.CountryCode == "" && e.info.ASN == 0 {
+			return nil, fmt.Errorf("line %d: country code or AS number is required", line)
+		}
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].prefix.Bits() > entries[j].prefix.Bits() })
+	return entries, nil
+}
+
+func parseOverrideNetwork(s string) (netip.Prefix, bool) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		return p.Masked(), true
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+func (p *overrideProvider) Lookup(addr netip.Addr) (IPInfo, bool, error) {
+	p.maybeReload()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	addr = addr.Unmap()
+	for _, e := range p.entries {
+		if e.prefix.Contains(addr) {
+			return e.info, true, nil
+		}
+	}
+	return IPInfo{}, false, nil
+}
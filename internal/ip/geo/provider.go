@@ -0,0 +1,226 @@
+package geo
+
+import (
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+)
+
+// Provider names, used in `GEOIP_PROVIDERS` and recorded in IPInfo.Provider
+const (
+	ProviderMaxMind  = "maxmind"
+	ProviderDBIP     = "dbip"
+	ProviderIPtoASN  = "iptoasn"
+	ProviderOverride = "override"
+)
+
+// DefaultProviders is used when `GEOIP_PROVIDERS` is not configured
+const DefaultProviders = ProviderMaxMind
+
+const (
+	DefaultDBIPCityDB  = "./assets/geoip/dbip-city-lite.mmdb"
+	DefaultDBIPASNDB   = "./assets/geoip/dbip-asn-lite.mmdb"
+	DefaultIPtoASNTSV  = "./assets/geoip/ip2asn-combined.tsv"
+	DefaultOverrideCSV = "./assets/geoip/override.csv"
+)
+
+// IPInfoProvider looks up IP address metadata from a single data source.
+//
+// Lookup returns false if the provider has no information about the IP
+// address or its data source is not available.
+type IPInfoProvider interface {
+	Name() string
+	Lookup(addr netip.Addr) (IPInfo, bool, error)
+	Status() ProviderStatus
+}
+
+// reloader is implemented by providers whose data source is reloaded when
+// it changes. checkReload reloads changed data sources and returns the
+// number of reloads so far, Service purges its cache when it grows.
+type reloader interface {
+	checkReload() uint64
+}
+
+// ProviderStatus is the data source state of a provider
+type ProviderStatus struct {
+	Name       string    `json:"name"`
+	Loaded     bool      `json:"loaded"`
+	Reloads    uint64    `json:"reloads"`
+	LastReload time.Time `json:"last_reload"`
+}
+
+// Path returns configured database path of the given provider and kind.
+// Kind is only relevant to MMDB providers.
+func Path(provider, kind string) string {
+	cfg := config.AppCfg()
+	pick := func(configured, fallback string) string {
+		if configured != "" {
+			return configured
+		}
+		return fallback
+	}
+
+	switch provider {
+	case ProviderDBIP:
+		if kind == KindASN {
+			return pick(cfg.DBIPASNDB, DefaultDBIPASNDB)
+		}
+		return pick(cfg.DBIPCityDB, DefaultDBIPCityDB)
+	case ProviderIPtoASN:
+		return pick(cfg.IPtoASNTSV, DefaultIPtoASNTSV)
+	case ProviderOverride:
+		return pick(cfg.GeoIPOverrideCSV, DefaultOverrideCSV)
+	default:
+		if kind == KindASN {
+			return pick(cfg.GeoIPASNDB, DefaultASNDB)
+		}
+		return pick(cfg.GeoIPCityDB, DefaultCityDB)
+	}
+}
+
+// NewProviders returns providers in the given comma separated order, eg.
+// `override,maxmind,iptoasn`. Data source paths are taken from the app
+// config.
+func NewProviders(names string) ([]IPInfoProvider, error) {
+	var providers []IPInfoProvider
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate GeoIP provider %q", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case ProviderMaxMind, ProviderDBIP:
+			providers = append(providers, newMMDBProvider(name, Path(name, KindCity), Path(name, KindASN)))
+		case ProviderIPtoASN:
+			providers = append(providers, newIPtoASNProvider(Path(name, "")))
+		case ProviderOverride:
+			providers = append(providers, newOverrideProvider(Path(name, "")))
+		default:
+			return nil, fmt.Errorf("unknown GeoIP provider %q", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no GeoIP provider configured")
+	}
+	return providers, nil
+}
+
+// watchedFile tracks modification time of a data source file, so providers
+// can reload it when it changes
+type watchedFile struct {
+	path      string
+	modTime   time.Time
+	lastCheck time.Time
+	loaded    bool
+	warned    bool
+	reloads   uint64
+	reloadTS  time.Time
+}
+
+// due reports whether the file should be checked for changes, at most once
+// per reloadCheckInterval
+func (w *watchedFile) due() bool {
+	return time.Since(w.lastCheck) >= reloadCheckInterval
+}
+
+// reload calls load if the file is modified since it was loaded. The load
+// func must only replace provider data on success. The caller must hold the
+// provider lock.
+func (w *watchedFile) reload(name string, load func(path string) error) {
+	w.lastCheck = time.Now()
+	info, err := os.Stat(w.path)
+	if err == nil && w.loaded && info.ModTime().Equal(w.modTime) {
+		return
+	}
+	if err == nil {
+		err = load(w.path)
+	}
+	if err != nil {
+		// warn once, keep using the previously loaded data (if any)
+		if !w.warned {
+			slog.Warn(fmt.Sprintf("[GeoIP] %s provider: %s", name, err))
+			w.warned = true
+		}
+		return
+	}
+	if w.loaded {
+		slog.Info(fmt.Sprintf("[GeoIP] %s provider reloaded", name))
+	}
+	w.modTime = info.ModTime()
+	w.loaded = true
+	w.warned = false
+	w.reloads++
+	w.reloadTS = time.Now()
+}
+
+func (w *watchedFile) status(name string) ProviderStatus {
+	return ProviderStatus{
+		Name:       name,
+		Loaded:     w.loaded,
+		Reloads:    w.reloads,
+		LastReload: w.reloadTS,
+	}
+}
+
+// fileProvider is the common reload logic of providers backed by files
+type fileProvider struct {
+	mu    sync.RWMutex
+	name  string
+	files []*watchedFile
+	load  func(idx int, path string) error
+}
+
+func (p *fileProvider) maybeReload() {
+	p.mu.RLock()
+	due := false
+	for _, f := range p.files {
+		due = due || f.due()
+	}
+	p.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, f := range p.files {
+		if f.due() {
+			f.reload(p.name, func(path string) error { return p.load(i, path) })
+		}
+	}
+}
+
+func (p *fileProvider) checkReload() uint64 {
+	p.maybeReload()
+	return p.Status().Reloads
+}
+
+func (p *fileProvider) Name() string { return p.name }
+
+// Status reports the provider as loaded if any of its files is loaded
+func (p *fileProvider) Status() ProviderStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	st := ProviderStatus{Name: p.name}
+	for _, f := range p.files {
+		fs := f.status(p.name)
+		st.Loaded = st.Loaded || fs.Loaded
+		st.Reloads += fs.Reloads
+		if fs.LastReload.After(st.LastReload) {
+			st.LastReload = fs.LastReload
+		}
+	}
+	return st
+}
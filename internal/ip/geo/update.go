@@ -0,0 +1,178 @@
+package geo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Database kinds
+const (
+	KindCity = "city"
+	KindASN  = "asn"
+)
+
+// UpdateOptions describes GeoIP database to download and where to install it
+type UpdateOptions struct {
+	Provider   string // maxmind or dbip
+	Kind       string // city or asn
+	Dest       string // database file path
+	AccountID  string // MaxMind account ID
+	LicenseKey string // MaxMind license key
+}
+
+// validationIP is looked up to make sure the downloaded database is usable
+var validationIP = net.ParseIP("1.1.1.1")
+
+// downloadURL returns the URL of the database. MaxMind credentials are
+// passed as URL user info (HTTP basic auth).
+func (o UpdateOptions) downloadURL(now time.Time) (string, error) {
+	if o.Kind != KindCity && o.Kind != KindASN {
+		return "", fmt.Errorf("unknown GeoIP database kind %q", o.Kind)
+	}
+
+	switch o.Provider {
+	case ProviderMaxMind:
+		if o.AccountID == "" || o.LicenseKey == "" {
+			return "", errors.New("MaxMind account ID and license key are required")
+		}
+		edition := "GeoLite2-City"
+		if o.Kind == KindASN {
+			edition = "GeoLite2-ASN"
+		}
+		u := url.URL{
+			Scheme:   "https",
+			User:     url.UserPassword(o.AccountID, o.LicenseKey),
+			Host:     "download.maxmind.com",
+			Path:     "/geoip/databases/" + edition + "/download",
+			RawQuery: "suffix=tar.gz",
+		}
+		return u.String(), nil
+	case ProviderDBIP:
+		// DB-IP lite databases are published monthly and compatible with
+		// GeoLite2 database format
+		return fmt.Sprintf("https://download.db-ip.com/free/dbip-%s-lite-%s.mmdb.gz", o.Kind, now.UTC().Format("2006-01")), nil
+	default:
+		return "", fmt.Errorf("unknown GeoIP database provider %q", o.Provider)
+	}
+}
+
+// Update downloads, validates and installs GeoIP database. The database file
+// is replaced atomically, running Service picks it up on its next reload
+// check.
+func Update(ctx context.Context, f *fetcher.Fetcher, o UpdateOptions) error {
+	u, err := o.downloadURL(time.Now())
+	if err != nil {
+		return err
+	}
+	resp, err := f.FetchUnconditional(ctx, u)
+	if err != nil {
+		return fmt.Errorf("download %s %s database: %w", o.Provider, o.Kind, err)
+	}
+	data, err := extractMMDB(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := validateMMDB(data, o.Kind); err != nil {
+		return err
+	}
+	return installFile(o.Dest, data)
+}
+
+// extractMMDB returns the `.mmdb` file content from plain, gzip or tar.gz
+// archive data
+func extractMMDB(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return data, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+
+	// tar archive has "ustar" magic at offset 257
+	if len(raw) < 262 || string(raw[257:262]) != "ustar" {
+		return raw, nil
+	}
+	tr := tar.NewReader(bytes.NewReader(raw))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no .mmdb file found in archive")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && strings.HasSuffix(hdr.Name, ".mmdb") {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// validateMMDB makes sure data is a readable database of the given kind
+func validateMMDB(data []byte, kind string) error {
+	reader, err := geoip2.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("invalid GeoIP database: %w", err)
+	}
+	defer reader.Close()
+
+	if kind == KindASN {
+		_, err = reader.ASN(validationIP)
+	} else {
+		_, err = reader.City(validationIP)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid GeoIP %s database (%s): %w", kind, reader.Metadata().DatabaseType, err)
+	}
+	return nil
+}
+
+// installFile writes data to a temporary file next to dest and renames it,
+// so readers never see a partially written database
+func installFile(dest string, data []byte) error {
+	dir := filepath.Dir(dest)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
@@ -0,0 +1,290 @@
+package monero
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultDiversityTopN = 10
+	maxDiversityTopN     = 50
+)
+
+// continentNames maps GeoIP continent codes to their names
+var continentNames = map[string]string{
+	"AF": "Africa",
+	"AN": "Antarctica",
+	"AS": "Asia",
+	"EU": "Europe",
+	"NA": "North America",
+	"OC": "Oceania",
+	"SA": "South America",
+}
+
+// Share is the number of nodes of a single ASN, hosting provider, country or
+// continent
+type Share struct {
+	Key     string  `json:"key"` // AS number, country or continent code, normalized provider name
+	Name    string  `json:"name"`
+	Nodes   int     `json:"nodes"`
+	Percent float64 `json:"percent"` // percentage of clearnet nodes
+}
+
+// Concentration is Herfindahl-Hirschman Index (HHI) of a node distribution.
+// HHI is the sum of squared percentage shares, ranging from near 0 (evenly
+// distributed) to 10000 (every node in a single ASN, provider or country).
+// Nodes with unknown ASN or location are left out of the calculation.
+type Concentration struct {
+	ASN      float64 `json:"asn"`
+	Provider float64 `json:"provider"`
+	Country  float64 `json:"country"`
+}
+
+// NetworkDiversity represents node distribution of a single nettype
+type NetworkDiversity struct {
+	Nettype           string        `json:"nettype"`
+	OnlineNodes       int           `json:"online_nodes"`
+	ClearnetNodes     int           `json:"clearnet_nodes"`
+	HiddenNodes       int           `json:"hidden_nodes"` // Tor and I2P nodes have no GeoIP data
+	HHI               Concentration `json:"hhi"`
+	TopASNs           []Share       `json:"top_asns"`
+	ByProvider        []Share       `json:"by_provider"`
+	ByCountry         []Share       `json:"by_country"`
+	ByContinent       []Share       `json:"by_continent"`
+	DistinctASNs      int           `json:"distinct_asns"`
+	DistinctCountries int           `json:"distinct_countries"`
+}
+
+// QueryDiversity represents network diversity query parameters
+type QueryDiversity struct {
+	Nettype string // empty string returns all nettypes
+	TopN    int    // number of top ASNs and providers, default 10
+}
+
+// diversityRow is online node count grouped by its network and location
+type diversityRow struct {
+	Nettype     string `db:"nettype"`
+	IsHidden    bool   `db:"is_hidden"`
+	ASN         uint   `db:"asn"`
+	ASNName     string `db:"asn_name"`
+	CountryCode string `db:"country"`
+	CountryName string `db:"country_name"`
+	Continent   string `db:"continent"`
+	Nodes       int    `db:"nodes"`
+}
+
+// NetworkDiversity returns online node distribution by ASN, hosting provider,
+// country and continent per nettype
+func (r *moneroRepo) NetworkDiversity(q QueryDiversity) ([]NetworkDiversity, error) {
+	where := `is_available = 1 AND is_archived = 0 AND nettype != ''`
+	var args []interface{}
+	if q.Nettype != "" {
+		if q.Nettype != "mainnet" && q.Nettype != "stagenet" && q.Nettype != "testnet" {
+			return nil, fmt.Errorf("invalid nettype %q", q.Nettype)
+		}
+		where += ` AND nettype = ?`
+		args = append(args, q.Nettype)
+	}
+
+	var rows []diversityRow
+	err := r.db.Select(&rows, fmt.Sprintf(`
+		SELECT
+			nettype,
+			IF(is_tor = 1 OR is_i2p = 1, 1, 0) AS is_hidden,
+			asn,
+			MAX(asn_name) AS asn_name,
+			country,
+			MAX(country_name) AS country_name,
+			continent,
+			COUNT(id) AS nodes
+		FROM
+			tbl_node
+		WHERE
+			%s
+		GROUP BY
+			nettype,
+			is_hidden,
+			asn,
+			country,
+			continent`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildDiversity(rows, q.TopN), nil
+}
+
+// buildDiversity aggregates grouped rows into per nettype distributions,
+// ordered by nettype
+func buildDiversity(rows []diversityRow, topN int) []NetworkDiversity {
+	if topN <= 0 {
+		topN = defaultDiversityTopN
+	}
+	topN = min(topN, maxDiversityTopN)
+
+	byNettype := make(map[string][]diversityRow)
+	for _, row := range rows {
+		byNettype[row.Nettype] = append(byNettype[row.Nettype], row)
+	}
+
+	result := []NetworkDiversity{}
+	for nettype, rows := range byNettype {
+		d := NetworkDiversity{Nettype: nettype}
+		asns := newShareCounter()
+		providers := newShareCounter()
+		countries := newShareCounter()
+		continents := newShareCounter()
+
+		for _, row := range rows {
+			d.OnlineNodes += row.Nodes
+			if row.IsHidden {
+				d.HiddenNodes += row.Nodes
+				continue
+			}
+			d.ClearnetNodes += row.Nodes
+
+			if row.ASN != 0 {
+				asns.add(fmt.Sprintf("AS%d", row.ASN), row.ASNName, row.Nodes)
+				providers.add(providerKey(row.ASNName), row.ASNName, row.Nodes)
+			} else {
+				asns.add("", "Unknown", row.Nodes)
+				providers.add("", "Unknown", row.Nodes)
+			}
+			countries.add(row.CountryCode, countryName(row.CountryCode, row.CountryName), row.Nodes)
+			continents.add(row.Continent, continentName(row.Continent), row.Nodes)
+		}
+
+		d.HHI = Concentration{
+			ASN:      asns.hhi(),
+			Provider: providers.hhi(),
+			Country:  countries.hhi(),
+		}
+		d.TopASNs = asns.shares(d.ClearnetNodes, topN)
+		d.ByProvider = providers.shares(d.ClearnetNodes, topN)
+		d.ByCountry = countries.shares(d.ClearnetNodes, 0)
+		d.ByContinent = continents.shares(d.ClearnetNodes, 0)
+		d.DistinctASNs = asns.known()
+		d.DistinctCountries = countries.known()
+		result = append(result, d)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return nettypeOrder(result[i].Nettype) < nettypeOrder(result[j].Nettype) })
+	return result
+}
+
+func nettypeOrder(nettype string) int {
+	switch nettype {
+	case "mainnet":
+		return 0
+	case "stagenet":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// providerKey normalizes AS organization name, so multiple ASNs operated by
+// the same hosting provider are counted together (eg. "Hetzner Online GmbH"
+// AS24940 and AS213230)
+func providerKey(asnName string) string {
+	return strings.Join(strings.Fields(strings.ToLower(asnName)), " ")
+}
+
+func countryName(cc, name string) string {
+	if cc == "" {
+		return "Unknown"
+	}
+	if name == "" {
+		return cc
+	}
+	return name
+}
+
+func continentName(code string) string {
+	if name, ok := continentNames[code]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// shareCounter counts nodes by key, the empty key is the unknown group
+type shareCounter struct {
+	counts map[string]*Share
+}
+
+func newShareCounter() *shareCounter {
+	return &shareCounter{counts: make(map[string]*Share)}
+}
+
+func (c *shareCounter) add(key, name string, nodes int) {
+	s, ok := c.counts[key]
+	if !ok {
+		s = &Share{Key: key, Name: name}
+		c.counts[key] = s
+	}
+	s.Nodes += nodes
+}
+
+// known returns the number of distinct known keys
+func (c *shareCounter) known() int {
+	n := len(c.counts)
+	if _, ok := c.counts[""]; ok {
+		n--
+	}
+	return n
+}
+
+// hhi returns Herfindahl-Hirschman Index of the known keys
+func (c *shareCounter) hhi() float64 {
+	total := 0
+	for key, s := range c.counts {
+		if key != "" {
+			total += s.Nodes
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var hhi float64
+	for key, s := range c.counts {
+		if key == "" {
+			continue
+		}
+		pct := float64(s.Nodes) / float64(total) * 100
+		hhi += pct * pct
+	}
+	return math.Round(hhi)
+}
+
+// shares returns node shares ordered by node count, limited to n (0 means no
+// limit). The unknown group is always listed last.
+func (c *shareCounter) shares(total, n int) []Share {
+	shares := make([]Share, 0, len(c.counts))
+	var unknown *Share
+	for key, s := range c.counts {
+		if total > 0 {
+			s.Percent = math.Round(float64(s.Nodes)/float64(total)*10000) / 100
+		}
+		if key == "" {
+			unknown = s
+			continue
+		}
+		shares = append(shares, *s)
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Nodes != shares[j].Nodes {
+			return shares[i].Nodes > shares[j].Nodes
+		}
+		return shares[i].Key < shares[j].Key
+	})
+	if n > 0 && len(shares) > n {
+		shares = shares[:n]
+	}
+	if unknown != nil {
+		shares = append(shares, *unknown)
+	}
+	return shares
+}
@@ -0,0 +1,58 @@
+package monero
+
+import (
+	"testing"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestBuildDiversity -v
+func TestBuildDiversity(t *testing.T) {
+	rows := []diversityRow{
+		{Nettype: "stagenet", ASN: 16276, ASNName: "OVH SAS", CountryCode: "FR", CountryName: "France", Continent: "EU", Nodes: 1},
+		{Nettype: "mainnet", ASN: 24940, ASNName: "Hetzner Online GmbH", CountryCode: "DE", CountryName: "Germany", Continent: "EU", Nodes: 4},
+		{Nettype: "mainnet", ASN: 213230, ASNName: "Hetzner Online  GmbH", CountryCode: "FI", CountryName: "Finland", Continent: "EU", Nodes: 2},
+		{Nettype: "mainnet", ASN: 16276, ASNName: "OVH SAS", CountryCode: "US", CountryName: "United States", Continent: "NA", Nodes: 2},
+		{Nettype: "mainnet", ASN: 0, CountryCode: "", Continent: "", Nodes: 2},
+		{Nettype: "mainnet", IsHidden: true, Nodes: 5},
+	}
+
+	got := buildDiversity(rows, 1)
+	if len(got) != 2 || got[0].Nettype != "mainnet" || got[1].Nettype != "stagenet" {
+		t.Fatalf("unexpected nettypes: %+v", got)
+	}
+
+	m := got[0]
+	if m.OnlineNodes != 15 || m.ClearnetNodes != 10 || m.HiddenNodes != 5 {
+		t.Errorf("node counts = %d/%d/%d, want 15/10/5", m.OnlineNodes, m.ClearnetNodes, m.HiddenNodes)
+	}
+	if m.DistinctASNs != 3 || m.DistinctCountries != 3 {
+		t.Errorf("distinct ASNs/countries = %d/%d, want 3/3", m.DistinctASNs, m.DistinctCountries)
+	}
+
+	// top 1 ASN plus the unknown group
+	if len(m.TopASNs) != 2 || m.TopASNs[0].Key != "AS24940" || m.TopASNs[0].Percent != 40 || m.TopASNs[1].Name != "Unknown" {
+		t.Errorf("unexpected top ASNs: %+v", m.TopASNs)
+	}
+
+	// both Hetzner ASNs are counted as a single provider
+	if m.ByProvider[0].Nodes != 6 || m.ByProvider[0].Percent != 60 {
+		t.Errorf("unexpected top provider: %+v", m.ByProvider[0])
+	}
+
+	// known ASN shares: 4/8, 2/8, 2/8 => 2500 + 625 + 625
+	if m.HHI.ASN != 3750 {
+		t.Errorf("ASN HHI = %v, want 3750", m.HHI.ASN)
+	}
+	// known provider shares: 6/8, 2/8 => 5625 + 625
+	if m.HHI.Provider != 6250 {
+		t.Errorf("provider HHI = %v, want 6250", m.HHI.Provider)
+	}
+
+	if len(m.ByContinent) != 3 || m.ByContinent[0].Name != "Europe" || m.ByContinent[0].Nodes != 6 {
+		t.Errorf("unexpected continents: %+v", m.ByContinent)
+	}
+
+	if s := got[1]; s.HHI.Country != 10000 || s.HHI.ASN != 10000 {
+		t.Errorf("single node HHI = %+v, want 10000", s.HHI)
+	}
+}
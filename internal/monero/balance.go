@@ -0,0 +1,204 @@
+package monero
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Network types of nodes
+const (
+	NetworkClearnet = "clearnet"
+	NetworkTor      = "tor"
+	NetworkI2P      = "i2p"
+)
+
+const (
+	// defaultProberCapacity is the capacity of probers not declaring it,
+	// the example systemd timer probes every 30 seconds
+	defaultProberCapacity = 120
+	// proberActiveWindow is how long a prober counts as active after its
+	// last job request
+	proberActiveWindow = time.Hour
+	// jobStatusTTL is how long GiveJob reuses the scheduler status to pick
+	// the network, the status aggregates all nodes and probers
+	jobStatusTTL = time.Minute
+)
+
+// jobStatus is the scheduler status cached for GiveJob
+var jobStatus struct {
+	sync.Mutex
+	status  SchedulerStatus
+	expires time.Time
+}
+
+// NetworkStatus is the probe backlog and prober capacity of a network type
+type NetworkStatus struct {
+	Network  string `json:"network" db:"network"`
+	Nodes    int    `json:"nodes" db:"nodes"`         // non-archived nodes
+	Backlog  int    `json:"backlog" db:"backlog"`     // nodes due for probing
+	NewNodes int    `json:"new_nodes" db:"new_nodes"` // never probed nodes
+
+	// seconds since the longest waiting due node was last checked (or
+	// submitted if never checked), 0 if there is no backlog
+	OldestUncheckedAge int64 `json:"oldest_unchecked_age" db:"-"`
+	OldestChecked      int64 `json:"-" db:"oldest_checked"`
+
+	TargetInterval int `json:"target_interval" db:"-"` // in seconds
+	Demand         int `json:"demand" db:"-"`          // probes per hour to meet the target interval
+	Probers        int `json:"probers" db:"-"`         // active probers accepting the network
+	Capacity       int `json:"capacity" db:"-"`        // probes per hour of the active probers
+}
+
+// SchedulerStatus is the job scheduler status per network type
+type SchedulerStatus struct {
+	Networks      []NetworkStatus `json:"networks"`
+	ActiveProbers int             `json:"active_probers"`
+	CheckedAt     int64           `json:"checked_at"`
+}
+
+type activeProber struct {
+	ProberCapabilities
+	ID int64 `db:"id"`
+}
+
+func (c ProberCapabilities) accepts(network string) bool {
+	switch network {
+	case NetworkTor:
+		return c.AcceptTor
+	case NetworkI2P:
+		return c.AcceptI2P
+	}
+	return true
+}
+
+// SchedulerStatus returns the probe backlog and active prober capacity of
+// each network type
+func (r *moneroRepo) SchedulerStatus(policy SchedulePolicy) (SchedulerStatus, error) {
+	now := time.Now()
+	status := SchedulerStatus{
+		Networks:  []NetworkStatus{},
+		CheckedAt: now.Unix(),
+	}
+
+	rows := []NetworkStatus{}
+	err := r.db.Select(&rows, `
+		SELECT
+			CASE
+				WHEN is_tor = 1 THEN 'tor'
+				WHEN is_i2p = 1 THEN 'i2p'
+				ELSE 'clearnet'
+			END AS network,
+			COUNT(id) AS nodes,
+			SUM(IF(next_check <= ?, 1, 0)) AS backlog,
+			SUM(IF(next_check = 0, 1, 0)) AS new_nodes,
+			COALESCE(MIN(IF(next_check <= ?, IF(last_checked > 0, last_checked, date_entered), NULL)), 0) AS oldest_checked
+		FROM
+			tbl_node
+		WHERE
+			is_archived = ?
+		GROUP BY
+			network`, now.Unix(), now.Unix(), 0)
+	if err != nil {
+		return status, err
+	}
+
+	probers := []activeProber{}
+	err = r.db.Select(&probers, `
+		SELECT
+			id,
+			accept_tor,
+			accept_i2p,
+			accept_ipv6,
+			capacity
+		FROM
+			tbl_prober
+		WHERE
+			last_job_ts >= ?`, now.Add(-proberActiveWindow).Unix())
+	if err != nil {
+		return status, err
+	}
+	status.ActiveProbers = len(probers)
+
+	// always list all network types, even without nodes
+	for _, network := range []string{NetworkClearnet, NetworkTor, NetworkI2P} {
+		ns := NetworkStatus{Network: network}
+		if i := slices.IndexFunc(rows, func(r NetworkStatus) bool { return r.Network == network }); i >= 0 {
+			ns = rows[i]
+		}
+		status.Networks = append(status.Networks, ns.withCapacity(policy, probers, now))
+	}
+	return status, nil
+}
+
+// jobSchedulerStatus returns the scheduler status, refreshed at most once
+// per jobStatusTTL. A stale backlog only affects which network is preferred,
+// GiveJob falls back to any accepted network.
+func (r *moneroRepo) jobSchedulerStatus(policy SchedulePolicy) (SchedulerStatus, error) {
+	jobStatus.Lock()
+	defer jobStatus.Unlock()
+
+	if time.Now().Before(jobStatus.expires) {
+		return jobStatus.status, nil
+	}
+	status, err := r.SchedulerStatus(policy)
+	if err != nil {
+		return status, err
+	}
+	jobStatus.status = status
+	jobStatus.expires = time.Now().Add(jobStatusTTL)
+	return status, nil
+}
+
+func (ns NetworkStatus) withCapacity(policy SchedulePolicy, probers []activeProber, now time.Time) NetworkStatus {
+	if ns.Backlog > 0 && ns.OldestChecked > 0 {
+		ns.OldestUncheckedAge = now.Unix() - ns.OldestChecked
+	}
+
+	interval := policy.Clearnet
+	switch ns.Network {
+	case NetworkTor:
+		interval = policy.Tor
+	case NetworkI2P:
+		interval = policy.I2P
+	}
+	ns.TargetInterval = int(interval.Seconds())
+	if interval > 0 {
+		ns.Demand = int(float64(ns.Nodes) * float64(time.Hour) / float64(interval))
+	}
+
+	for _, p := range probers {
+		if !p.accepts(ns.Network) {
+			continue
+		}
+		ns.Probers++
+		if p.Capacity > 0 {
+			ns.Capacity += p.Capacity
+		} else {
+			ns.Capacity += defaultProberCapacity
+		}
+	}
+	return ns
+}
+
+// pickNetwork returns the network type the prober should probe next, empty
+// if none of the accepted networks has backlog. The backlog is weighted by
+// the capacity of probers able to probe the network: a Tor capable prober
+// takes onion nodes while few others can, and helps with clearnet nodes once
+// the clearnet backlog grows larger relative to its capacity.
+func pickNetwork(networks []NetworkStatus, caps ProberCapabilities) string {
+	var (
+		picked   string
+		pressure float64
+	)
+	for _, ns := range networks {
+		if ns.Backlog == 0 || !caps.accepts(ns.Network) {
+			continue
+		}
+		p := float64(ns.Backlog) / float64(max(ns.Capacity, 1))
+		if picked == "" || p > pressure {
+			picked, pressure = ns.Network, p
+		}
+	}
+	return picked
+}
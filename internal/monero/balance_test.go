@@ -0,0 +1,76 @@
+package monero
+
+import (
+	"testing"
+	"time"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestPickNetwork -v
+func TestPickNetwork(t *testing.T) {
+	networks := []NetworkStatus{
+		{Network: NetworkClearnet, Backlog: 100, Capacity: 360},
+		{Network: NetworkTor, Backlog: 40, Capacity: 120},
+		{Network: NetworkI2P, Backlog: 0, Capacity: 120},
+	}
+	tests := []struct {
+		name     string
+		networks []NetworkStatus
+		caps     ProberCapabilities
+		want     string
+	}{
+		{"clearnet prober", networks, ProberCapabilities{}, NetworkClearnet},
+		{"tor prober takes onion backlog", networks, ProberCapabilities{AcceptTor: true}, NetworkTor},
+		{"no i2p backlog", networks, ProberCapabilities{AcceptI2P: true}, NetworkClearnet},
+		{
+			"tor prober helps with clearnet backlog",
+			[]NetworkStatus{
+				{Network: NetworkClearnet, Backlog: 500, Capacity: 360},
+				{Network: NetworkTor, Backlog: 10, Capacity: 120},
+			},
+			ProberCapabilities{AcceptTor: true},
+			NetworkClearnet,
+		},
+		{
+			"no capacity",
+			[]NetworkStatus{
+				{Network: NetworkClearnet, Backlog: 5, Capacity: 120},
+				{Network: NetworkTor, Backlog: 5},
+			},
+			ProberCapabilities{AcceptTor: true},
+			NetworkTor,
+		},
+		{"no backlog", []NetworkStatus{{Network: NetworkClearnet}}, ProberCapabilities{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickNetwork(tt.networks, tt.caps); got != tt.want {
+				t.Errorf("pickNetwork() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestNetworkStatus_withCapacity -v
+func TestNetworkStatus_withCapacity(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	policy := SchedulePolicy{Clearnet: time.Hour, Tor: 2 * time.Hour, I2P: 2 * time.Hour}
+	probers := []activeProber{
+		{ProberCapabilities: ProberCapabilities{Capacity: 60}},
+		{ProberCapabilities: ProberCapabilities{AcceptTor: true}},
+	}
+
+	ns := NetworkStatus{Network: NetworkTor, Nodes: 100, Backlog: 3, OldestChecked: now.Unix() - 600}.withCapacity(policy, probers, now)
+	if ns.Probers != 1 || ns.Capacity != defaultProberCapacity {
+		t.Errorf("tor probers = %d, capacity = %d, want 1, %d", ns.Probers, ns.Capacity, defaultProberCapacity)
+	}
+	if ns.Demand != 50 || ns.TargetInterval != 7200 || ns.OldestUncheckedAge != 600 {
+		t.Errorf("tor demand = %d, interval = %d, age = %d, want 50, 7200, 600", ns.Demand, ns.TargetInterval, ns.OldestUncheckedAge)
+	}
+
+	ns = NetworkStatus{Network: NetworkClearnet, Nodes: 10, OldestChecked: now.Unix() - 600}.withCapacity(policy, probers, now)
+	if ns.Probers != 2 || ns.Capacity != 60+defaultProberCapacity || ns.OldestUncheckedAge != 0 {
+		t.Errorf("clearnet probers = %d, capacity = %d, age = %d", ns.Probers, ns.Capacity, ns.OldestUncheckedAge)
+	}
+}
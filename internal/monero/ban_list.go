@@ -2,56 +2,343 @@ package monero
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
-	"net/http"
 	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
 )
 
-// Fetch and store IP addresses from Boog900's ban list to local db
-func (r *moneroRepo) FetchBoog900BanList() error {
-	resp, err := http.Get("https://raw.githubusercontent.com/Boog900/monero-ban-list/main/ban_list.txt")
+// DefaultBanListSources is used when `BAN_LIST_SOURCES` is not configured
+const DefaultBanListSources = "boog900=https://raw.githubusercontent.com/Boog900/monero-ban-list/main/ban_list.txt"
+
+// banListStagingTable is filled with the new ban list version before it is
+// atomically swapped with tbl_ban_list
+const banListStagingTable = "tbl_ban_list_staging"
+
+var validBanListSourceName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,100}$`)
+
+// BanListSource is a named ban list location, either HTTP(S) URL or local
+// file path.
+type BanListSource struct {
+	Name     string
+	Location string
+}
+
+// BanListEntry is a single banned IP address or subnet attributed to the
+// source it comes from
+type BanListEntry struct {
+	IPAddr string `json:"ip_addr" db:"ip_addr"`
+	Source string `json:"source" db:"source"`
+}
+
+// BanListVersion is a single ban list update
+type BanListVersion struct {
+	ID           int64  `json:"id" db:"id"`
+	Sources      string `json:"sources" db:"sources"`
+	TotalEntries int    `json:"total_entries" db:"total_entries"`
+	Added        int    `json:"added" db:"added"`
+	Removed      int    `json:"removed" db:"removed"`
+	DateCreated  int64  `json:"date_created" db:"date_created"`
+}
+
+// BanListChange is a single added or removed entry of a ban list version
+type BanListChange struct {
+	BanListEntry
+	Change string `json:"change" db:"change_type"` // added | removed
+}
+
+// ParseBanListSources parses comma separated `name=location` pairs, eg:
+// `boog900=https://example.com/ban_list.txt,local=/etc/xmr-nodes/ban.txt`.
+func ParseBanListSources(s string) ([]BanListSource, error) {
+	var sources []BanListSource
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, location, ok := strings.Cut(pair, "=")
+		name, location = strings.TrimSpace(name), strings.TrimSpace(location)
+		if !ok || location == "" {
+			return nil, fmt.Errorf("invalid ban list source %q, must be name=location", pair)
+		}
+		if !validBanListSourceName.MatchString(name) {
+			return nil, fmt.Errorf("invalid ban list source name %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate ban list source name %q", name)
+		}
+		seen[name] = true
+		sources = append(sources, BanListSource{Name: name, Location: location})
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no ban list source configured")
+	}
+	return sources, nil
+}
+
+// parseBanListEntries reads one IP address or subnet per line. Empty lines,
+// comments (everything after `#`) and invalid entries are ignored. Entries
+// are normalized and deduplicated.
+func parseBanListEntries(r io.Reader) ([]string, error) {
+	var entries []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		p, ok := parseIPCIDRToPrefix(line)
+		if !ok {
+			continue
+		}
+		entry := p.Masked().String()
+		if p.IsSingleIP() {
+			entry = p.Addr().String()
+		}
+		if !seen[entry] {
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// diffBanList returns entries which exist in next but not in prev (added)
+// and entries which exist in prev but not in next (removed).
+func diffBanList(prev, next []BanListEntry) (added, removed []BanListEntry) {
+	prevSet := make(map[BanListEntry]bool, len(prev))
+	for _, e := range prev {
+		prevSet[e] = true
+	}
+	nextSet := make(map[BanListEntry]bool, len(next))
+	for _, e := range next {
+		nextSet[e] = true
+		if !prevSet[e] {
+			added = append(added, e)
+		}
+	}
+	for _, e := range prev {
+		if !nextSet[e] {
+			removed = append(removed, e)
+		}
+	}
+	return added, removed
+}
+
+// FetchBanLists fetches all configured ban list sources and replaces the
+// stored ban list if any entry changed. Returns the number of stored entries.
+//
+// Sources unchanged since the last fetch keep their stored entries. If any
+// source fails, the stored ban list is kept as is. The new list is written to
+// a staging table first and swapped with `RENAME TABLE`, so readers never see
+// a partial list.
+func (r *moneroRepo) FetchBanLists(ctx context.Context, f *fetcher.Fetcher, sources []BanListSource) (int64, error) {
+	next, responses, err := fetchBanLists(ctx, f, r, sources)
 	if err != nil {
-		slog.Error(fmt.Sprintf("[MRL] Failed to download Boog900's ban list: %s", err))
-		return err
+		return 0, err
+	}
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		names[i] = src.Name
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("[MRL] HTTP request return with status code:  %d ", resp.StatusCode)
+	prev, err := r.BanListEntries("")
+	if err != nil {
+		return 0, err
+	}
+	added, removed := diffBanList(prev, next)
+	if len(added) == 0 && len(removed) == 0 {
+		slog.Info("[MRL] Ban list unchanged")
+		r.commitFetches(ctx, f, responses)
+		return int64(len(next)), nil
 	}
 
-	// turncate tbl_ban_list table
-	if _, err := r.db.Exec("TRUNCATE TABLE tbl_ban_list"); err != nil {
-		return err
+	if err := r.swapBanList(ctx, next); err != nil {
+		return 0, err
 	}
+	r.commitFetches(ctx, f, responses)
+	if err := r.addBanListVersion(ctx, strings.Join(names, ","), len(next), added, removed); err != nil {
+		// the new list is already in use, only the history is missing
+		slog.Error(fmt.Sprintf("[MRL] Failed to store ban list version: %s", err))
+	}
+	slog.Info(fmt.Sprintf("[MRL] Ban list updated: %d entries, %d added, %d removed", len(next), len(added), len(removed)))
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		ip := scanner.Text()
+	return int64(len(next)), nil
+}
 
-		if _, ok := parseIPCIDRToPrefix(ip); !ok {
-			continue
+// banListStore provides stored ban list entries of sources unchanged since
+// the last fetch
+type banListStore interface {
+	BanListEntries(source string) ([]BanListEntry, error)
+}
+
+// fetchBanLists fetches all ban list sources and returns their entries
+// attributed to the source they come from. It fails if any source fails or
+// has no valid entries.
+func fetchBanLists(ctx context.Context, f *fetcher.Fetcher, store banListStore, sources []BanListSource) ([]BanListEntry, []*fetcher.Response, error) {
+	var (
+		next      []BanListEntry
+		responses []*fetcher.Response
+	)
+	for _, src := range sources {
+		entries, resp, err := fetchBanListSource(ctx, f, store, src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ban list source %s: %w", src.Name, err)
+		}
+		if len(entries) == 0 {
+			return nil, nil, fmt.Errorf("ban list source %s: no valid entries", src.Name)
+		}
+		slog.Debug(fmt.Sprintf("[MRL] Ban list source %s: %d entries", src.Name, len(entries)))
+		for _, e := range entries {
+			next = append(next, BanListEntry{IPAddr: e, Source: src.Name})
+		}
+		responses = append(responses, resp)
+	}
+	return next, responses, nil
+}
+
+// fetchBanListSource returns entries of the ban list source. If the source
+// is unchanged since the last fetch, the stored entries are returned.
+func fetchBanListSource(ctx context.Context, f *fetcher.Fetcher, store banListStore, src BanListSource) ([]string, *fetcher.Response, error) {
+	resp, err := f.Fetch(ctx, src.Location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.NotModified {
+		stored, err := store.BanListEntries(src.Name)
+		if err != nil {
+			return nil, nil, err
 		}
+		if len(stored) > 0 {
+			entries := make([]string, len(stored))
+			for i, e := range stored {
+				entries[i] = e.IPAddr
+			}
+			return entries, resp, nil
+		}
+		// nothing stored for this source (eg. renamed), download it again
+		if resp, err = f.FetchUnconditional(ctx, src.Location); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entries, err := parseBanListEntries(bytes.NewReader(resp.Body))
+	return entries, resp, err
+}
+
+func (r *moneroRepo) commitFetches(ctx context.Context, f *fetcher.Fetcher, responses []*fetcher.Response) {
+	for _, resp := range responses {
+		if err := f.Commit(ctx, resp); err != nil {
+			slog.Warn(fmt.Sprintf("[MRL] Failed to store cache validators of %s: %s", resp.Location, err))
+		}
+	}
+}
 
-		_, err := r.db.Exec(`INSERT INTO tbl_ban_list (ip_addr) VALUES (?)`, ip)
+func (r *moneroRepo) swapBanList(ctx context.Context, entries []BanListEntry) error {
+	if _, err := r.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+banListStagingTable); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "CREATE TABLE "+banListStagingTable+" LIKE tbl_ban_list"); err != nil {
+		return err
+	}
+
+	const batchSize = 500
+	for start := 0; start < len(entries); start += batchSize {
+		batch := entries[start:min(start+batchSize, len(entries))]
+		_, err := r.db.NamedExecContext(ctx,
+			"INSERT IGNORE INTO "+banListStagingTable+" (ip_addr, source) VALUES (:ip_addr, :source)",
+			batch)
 		if err != nil {
-			slog.Error(fmt.Sprintf("[MRL] Failed to insert ip: %s", err))
+			return err
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
+	// leftover of an interrupted swap would make the rename fail
+	if _, err := r.db.ExecContext(ctx, "DROP TABLE IF EXISTS tbl_ban_list_old"); err != nil {
+		return err
+	}
+	// RENAME TABLE is atomic, readers see either the old or the new list
+	_, err := r.db.ExecContext(ctx, `
+		RENAME TABLE
+			tbl_ban_list TO tbl_ban_list_old,
+			`+banListStagingTable+` TO tbl_ban_list`)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "DROP TABLE tbl_ban_list_old")
+	return err
+}
+
+func (r *moneroRepo) addBanListVersion(ctx context.Context, sources string, total int, added, removed []BanListEntry) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO tbl_ban_list_version (
+			sources,
+			total_entries,
+			added,
+			removed,
+			date_created
+		) VALUES (
+			?,
+			?,
+			?,
+			?,
+			?
+		)`, sources, total, len(added), len(removed), time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	versionID, err := res.LastInsertId()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	for change, entries := range map[string][]BanListEntry{"added": added, "removed": removed} {
+		for _, e := range entries {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO tbl_ban_list_diff (
+					version_id,
+					ip_addr,
+					source,
+					change_type
+				) VALUES (
+					?,
+					?,
+					?,
+					?
+				)`, versionID, e.IPAddr, e.Source, change)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// BanList returns list of banned IP addresses (may contain subnets)
+func (r *moneroRepo) BanList() ([]string, error) {
+	return r.banList()
 }
 
 // Get list of IP addresses (may contain subnets) from local database
 func (r *moneroRepo) banList() ([]string, error) {
 	var ips []string
-	rows, err := r.db.Query("SELECT ip_addr FROM tbl_ban_list")
+	rows, err := r.db.Query("SELECT DISTINCT ip_addr FROM tbl_ban_list")
 	if err != nil {
 		return ips, err
 	}
@@ -72,6 +359,221 @@ func (r *moneroRepo) banList() ([]string, error) {
 	return ips, err
 }
 
+// BanListEntries returns ban list entries with their source. If source is not
+// empty, only entries from that source are returned.
+func (r *moneroRepo) BanListEntries(source string) ([]BanListEntry, error) {
+	entries := []BanListEntry{}
+	query := `SELECT ip_addr, source FROM tbl_ban_list`
+	args := []interface{}{}
+	if source != "" {
+		query += ` WHERE source = ?`
+		args = append(args, source)
+	}
+	err := r.db.Select(&entries, query+` ORDER BY source, ip_addr`, args...)
+	return entries, err
+}
+
+// CheckBanList returns ban list entries (and their sources) which contain
+// the given IP address
+func (r *moneroRepo) CheckBanList(ip string) ([]BanListEntry, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := r.BanListEntries("")
+	if err != nil {
+		return nil, err
+	}
+	return matchBanList(entries, addr.Unmap()), nil
+}
+
+func matchBanList(entries []BanListEntry, addr netip.Addr) []BanListEntry {
+	matches := []BanListEntry{}
+	for _, e := range entries {
+		if p, ok := parseIPCIDRToPrefix(e.IPAddr); ok && p.Contains(addr) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+// bannedPrefix is parsed ban list entry
+type bannedPrefix struct {
+	prefix netip.Prefix
+	entry  BanListEntry
+}
+
+func parseBannedPrefixes(entries []BanListEntry) []bannedPrefix {
+	prefixes := make([]bannedPrefix, 0, len(entries))
+	for _, e := range entries {
+		if p, ok := parseIPCIDRToPrefix(e.IPAddr); ok {
+			prefixes = append(prefixes, bannedPrefix{prefix: p, entry: e})
+		}
+	}
+	return prefixes
+}
+
+// banReason returns the reason why one of the comma separated IP addresses
+// is banned, or empty string if none of them is banned.
+func banReason(prefixes []bannedPrefix, ipAddresses string) string {
+	for _, ip := range strings.Split(ipAddresses, ",") {
+		addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		for _, p := range prefixes {
+			if p.prefix.Contains(addr) {
+				return fmt.Sprintf("%s is listed in %s ban list (%s)", addr, p.entry.Source, p.entry.IPAddr)
+			}
+		}
+	}
+	return ""
+}
+
+// RecheckBannedNodes re-evaluates every clearnet node's current IP addresses
+// against the stored ban list, flags (or clears) `is_banned` along with the
+// reason, and archives newly banned nodes if autoArchive is true. Nodes it
+// archived are restored once they are no longer banned. Returns the number
+// of updated nodes.
+func (r *moneroRepo) RecheckBannedNodes(ctx context.Context, autoArchive bool) (int64, error) {
+	entries, err := r.BanListEntries("")
+	if err != nil {
+		return 0, err
+	}
+	prefixes := parseBannedPrefixes(entries)
+
+	var nodes []Node
+	err = r.db.SelectContext(ctx, &nodes, `
+		SELECT
+			id,
+			ip_addresses,
+			is_archived,
+			archived_reason,
+			is_banned,
+			ban_reason
+		FROM
+			tbl_node
+		WHERE
+			is_tor = ?
+			AND is_i2p = ?`, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	for _, node := range nodes {
+		if ctx.Err() != nil {
+			return updated, ctx.Err()
+		}
+
+		next, changed := recheckBan(prefixes, node, autoArchive)
+		if !changed {
+			continue
+		}
+		if next.IsBanned == 1 && node.IsBanned == 0 {
+			slog.Info(fmt.Sprintf("[MRL] Node #%d banned: %s", node.ID, next.BanReason))
+		}
+		if next.IsArchived == 0 && node.IsArchived == 1 {
+			slog.Info(fmt.Sprintf("[MRL] Node #%d no longer banned, restored from archive", node.ID))
+		}
+		_, err := r.db.ExecContext(ctx, `
+			UPDATE tbl_node
+			SET
+				is_banned = ?,
+				ban_reason = ?,
+				is_archived = ?,
+				archived_reason = ?
+			WHERE
+				id = ?`, next.IsBanned, next.BanReason, next.IsArchived, next.ArchivedReason, node.ID)
+		if err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// recheckBan evaluates node IP addresses against the ban list and returns
+// the node with updated ban and archive state, and whether anything changed.
+func recheckBan(prefixes []bannedPrefix, node Node, autoArchive bool) (Node, bool) {
+	next := node
+	next.BanReason = banReason(prefixes, node.IPAddresses)
+	next.IsBanned = 0
+	if next.BanReason != "" {
+		next.IsBanned = 1
+	}
+	next.IsArchived, next.ArchivedReason = banArchiveState(node, next.IsBanned == 1, autoArchive)
+
+	changed := next.IsBanned != node.IsBanned ||
+		next.BanReason != node.BanReason ||
+		next.IsArchived != node.IsArchived
+	return next, changed
+}
+
+// banArchiveState returns the archive state of the node after its ban status
+// is evaluated. Only nodes archived by the ban list are restored, nodes
+// archived for other reasons stay archived.
+func banArchiveState(node Node, isBanned, autoArchive bool) (isArchived int, reason string) {
+	switch {
+	case node.IsArchived == 1 && node.ArchivedReason == ArchivedByBanList && !isBanned:
+		return 0, ""
+	case node.IsArchived == 0 && isBanned && autoArchive:
+		return 1, ArchivedByBanList
+	}
+	return node.IsArchived, node.ArchivedReason
+}
+
+// BanListVersions returns latest ban list versions
+func (r *moneroRepo) BanListVersions(limit int) ([]BanListVersion, error) {
+	versions := []BanListVersion{}
+	err := r.db.Select(&versions, `
+		SELECT
+			id,
+			sources,
+			total_entries,
+			added,
+			removed,
+			date_created
+		FROM
+			tbl_ban_list_version
+		ORDER BY
+			id DESC
+		LIMIT ?`, limit)
+	return versions, err
+}
+
+// BanListDiff returns added and removed entries of the given ban list
+// version. If versionID is 0, the latest version is used.
+func (r *moneroRepo) BanListDiff(versionID int64) (BanListVersion, []BanListChange, error) {
+	var v BanListVersion
+	query := `SELECT id, sources, total_entries, added, removed, date_created FROM tbl_ban_list_version`
+	var err error
+	if versionID == 0 {
+		err = r.db.Get(&v, query+` ORDER BY id DESC LIMIT 1`)
+	} else {
+		err = r.db.Get(&v, query+` WHERE id = ?`, versionID)
+	}
+	if err != nil {
+		return v, nil, err
+	}
+
+	changes := []BanListChange{}
+	err = r.db.Select(&changes, `
+		SELECT
+			ip_addr,
+			source,
+			change_type
+		FROM
+			tbl_ban_list_diff
+		WHERE
+			version_id = ?
+		ORDER BY
+			change_type, source, ip_addr`, v.ID)
+	return v, changes, err
+}
+
 // Check if the given IP address is on the blacklist
 //
 // TODO: Use `netip.Addr` for ips from net/netip package instead of `net.IP`.
@@ -1,9 +1,14 @@
 package monero
 
 import (
+	"context"
 	"net"
 	"net/netip"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
 )
 
 // Single test:
@@ -188,3 +193,357 @@ func Benchmark_IsBannedIP(b *testing.B) {
 		_ = isBannedIP(banList, inputIPs)
 	}
 }
+
+func TestParseBanListSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []BanListSource
+		wantErr bool
+	}{
+		{
+			name:  "Single URL",
+			input: "boog900=https://example.com/ban_list.txt",
+			want:  []BanListSource{{"boog900", "https://example.com/ban_list.txt"}},
+		},
+		{
+			name:  "URL and local file with spaces",
+			input: " boog900 = https://example.com/ban_list.txt , local=/etc/ban.txt,",
+			want: []BanListSource{
+				{"boog900", "https://example.com/ban_list.txt"},
+				{"local", "/etc/ban.txt"},
+			},
+		},
+		{
+			name:    "Empty",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "Missing location",
+			input:   "boog900=",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid name",
+			input:   "boog 900=https://example.com",
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate name",
+			input:   "a=/tmp/a.txt,a=/tmp/b.txt",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBanListSources(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBanListSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBanListSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBanListEntries(t *testing.T) {
+	input := `# Monero Research Lab spy node ban list
+192.168.1.1
+192.168.1.1 # duplicate with comment
+10.0.0.5/8
+2001:db8::1
+invalid
+
+  172.16.0.0/12
+`
+	got, err := parseBanListEntries(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"192.168.1.1", "10.0.0.0/8", "2001:db8::1", "172.16.0.0/12"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseBanListEntries() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffBanList(t *testing.T) {
+	prev := []BanListEntry{
+		{"1.1.1.1", "a"},
+		{"2.2.2.0/24", "a"},
+		{"3.3.3.3", "b"},
+	}
+	next := []BanListEntry{
+		{"1.1.1.1", "a"},
+		{"3.3.3.3", "a"}, // same IP, different source
+		{"4.4.4.4", "b"},
+	}
+	added, removed := diffBanList(prev, next)
+	wantAdded := []BanListEntry{{"3.3.3.3", "a"}, {"4.4.4.4", "b"}}
+	wantRemoved := []BanListEntry{{"2.2.2.0/24", "a"}, {"3.3.3.3", "b"}}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("diffBanList() added = %v, want %v", added, wantAdded)
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("diffBanList() removed = %v, want %v", removed, wantRemoved)
+	}
+
+	added, removed = diffBanList(next, next)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("diffBanList() of the same list = %v, %v, want no changes", added, removed)
+	}
+}
+
+func TestMatchBanList(t *testing.T) {
+	entries := []BanListEntry{
+		{"192.168.1.0/24", "a"},
+		{"192.168.1.10", "b"},
+		{"2001:db8::/32", "a"},
+	}
+	tests := []struct {
+		ip   string
+		want []BanListEntry
+	}{
+		{"192.168.1.10", []BanListEntry{{"192.168.1.0/24", "a"}, {"192.168.1.10", "b"}}},
+		{"192.168.1.20", []BanListEntry{{"192.168.1.0/24", "a"}}},
+		{"2001:db8::abcd", []BanListEntry{{"2001:db8::/32", "a"}}},
+		{"8.8.8.8", []BanListEntry{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got := matchBanList(entries, netip.MustParseAddr(tt.ip))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchBanList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBanReason(t *testing.T) {
+	prefixes := parseBannedPrefixes([]BanListEntry{
+		{"192.168.1.0/24", "boog900"},
+		{"2001:db8::/32", "local"},
+		{"invalid", "local"},
+	})
+	tests := []struct {
+		name        string
+		ipAddresses string
+		want        string
+	}{
+		{
+			name:        "Not banned",
+			ipAddresses: "8.8.8.8,2001:4860::8888",
+			want:        "",
+		},
+		{
+			name:        "Second IPv4 address banned",
+			ipAddresses: "8.8.8.8,192.168.1.7",
+			want:        "192.168.1.7 is listed in boog900 ban list (192.168.1.0/24)",
+		},
+		{
+			name:        "IPv6 address banned",
+			ipAddresses: "2001:db8::1",
+			want:        "2001:db8::1 is listed in local ban list (2001:db8::/32)",
+		},
+		{
+			name:        "Empty and malformed",
+			ipAddresses: ",not-an-ip",
+			want:        "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := banReason(prefixes, tt.ipAddresses); got != tt.want {
+				t.Errorf("banReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestBanArchiveState -v
+func TestBanArchiveState(t *testing.T) {
+	tests := []struct {
+		name         string
+		node         Node
+		isBanned     bool
+		autoArchive  bool
+		wantArchived int
+		wantReason   string
+	}{
+		{
+			name:         "Banned node is archived",
+			node:         Node{},
+			isBanned:     true,
+			autoArchive:  true,
+			wantArchived: 1,
+			wantReason:   ArchivedByBanList,
+		},
+		{
+			name:         "Banned node without auto archive",
+			node:         Node{},
+			isBanned:     true,
+			wantArchived: 0,
+			wantReason:   "",
+		},
+		{
+			name:         "No longer banned node is restored",
+			node:         Node{IsArchived: 1, ArchivedReason: ArchivedByBanList},
+			wantArchived: 0,
+			wantReason:   "",
+		},
+		{
+			name:         "Restored even if auto archive is disabled later",
+			node:         Node{IsArchived: 1, ArchivedReason: ArchivedByBanList},
+			autoArchive:  false,
+			wantArchived: 0,
+			wantReason:   "",
+		},
+		{
+			name:         "Still banned node stays archived",
+			node:         Node{IsArchived: 1, ArchivedReason: ArchivedByBanList},
+			isBanned:     true,
+			autoArchive:  true,
+			wantArchived: 1,
+			wantReason:   ArchivedByBanList,
+		},
+		{
+			name:         "Node archived by admin stays archived",
+			node:         Node{IsArchived: 1, ArchivedReason: ArchivedByAdmin},
+			wantArchived: 1,
+			wantReason:   ArchivedByAdmin,
+		},
+		{
+			name:         "Node archived before reasons were recorded stays archived",
+			node:         Node{IsArchived: 1},
+			wantArchived: 1,
+			wantReason:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArchived, gotReason := banArchiveState(tt.node, tt.isBanned, tt.autoArchive)
+			if gotArchived != tt.wantArchived || gotReason != tt.wantReason {
+				t.Errorf("banArchiveState() = (%d, %q), want (%d, %q)", gotArchived, gotReason, tt.wantArchived, tt.wantReason)
+			}
+		})
+	}
+}
+
+// memoryBanListStore is banListStore backed by a slice
+type memoryBanListStore []BanListEntry
+
+func (m memoryBanListStore) BanListEntries(source string) ([]BanListEntry, error) {
+	entries := []BanListEntry{}
+	for _, e := range m {
+		if source == "" || e.Source == source {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestBanListFixture -v
+func TestBanListFixture(t *testing.T) {
+	srv := newFixtureServer(t)
+	ctx := context.Background()
+	f := fetcher.New(fetcher.WithClient(srv.Client()), fetcher.WithCache(fetcher.NewMemoryCache()))
+	sources := []BanListSource{{Name: "fixture", Location: srv.URL + "/ban_list.txt"}}
+
+	entries, responses, err := fetchBanLists(ctx, f, memoryBanListStore{}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BanListEntry{
+		{"198.51.100.0/24", "fixture"},
+		{"203.0.113.5", "fixture"},
+		{"2001:db8:ff::/48", "fixture"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Fatalf("fetchBanLists() = %v, want %v", entries, want)
+	}
+	if len(responses) != 1 || responses[0].NotModified {
+		t.Fatal("first fetchBanLists() must download the source")
+	}
+
+	// unchanged source keeps its stored entries
+	if err := f.Commit(ctx, responses[0]); err != nil {
+		t.Fatal(err)
+	}
+	stored := memoryBanListStore{{"198.51.100.0/24", "fixture"}}
+	entries, responses, err = fetchBanLists(ctx, f, stored, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !responses[0].NotModified || !reflect.DeepEqual(entries, []BanListEntry(stored)) {
+		t.Errorf("fetchBanLists() of unchanged source = %v, want stored %v", entries, stored)
+	}
+
+	// nothing stored for the source, it is downloaded again
+	entries, _, err = fetchBanLists(ctx, f, memoryBanListStore{}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("fetchBanLists() without stored entries = %v, want %v", entries, want)
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestBanListFixture_RecheckBan -v
+func TestBanListFixture_RecheckBan(t *testing.T) {
+	srv := newFixtureServer(t)
+	f := fetcher.New(fetcher.WithClient(srv.Client()), fetcher.WithCache(fetcher.NewMemoryCache()))
+	sources := []BanListSource{{Name: "fixture", Location: srv.URL + "/ban_list.txt"}}
+	entries, _, err := fetchBanLists(context.Background(), f, memoryBanListStore{}, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := []Node{
+		{ID: 1, IPAddresses: "192.0.2.10"},
+		{ID: 2, IPAddresses: "192.0.2.10,198.51.100.7"},
+		{ID: 3, IPAddresses: "2001:db8:ff::7"},
+		{ID: 4, IPAddresses: "203.0.113.5", IsArchived: 1, ArchivedReason: ArchivedByAdmin},
+	}
+	want := []Node{
+		{ID: 1, IPAddresses: "192.0.2.10"},
+		{
+			ID: 2, IPAddresses: "192.0.2.10,198.51.100.7",
+			IsBanned: 1, BanReason: "198.51.100.7 is listed in fixture ban list (198.51.100.0/24)",
+			IsArchived: 1, ArchivedReason: ArchivedByBanList,
+		},
+		{
+			ID: 3, IPAddresses: "2001:db8:ff::7",
+			IsBanned: 1, BanReason: "2001:db8:ff::7 is listed in fixture ban list (2001:db8:ff::/48)",
+			IsArchived: 1, ArchivedReason: ArchivedByBanList,
+		},
+		{
+			ID: 4, IPAddresses: "203.0.113.5",
+			IsBanned: 1, BanReason: "203.0.113.5 is listed in fixture ban list (203.0.113.5)",
+			IsArchived: 1, ArchivedReason: ArchivedByAdmin,
+		},
+	}
+	wantChanged := []bool{false, true, true, true}
+	prefixes := parseBannedPrefixes(entries)
+	for i, node := range nodes {
+		got, changed := recheckBan(prefixes, node, true)
+		if !reflect.DeepEqual(got, want[i]) || changed != wantChanged[i] {
+			t.Errorf("recheckBan() node %s = %+v, %v, want %+v, %v", node.IPAddresses, got, changed, want[i], wantChanged[i])
+		}
+		nodes[i] = got
+	}
+
+	// subnets removed from the ban list, only nodes archived by the ban list
+	// are restored
+	prefixes = parseBannedPrefixes([]BanListEntry{{"2001:db8:ff::/48", "fixture"}})
+	wantArchived := []int{0, 0, 1, 1}
+	for i, node := range nodes {
+		got, _ := recheckBan(prefixes, node, true)
+		if got.IsArchived != wantArchived[i] {
+			t.Errorf("recheckBan() node %s archived = %d, want %d", node.IPAddresses, got.IsArchived, wantArchived[i])
+		}
+	}
+}
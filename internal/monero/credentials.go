@@ -0,0 +1,145 @@
+package monero
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// RPCLogin is monerod `--rpc-login` credentials shared by the node operator
+type RPCLogin struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+var errNoSecret = errors.New("APP_SECRET is not configured")
+
+// credentialsKey derives AES-256 key for node credentials from the app
+// secret, so the key differs from the secret used elsewhere
+func credentialsKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("xmr-nodes rpc credentials"))
+	return mac.Sum(nil)
+}
+
+// encryptLogin encrypts the credentials with AES-GCM, the result is base64
+// encoded nonce followed by the ciphertext
+func encryptLogin(secret string, login RPCLogin) (string, error) {
+	if secret == "" {
+		return "", errNoSecret
+	}
+	plaintext, err := json.Marshal(login)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(credentialsKey(secret))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// decryptLogin decrypts credentials encrypted by encryptLogin
+func decryptLogin(secret, encrypted string) (RPCLogin, error) {
+	var login RPCLogin
+	if secret == "" {
+		return login, errNoSecret
+	}
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return login, err
+	}
+	block, err := aes.NewCipher(credentialsKey(secret))
+	if err != nil {
+		return login, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return login, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return login, errors.New("invalid encrypted credentials")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return login, errors.New("cannot decrypt credentials, APP_SECRET changed?")
+	}
+	err = json.Unmarshal(plaintext, &login)
+	return login, err
+}
+
+// validate checks the credentials. Empty username and password means no
+// credentials.
+func (l *RPCLogin) validate() error {
+	l.Username = strings.TrimSpace(l.Username)
+	if l.Username == "" && l.Password == "" {
+		return nil
+	}
+	if l.Username == "" || l.Password == "" {
+		return errors.New("both RPC login username and password are required")
+	}
+	if len(l.Username) > 64 || len(l.Password) > 128 {
+		return errors.New("RPC login username or password is too long")
+	}
+	if strings.ContainsAny(l.Username, `:"`) {
+		return errors.New("RPC login username must not contain colon or double quote")
+	}
+	return nil
+}
+
+// DecryptLogin sets RPCUsername and RPCPassword from the stored encrypted
+// credentials of the node. It is used to pass the credentials to probers.
+func (n *Node) DecryptLogin(secret string) error {
+	if n.RPCLogin == "" {
+		return nil
+	}
+	login, err := decryptLogin(secret, n.RPCLogin)
+	if err != nil {
+		return err
+	}
+	n.RPCUsername = login.Username
+	n.RPCPassword = login.Password
+	return nil
+}
+
+// SetLogin sets or removes (empty credentials) the node RPC login
+func (r *moneroRepo) SetLogin(id uint, secret string, login RPCLogin) error {
+	if err := login.validate(); err != nil {
+		return err
+	}
+	encrypted := ""
+	if login.Username != "" {
+		var err error
+		if encrypted, err = encryptLogin(secret, login); err != nil {
+			return err
+		}
+	}
+	res, err := r.db.Exec(`
+		UPDATE tbl_node
+		SET
+			rpc_login = ?,
+			login_required = ?
+		WHERE
+			id = ?`, encrypted, encrypted != "", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("node not found or login unchanged")
+	}
+	return nil
+}
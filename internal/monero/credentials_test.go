@@ -0,0 +1,63 @@
+package monero
+
+import (
+	"strings"
+	"testing"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestEncryptLogin -v
+func TestEncryptLogin(t *testing.T) {
+	const secret = "0000000000000000000000000000000000000000000000000000000000000001"
+	login := RPCLogin{Username: "monero", Password: "p@ss:word"}
+
+	encrypted, err := encryptLogin(secret, login)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(encrypted, login.Password) {
+		t.Fatal("encrypted credentials contain the plaintext password")
+	}
+	again, _ := encryptLogin(secret, login)
+	if again == encrypted {
+		t.Error("encryptLogin() must use random nonce")
+	}
+
+	node := Node{RPCLogin: encrypted}
+	if err := node.DecryptLogin(secret); err != nil {
+		t.Fatal(err)
+	}
+	if node.RPCUsername != login.Username || node.RPCPassword != login.Password {
+		t.Errorf("DecryptLogin() = %q:%q, want %q:%q", node.RPCUsername, node.RPCPassword, login.Username, login.Password)
+	}
+
+	if _, err := decryptLogin("another secret", encrypted); err == nil {
+		t.Error("decryptLogin() with wrong secret must fail")
+	}
+	if _, err := encryptLogin("", login); err == nil {
+		t.Error("encryptLogin() without secret must fail")
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRPCLogin_validate -v
+func TestRPCLogin_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		login   RPCLogin
+		wantErr bool
+	}{
+		{"no credentials", RPCLogin{}, false},
+		{"valid", RPCLogin{Username: "monero", Password: "secret"}, false},
+		{"missing password", RPCLogin{Username: "monero"}, true},
+		{"missing username", RPCLogin{Password: "secret"}, true},
+		{"colon in username", RPCLogin{Username: "mon:ero", Password: "secret"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.login.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
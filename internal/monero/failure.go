@@ -0,0 +1,169 @@
+package monero
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Probe failure codes, stored in tbl_probe_log beside the failed reason
+const (
+	FailureSOCKSUnreachable = "socks_unreachable"    // cannot connect to Tor / I2P SOCKS proxy
+	FailureSOCKSAuth        = "socks_auth"           // SOCKS proxy authentication failed
+	FailureSOCKSError       = "socks_error"          // other SOCKS proxy failure
+	FailureHostUnreachable  = "host_unreachable"     // host or network unreachable
+	FailureConnRefused      = "connection_refused"   // port is closed
+	FailureDescNotFound     = "descriptor_not_found" // onion service descriptor not found or invalid
+	FailureCircuitFailed    = "circuit_failed"       // onion service introduction or rendezvous failed
+	FailureTTLExpired       = "ttl_expired"          // SOCKS TTL expired, Tor circuit timed out
+	FailureDNS              = "dns_error"
+	FailureTimeout          = "timeout"
+	FailureTLS              = "tls_error"
+	FailureHTTPStatus       = "http_status"    // non 200 response
+	FailureLoginRequired    = "login_required" // digest auth challenge, see RPCLogin
+	FailureJSONDecode       = "json_decode"    // not a valid RPC response
+	FailureRPCStatus        = "rpc_status"     // RPC response status is not OK
+	FailureOther            = "other"
+)
+
+// FailureCodes is a list of known probe failure codes
+var FailureCodes = []string{
+	FailureSOCKSUnreachable,
+	FailureSOCKSAuth,
+	FailureSOCKSError,
+	FailureHostUnreachable,
+	FailureConnRefused,
+	FailureDescNotFound,
+	FailureCircuitFailed,
+	FailureTTLExpired,
+	FailureDNS,
+	FailureTimeout,
+	FailureTLS,
+	FailureHTTPStatus,
+	FailureLoginRequired,
+	FailureJSONDecode,
+	FailureRPCStatus,
+	FailureOther,
+}
+
+// ClassifyFailure returns failure code of the probe request error
+func ClassifyFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// golang.org/x/net/proxy SOCKS5 dialer errors, see
+	// `golang.org/x/net/internal/socks`
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && strings.HasPrefix(opErr.Op, "socks") {
+		var dialErr *net.OpError
+		if errors.As(opErr.Err, &dialErr) && dialErr.Op == "dial" {
+			return FailureSOCKSUnreachable
+		}
+		return classifySOCKSReply(opErr.Err.Error())
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureDNS
+	}
+
+	var (
+		certErr    *tls.CertificateVerificationError
+		recordErr  tls.RecordHeaderError
+		alertErr   tls.AlertError
+		unknownErr x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+	)
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &unknownErr) || errors.As(err, &hostErr) || strings.Contains(err.Error(), "tls: ") {
+		return FailureTLS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return FailureTimeout
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureConnRefused
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return FailureHostUnreachable
+	}
+
+	return FailureOther
+}
+
+// classifySOCKSReply classifies SOCKS5 reply error message. Tor extended
+// error codes (`ExtendedErrors` SocksPort flag) are reported as "unknown
+// code: N".
+func classifySOCKSReply(msg string) string {
+	switch {
+	case strings.Contains(msg, "authentication"), strings.Contains(msg, "username/password"):
+		return FailureSOCKSAuth
+	case strings.Contains(msg, "host unreachable"), strings.Contains(msg, "network unreachable"):
+		return FailureHostUnreachable
+	case strings.Contains(msg, "connection refused"):
+		return FailureConnRefused
+	case strings.Contains(msg, "TTL expired"):
+		return FailureTTLExpired
+	}
+
+	var code int
+	if i := strings.Index(msg, "unknown code: "); i >= 0 {
+		if _, err := fmt.Sscanf(msg[i:], "unknown code: %d", &code); err == nil {
+			switch code {
+			case 0xf0, 0xf1, 0xf6: // descriptor not found, invalid descriptor, bad address
+				return FailureDescNotFound
+			case 0xf2, 0xf3, 0xf7: // introduction failed, rendezvous failed, introduction timed out
+				return FailureCircuitFailed
+			case 0xf4, 0xf5: // missing or wrong client authorization
+				return FailureSOCKSAuth
+			}
+		}
+	}
+	return FailureSOCKSError
+}
+
+// validFailureCode returns the failure code reported by the prober, unknown
+// codes are stored as FailureOther
+func validFailureCode(code string) string {
+	if code == "" || slices.Contains(FailureCodes, code) {
+		return code
+	}
+	return FailureOther
+}
+
+// FailureCount is the number of failed probes by failure code
+type FailureCount struct {
+	Code  string `json:"failure_code" db:"failure_code"` // empty for probes reported before classification
+	Count int    `json:"count" db:"count"`
+}
+
+// FailureSummary returns the number of failed probes per failure code of
+// the probe logs matching the query
+func (r *moneroRepo) FailureSummary(q QueryLogs) ([]FailureCount, error) {
+	q.Status = 0
+	args, where, _, _ := q.toSQL()
+
+	summary := []FailureCount{}
+	err := r.db.Select(&summary, fmt.Sprintf(`
+		SELECT
+			failure_code,
+			COUNT(id) AS count
+		FROM
+			tbl_probe_log
+		%s
+		GROUP BY
+			failure_code
+		ORDER BY
+			count DESC`, where), args...)
+
+	return summary, err
+}
@@ -0,0 +1,48 @@
+package monero
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+// socksErr mimics golang.org/x/net/proxy SOCKS5 dialer error
+func socksErr(err error) error {
+	return &url.Error{Op: "Post", URL: "http://example.onion/json_rpc", Err: &net.OpError{Op: "socks connect", Net: "tcp", Err: err}}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestClassifyFailure -v
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"no error", nil, ""},
+		{"SOCKS proxy down", socksErr(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), FailureSOCKSUnreachable},
+		{"SOCKS auth", socksErr(errors.New("username/password authentication failed")), FailureSOCKSAuth},
+		{"SOCKS host unreachable", socksErr(errors.New("unknown error host unreachable")), FailureHostUnreachable},
+		{"SOCKS TTL expired", socksErr(errors.New("unknown error TTL expired")), FailureTTLExpired},
+		{"Tor descriptor not found", socksErr(errors.New("unknown error unknown code: 240")), FailureDescNotFound},
+		{"Tor rendezvous failed", socksErr(errors.New("unknown error unknown code: 243")), FailureCircuitFailed},
+		{"SOCKS general failure", socksErr(errors.New("unknown error general SOCKS server failure")), FailureSOCKSError},
+		{"DNS", &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "x.invalid"}}}, FailureDNS},
+		{"connection refused", &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, FailureConnRefused},
+		{"host unreachable", &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Err: syscall.EHOSTUNREACH}}, FailureHostUnreachable},
+		{"timeout", &url.Error{Op: "Post", Err: context.DeadlineExceeded}, FailureTimeout},
+		{"TLS", &url.Error{Op: "Post", Err: fmt.Errorf("tls: first record does not look like a TLS handshake")}, FailureTLS},
+		{"other", errors.New("EOF"), FailureOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.err); got != tt.want {
+				t.Errorf("ClassifyFailure() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
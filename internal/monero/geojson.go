@@ -0,0 +1,128 @@
+package monero
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxCoordPrecision is the maximum decimal places of public node coordinates,
+// 3 decimal places is roughly 110 m
+const maxCoordPrecision = 3
+
+// NodeLocation represents node with its GeoIP coordinate
+type NodeLocation struct {
+	ID          uint    `json:"id" db:"id"`
+	Hostname    string  `json:"hostname" db:"hostname"`
+	Port        uint    `json:"port" db:"port"`
+	Protocol    string  `json:"protocol" db:"protocol"`
+	Nettype     string  `json:"nettype" db:"nettype"`
+	IsAvailable bool    `json:"is_available" db:"is_available"`
+	CountryCode string  `json:"-" db:"country"`
+	CountryName string  `json:"-" db:"country_name"`
+	City        string  `json:"-" db:"city"`
+	Latitude    float64 `json:"-" db:"lat"`
+	Longitude   float64 `json:"-" db:"lon"`
+}
+
+// NodeLocations returns nodes matching the query which have coordinate.
+// Paging and sorting parameters are ignored.
+func (r *moneroRepo) NodeLocations(q QueryNodes) ([]NodeLocation, error) {
+	args, where := q.toSQL()
+	if where == "" {
+		where = "WHERE (lat != 0 OR lon != 0)"
+	} else {
+		where += " AND (lat != 0 OR lon != 0)"
+	}
+
+	locations := []NodeLocation{}
+	err := r.db.Select(&locations, fmt.Sprintf(`
+		SELECT
+			id,
+			hostname,
+			port,
+			protocol,
+			nettype,
+			is_available,
+			country,
+			country_name,
+			city,
+			lat,
+			lon
+		FROM
+			tbl_node
+		%s
+		ORDER BY
+			id ASC`, where), args...)
+	return locations, err
+}
+
+// FeatureCollection is GeoJSON (RFC 7946) feature collection of node
+// locations
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a single location, nodes with the same rounded coordinate are
+// grouped into one feature
+type Feature struct {
+	Type       string           `json:"type"`
+	Geometry   Point            `json:"geometry"`
+	Properties LocationProperty `json:"properties"`
+}
+
+// Point is GeoJSON point geometry, coordinates are in longitude, latitude
+// order
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// LocationProperty is nodes summary of a single location
+type LocationProperty struct {
+	Total       int            `json:"total"`
+	Online      int            `json:"online"`
+	CountryCode string         `json:"cc"`
+	CountryName string         `json:"country_name"`
+	City        string         `json:"city"` // empty if nodes are located in different cities
+	Nodes       []NodeLocation `json:"nodes"`
+}
+
+// NodesGeoJSON groups node locations by their coordinate rounded to the given
+// decimal places (0 to 3), so exact GeoIP coordinates are never published
+func NodesGeoJSON(locations []NodeLocation, precision int) FeatureCollection {
+	precision = max(0, min(precision, maxCoordPrecision))
+	scale := math.Pow10(precision)
+	round := func(v float64) float64 { return math.Round(v*scale) / scale }
+
+	fc := FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	index := make(map[[2]float64]int)
+	for _, loc := range locations {
+		coord := [2]float64{round(loc.Longitude), round(loc.Latitude)}
+		i, ok := index[coord]
+		if !ok {
+			i = len(fc.Features)
+			index[coord] = i
+			fc.Features = append(fc.Features, Feature{
+				Type:     "Feature",
+				Geometry: Point{Type: "Point", Coordinates: coord},
+				Properties: LocationProperty{
+					CountryCode: loc.CountryCode,
+					CountryName: loc.CountryName,
+					City:        loc.City,
+				},
+			})
+		}
+
+		p := &fc.Features[i].Properties
+		if p.City != loc.City {
+			p.City = ""
+		}
+		p.Total++
+		if loc.IsAvailable {
+			p.Online++
+		}
+		p.Nodes = append(p.Nodes, loc)
+	}
+	return fc
+}
@@ -0,0 +1,51 @@
+package monero
+
+import (
+	"testing"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestNodesGeoJSON -v
+func TestNodesGeoJSON(t *testing.T) {
+	locations := []NodeLocation{
+		{ID: 1, IsAvailable: true, CountryCode: "DE", City: "Falkenstein", Latitude: 50.4777, Longitude: 12.3649},
+		{ID: 2, IsAvailable: false, CountryCode: "DE", City: "Falkenstein", Latitude: 50.4812, Longitude: 12.3711},
+		{ID: 3, IsAvailable: true, CountryCode: "DE", City: "Auerbach", Latitude: 50.4999, Longitude: 12.3999},
+		{ID: 4, IsAvailable: true, CountryCode: "US", City: "Ashburn", Latitude: 39.0437, Longitude: -77.4875},
+	}
+
+	tests := []struct {
+		name      string
+		precision int
+		want      [][2]float64
+		wantTotal []int
+	}{
+		{"default precision", 1, [][2]float64{{12.4, 50.5}, {-77.5, 39}}, []int{3, 1}},
+		{"finer precision", 2, [][2]float64{{12.36, 50.48}, {12.37, 50.48}, {12.4, 50.5}, {-77.49, 39.04}}, []int{1, 1, 1, 1}},
+		{"negative precision is clamped", -1, [][2]float64{{12, 50}, {-77, 39}}, []int{3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := NodesGeoJSON(locations, tt.precision)
+			if len(fc.Features) != len(tt.want) {
+				t.Fatalf("got %d features, want %d", len(fc.Features), len(tt.want))
+			}
+			for i, f := range fc.Features {
+				if f.Geometry.Coordinates != tt.want[i] {
+					t.Errorf("feature %d coordinate = %v, want %v", i, f.Geometry.Coordinates, tt.want[i])
+				}
+				if f.Properties.Total != tt.wantTotal[i] {
+					t.Errorf("feature %d total = %d, want %d", i, f.Properties.Total, tt.wantTotal[i])
+				}
+			}
+		})
+	}
+
+	fc := NodesGeoJSON(locations, 1)
+	if p := fc.Features[0].Properties; p.Online != 2 || p.City != "" || p.CountryCode != "DE" {
+		t.Errorf("unexpected grouped location: %+v", p)
+	}
+	if p := fc.Features[1].Properties; p.City != "Ashburn" {
+		t.Errorf("city = %q, want Ashburn", p.City)
+	}
+}
@@ -0,0 +1,170 @@
+package monero
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Hard fork readiness of a single node
+const (
+	ReadinessReady   = "ready"
+	ReadinessLaggard = "laggard"
+	ReadinessUnknown = "unknown" // no version and hard fork info reported
+)
+
+// HardForkNode is online node version and hard fork info
+type HardForkNode struct {
+	ID        uint   `json:"id" db:"id"`
+	Hostname  string `json:"hostname" db:"hostname"`
+	Port      uint   `json:"port" db:"port"`
+	Protocol  string `json:"protocol" db:"protocol"`
+	Nettype   string `json:"nettype" db:"nettype"`
+	Version   string `json:"version" db:"version"`
+	HFVersion uint   `json:"hf_version" db:"hf_version"`
+	HFVoting  uint   `json:"hf_voting" db:"hf_voting"`
+	Readiness string `json:"readiness"`
+}
+
+// HFVoteCount is the number of nodes voting for a hard fork version
+type HFVoteCount struct {
+	Version uint `json:"version"`
+	Nodes   int  `json:"nodes"`
+}
+
+// HardForkReadiness represents upgrade readiness of online nodes of a single
+// nettype
+type HardForkReadiness struct {
+	Nettype string `json:"nettype"`
+	// the newest release flagged as hard fork minimum
+	RequiredVersion string `json:"required_version"`
+	// the highest hard fork version voted by online nodes
+	TargetHFVersion uint           `json:"target_hf_version"`
+	OnlineNodes     int            `json:"online_nodes"`
+	ReadyNodes      int            `json:"ready_nodes"`
+	UnknownNodes    int            `json:"unknown_nodes"`
+	ReadyPercent    float64        `json:"ready_percent"` // of online nodes with known readiness
+	Votes           []HFVoteCount  `json:"votes"`
+	Laggards        []HardForkNode `json:"laggards"`
+}
+
+// HardForkReadiness returns hard fork readiness report of online nodes per
+// nettype. Required version is taken from known releases, see SetRelease.
+func (r *moneroRepo) HardForkReadiness(nettype string) ([]HardForkReadiness, error) {
+	where := `is_available = 1 AND is_archived = 0 AND nettype != ''`
+	var args []interface{}
+	if nettype != "" {
+		where += ` AND nettype = ?`
+		args = append(args, nettype)
+	}
+
+	var nodes []HardForkNode
+	err := r.db.Select(&nodes, fmt.Sprintf(`
+		SELECT
+			id,
+			hostname,
+			port,
+			protocol,
+			nettype,
+			version,
+			hf_version,
+			hf_voting
+		FROM
+			tbl_node
+		WHERE
+			%s
+		ORDER BY
+			id ASC`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := r.Releases()
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReadiness(nodes, releases), nil
+}
+
+// buildReadiness groups nodes by nettype and classifies them. Node is ready
+// if its version is at least the required version. Nodes that don't report
+// their version (eg. restricted RPC) are classified by their hard fork vote
+// against the highest vote of the nettype.
+func buildReadiness(nodes []HardForkNode, releases []MoneroRelease) []HardForkReadiness {
+	var required MoneroVersion
+	hasRequired := false
+	for _, release := range releases {
+		v, ok := ParseMoneroVersion(release.Version)
+		if ok && release.IsForkMinimum && v.Compare(required) > 0 {
+			required = v
+			hasRequired = true
+		}
+	}
+
+	byNettype := make(map[string][]HardForkNode)
+	for _, node := range nodes {
+		byNettype[node.Nettype] = append(byNettype[node.Nettype], node)
+	}
+
+	result := []HardForkReadiness{}
+	for nettype, nodes := range byNettype {
+		rd := HardForkReadiness{
+			Nettype:     nettype,
+			OnlineNodes: len(nodes),
+			Votes:       []HFVoteCount{},
+			Laggards:    []HardForkNode{},
+		}
+		if hasRequired {
+			rd.RequiredVersion = required.String()
+		}
+
+		votes := make(map[uint]int)
+		for _, node := range nodes {
+			if node.HFVoting > 0 {
+				votes[node.HFVoting]++
+				rd.TargetHFVersion = max(rd.TargetHFVersion, node.HFVoting)
+			}
+		}
+		for version, count := range votes {
+			rd.Votes = append(rd.Votes, HFVoteCount{Version: version, Nodes: count})
+		}
+		sort.Slice(rd.Votes, func(i, j int) bool { return rd.Votes[i].Version > rd.Votes[j].Version })
+
+		for _, node := range nodes {
+			v, ok := ParseMoneroVersion(node.Version)
+			switch {
+			case ok && hasRequired:
+				node.Readiness = ReadinessReady
+				if v.Compare(required) < 0 {
+					node.Readiness = ReadinessLaggard
+				}
+			case node.HFVoting > 0:
+				node.Readiness = ReadinessReady
+				if node.HFVoting < rd.TargetHFVersion {
+					node.Readiness = ReadinessLaggard
+				}
+			case ok:
+				// without required version, known version is not a laggard
+				node.Readiness = ReadinessReady
+			default:
+				node.Readiness = ReadinessUnknown
+			}
+
+			switch node.Readiness {
+			case ReadinessReady:
+				rd.ReadyNodes++
+			case ReadinessLaggard:
+				rd.Laggards = append(rd.Laggards, node)
+			default:
+				rd.UnknownNodes++
+			}
+		}
+		if known := rd.OnlineNodes - rd.UnknownNodes; known > 0 {
+			rd.ReadyPercent = math.Round(float64(rd.ReadyNodes)/float64(known)*10000) / 100
+		}
+		result = append(result, rd)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return nettypeOrder(result[i].Nettype) < nettypeOrder(result[j].Nettype) })
+	return result
+}
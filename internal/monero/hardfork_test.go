@@ -0,0 +1,46 @@
+package monero
+
+import (
+	"testing"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestBuildReadiness -v
+func TestBuildReadiness(t *testing.T) {
+	releases := []MoneroRelease{
+		{Version: "0.18.3.4"},
+		{Version: "0.18.0.0", IsForkMinimum: true},
+		{Version: "0.19.0.0", IsForkMinimum: true},
+	}
+	nodes := []HardForkNode{
+		{ID: 1, Nettype: "mainnet", Version: "0.19.0.0-release", HFVersion: 16, HFVoting: 17},
+		{ID: 2, Nettype: "mainnet", Version: "0.18.3.4-release", HFVersion: 16, HFVoting: 16},
+		{ID: 3, Nettype: "mainnet", Version: "", HFVersion: 16, HFVoting: 17}, // restricted RPC, votes for the target
+		{ID: 4, Nettype: "mainnet", Version: "", HFVersion: 16, HFVoting: 16}, // restricted RPC, old vote
+		{ID: 5, Nettype: "mainnet", Version: "", HFVersion: 0, HFVoting: 0},   // no info
+		{ID: 6, Nettype: "stagenet", Version: "0.19.0.1-release", HFVersion: 17, HFVoting: 17},
+	}
+
+	got := buildReadiness(nodes, releases)
+	if len(got) != 2 || got[0].Nettype != "mainnet" {
+		t.Fatalf("unexpected nettypes: %+v", got)
+	}
+
+	m := got[0]
+	if m.RequiredVersion != "0.19.0.0" || m.TargetHFVersion != 17 {
+		t.Errorf("required version / target HF = %s / %d, want 0.19.0.0 / 17", m.RequiredVersion, m.TargetHFVersion)
+	}
+	if m.OnlineNodes != 5 || m.ReadyNodes != 2 || m.UnknownNodes != 1 || m.ReadyPercent != 50 {
+		t.Errorf("online/ready/unknown/percent = %d/%d/%d/%v, want 5/2/1/50", m.OnlineNodes, m.ReadyNodes, m.UnknownNodes, m.ReadyPercent)
+	}
+	if len(m.Laggards) != 2 || m.Laggards[0].ID != 2 || m.Laggards[1].ID != 4 {
+		t.Errorf("unexpected laggards: %+v", m.Laggards)
+	}
+	if len(m.Votes) != 2 || m.Votes[0] != (HFVoteCount{Version: 17, Nodes: 2}) {
+		t.Errorf("unexpected votes: %+v", m.Votes)
+	}
+
+	if s := got[1]; s.ReadyNodes != 1 || s.ReadyPercent != 100 {
+		t.Errorf("unexpected stagenet readiness: %+v", s)
+	}
+}
@@ -0,0 +1,243 @@
+package monero
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+)
+
+// latencyWindows are the rolling windows of response time percentiles, in
+// hours. Response time is the get_info request time reported by the prober
+// (`rpc_runtime`), probes without it are not counted. The first window is stored on the node.
+var latencyWindows = []int{24, 168}
+
+// maxLatencyTrendDays is the maximum number of days of the latency trend,
+// older probe logs are deleted by the delete_old_probe_logs cron task
+const maxLatencyTrendDays = 30
+
+// LatencyStats is the response time percentiles of a node in seconds, from
+// the successful probes within the window
+type LatencyStats struct {
+	Region      string  `json:"region" db:"region"` // prober region, empty for all probers
+	WindowHours int     `json:"window_hours" db:"window_hours"`
+	P50         float64 `json:"p50" db:"p50"`
+	P90         float64 `json:"p90" db:"p90"`
+	P99         float64 `json:"p99" db:"p99"`
+	Samples     int     `json:"samples" db:"samples"`
+	UpdatedAt   int64   `json:"updated_at" db:"updated_at"`
+}
+
+// LatencyPoint is the response time percentiles of a node in a trend bucket
+type LatencyPoint struct {
+	Time    int64   `json:"time"` // bucket start
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	Samples int     `json:"samples"`
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func newLatencyStats(region string, window int, values []float64, now int64) LatencyStats {
+	slices.Sort(values)
+	return LatencyStats{
+		Region:      region,
+		WindowHours: window,
+		P50:         percentile(values, 50),
+		P90:         percentile(values, 90),
+		P99:         percentile(values, 99),
+		Samples:     len(values),
+		UpdatedAt:   now,
+	}
+}
+
+type latencyKey struct {
+	nodeID uint
+	region string
+	window int
+}
+
+// CalculateLatency calculates response time percentiles of each node over
+// the rolling windows, for all probers and per prober region. The
+// percentiles of the first window from all probers are stored on the node.
+func (r *moneroRepo) CalculateLatency(ctx context.Context) (int64, error) {
+	now := time.Now()
+	oldest := now.Add(-time.Duration(slices.Max(latencyWindows)) * time.Hour).Unix()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			p.node_id,
+			p.date_checked,
+			p.rpc_runtime,
+			COALESCE(s.region, '') AS region
+		FROM
+			tbl_probe_log p
+			LEFT JOIN tbl_prober s ON s.id = p.prober_id
+		WHERE
+			p.is_available = ?
+			AND p.rpc_runtime > 0
+			AND p.date_checked >= ?`, 1, oldest)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	samples := make(map[latencyKey][]float64)
+	for rows.Next() {
+		var (
+			nodeID      uint
+			dateChecked int64
+			rpcRuntime  float64
+			region      string
+		)
+		if err := rows.Scan(&nodeID, &dateChecked, &rpcRuntime, &region); err != nil {
+			return 0, err
+		}
+		for _, window := range latencyWindows {
+			if dateChecked < now.Add(-time.Duration(window)*time.Hour).Unix() {
+				continue
+			}
+			k := latencyKey{nodeID: nodeID, window: window}
+			samples[k] = append(samples[k], rpcRuntime)
+			if region != "" {
+				k.region = region
+				samples[k] = append(samples[k], rpcRuntime)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	for k, values := range samples {
+		s := newLatencyStats(k.region, k.window, values, now.Unix())
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO tbl_node_latency (
+				node_id,
+				region,
+				window_hours,
+				p50,
+				p90,
+				p99,
+				samples,
+				updated_at
+			) VALUES (
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?,
+				?
+			)
+			ON DUPLICATE KEY UPDATE
+				p50 = VALUES(p50),
+				p90 = VALUES(p90),
+				p99 = VALUES(p99),
+				samples = VALUES(samples),
+				updated_at = VALUES(updated_at)`,
+			k.nodeID, s.Region, s.WindowHours, s.P50, s.P90, s.P99, s.Samples, s.UpdatedAt)
+		if err != nil {
+			return affected, err
+		}
+		affected++
+	}
+
+	// nodes and regions without successful probes within the window
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM tbl_node_latency WHERE updated_at < ?`, now.Unix()); err != nil {
+		return affected, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE tbl_node n
+			LEFT JOIN tbl_node_latency l ON l.node_id = n.id
+				AND l.region = ''
+				AND l.window_hours = ?
+		SET
+			n.latency_p50 = COALESCE(l.p50, 0),
+			n.latency_p90 = COALESCE(l.p90, 0),
+			n.latency_p99 = COALESCE(l.p99, 0)`, latencyWindows[0])
+
+	return affected, err
+}
+
+// NodeLatency returns response time percentiles of the node for all probers
+// and per prober region
+func (r *moneroRepo) NodeLatency(id uint) ([]LatencyStats, error) {
+	stats := []LatencyStats{}
+	err := r.db.Select(&stats, `
+		SELECT
+			region,
+			window_hours,
+			p50,
+			p90,
+			p99,
+			samples,
+			updated_at
+		FROM
+			tbl_node_latency
+		WHERE
+			node_id = ?
+		ORDER BY
+			region ASC,
+			window_hours ASC`, id)
+	return stats, err
+}
+
+// LatencyTrend returns daily response time percentiles of the node from the
+// successful probes of the last days (at most 30), oldest first. Days without
+// successful probes are omitted.
+func (r *moneroRepo) LatencyTrend(id uint, days int) ([]LatencyPoint, error) {
+	days = min(max(days, 1), maxLatencyTrendDays)
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+
+	type probe struct {
+		DateChecked int64   `db:"date_checked"`
+		Runtime     float64 `db:"rpc_runtime"`
+	}
+	probes := []probe{}
+	err := r.db.Select(&probes, `
+		SELECT
+			date_checked,
+			rpc_runtime
+		FROM
+			tbl_probe_log
+		WHERE
+			node_id = ?
+			AND is_available = ?
+			AND rpc_runtime > 0
+			AND date_checked >= ?`, id, 1, start.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make(map[int64][]float64)
+	for _, p := range probes {
+		day := p.DateChecked - (p.DateChecked-start.Unix())%86400
+		buckets[day] = append(buckets[day], p.Runtime)
+	}
+	trend := make([]LatencyPoint, 0, len(buckets))
+	for day, values := range buckets {
+		slices.Sort(values)
+		trend = append(trend, LatencyPoint{
+			Time:    day,
+			P50:     percentile(values, 50),
+			P90:     percentile(values, 90),
+			Samples: len(values),
+		})
+	}
+	slices.SortFunc(trend, func(a, b LatencyPoint) int {
+		return int(a.Time - b.Time)
+	})
+	return trend, nil
+}
@@ -0,0 +1,48 @@
+package monero
+
+import "testing"
+
+// Single test:
+// go test -race ./internal/monero -run=TestPercentile -v
+func TestPercentile(t *testing.T) {
+	values := []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0}
+	tests := []struct {
+		name   string
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{"no values", nil, 50, 0},
+		{"single value", []float64{0.42}, 99, 0.42},
+		{"p50", values, 50, 0.5},
+		{"p90", values, 90, 0.9},
+		{"p99", values, 99, 1.0},
+		{"p0 is the minimum", values, 0, 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.values, tt.p); got != tt.want {
+				t.Errorf("percentile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestNewLatencyStats -v
+func TestNewLatencyStats(t *testing.T) {
+	values := []float64{2.5, 0.3, 0.1, 0.2, 0.4}
+	got := newLatencyStats("eu", 24, values, 1700000000)
+	want := LatencyStats{
+		Region:      "eu",
+		WindowHours: 24,
+		P50:         0.3,
+		P90:         2.5,
+		P99:         2.5,
+		Samples:     5,
+		UpdatedAt:   1700000000,
+	}
+	if got != want {
+		t.Errorf("newLatencyStats() = %+v, want %+v", got, want)
+	}
+}
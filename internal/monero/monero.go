@@ -31,11 +31,18 @@ func New() *moneroRepo {
 
 // Node represents a single remote node
 type Node struct {
-	ID              uint           `json:"id,omitempty" db:"id"`
-	Hostname        string         `json:"hostname" db:"hostname"`
-	IP              string         `json:"ip" db:"ip_addr"`
-	Port            uint           `json:"port" db:"port"`
-	Protocol        string         `json:"protocol" db:"protocol"`
+	ID            uint   `json:"id,omitempty" db:"id"`
+	Hostname      string `json:"hostname" db:"hostname"`
+	IP            string `json:"ip" db:"ip_addr"`
+	Port          uint   `json:"port" db:"port"`
+	ZMQPort       uint   `json:"zmq_port" db:"zmq_port"` // operator declared, 0 = nettype default
+	P2PPort       uint   `json:"p2p_port" db:"p2p_port"` // operator declared, 0 = nettype default
+	Protocol      string `json:"protocol" db:"protocol"`
+	RPCLogin      string `json:"-" db:"rpc_login"` // encrypted, see DecryptLogin
+	LoginRequired bool   `json:"login_required" db:"login_required"`
+	// decrypted RPC login, only sent to probers in the job payload
+	RPCUsername     string         `json:"rpc_username,omitempty" db:"-"`
+	RPCPassword     string         `json:"rpc_password,omitempty" db:"-"`
 	IsTor           bool           `json:"is_tor" db:"is_tor"`
 	IsI2P           bool           `json:"is_i2p" db:"is_i2p"`
 	IsAvailable     bool           `json:"is_available" db:"is_available"`
@@ -45,29 +52,48 @@ type Node struct {
 	DatabaseSize    uint           `json:"database_size" db:"database_size"`
 	Difficulty      uint           `json:"difficulty" db:"difficulty"`
 	Version         string         `json:"version" db:"version"`
+	VersionStatus   string         `json:"version_status" db:"version_status"`
+	HFVersion       uint           `json:"hf_version" db:"hf_version"` // hard fork version the node follows
+	HFVoting        uint           `json:"hf_voting" db:"hf_voting"`   // hard fork version the node votes for
 	Status          string         `json:"status,omitempty"`
 	Uptime          float64        `json:"uptime" db:"uptime"`
+	LatencyP50      float64        `json:"latency_p50" db:"latency_p50"` // response time in seconds, last 24 hours
+	LatencyP90      float64        `json:"latency_p90" db:"latency_p90"`
+	LatencyP99      float64        `json:"latency_p99" db:"latency_p99"`
 	EstimateFee     uint           `json:"estimate_fee" db:"estimate_fee"`
 	ASN             uint           `json:"asn" db:"asn"`
 	ASNName         string         `json:"asn_name" db:"asn_name"`
 	CountryCode     string         `json:"cc" db:"country"`
 	CountryName     string         `json:"country_name" db:"country_name"`
+	ContinentCode   string         `json:"continent" db:"continent"`
 	City            string         `json:"city" db:"city"`
 	Latitude        float64        `json:"latitude" db:"lat"`
 	Longitude       float64        `json:"longitude" db:"lon"`
+	GeoProvider     string         `json:"geo_provider" db:"geo_provider"`
 	DateEntered     int64          `json:"date_entered,omitempty" db:"date_entered"`
 	SubmitterIPHash string         `json:"submitter_iphash,omitempty" db:"submitter_iphash"`
 	LastChecked     int64          `json:"last_checked" db:"last_checked"`
 	FailedCount     uint           `json:"failed_count,omitempty" db:"failed_count"`
 	LastCheckStatus types.JSONText `json:"last_check_statuses" db:"last_check_status"`
 	CORSCapable     bool           `json:"cors" db:"cors_capable"`
+	TLSValid        int            `json:"tls_valid" db:"tls_valid"`         // 0 = no, 1 = yes, 2 = not applicable
+	ZMQCapable      int            `json:"zmq_capable" db:"zmq_capable"`     // 0 = no, 1 = yes, 2 = not checked
+	P2PReachable    int            `json:"p2p_reachable" db:"p2p_reachable"` // 0 = no, 1 = yes, 2 = not checked
 	IPv6Only        bool           `json:"ipv6_only" db:"ipv6_only"`
 	IPAddresses     string         `json:"ip_addresses" db:"ip_addresses"`
 	IsArchived      int            `json:"is_archived" db:"is_archived"`
+	ArchivedReason  string         `json:"archived_reason" db:"archived_reason"` // see ArchivedBy* constants
+	IsBanned        int            `json:"is_banned" db:"is_banned"`
+	BanReason       string         `json:"ban_reason" db:"ban_reason"`
 	// Rucknium's node data
-	IsSpyNode         int `json:"is_spy_node" db:"is_spy_node"`                   // 0 = no, 1 = yes, 2 = not applied
-	MRLBanListEnabled int `json:"mrl_ban_list_enabled" db:"mrl_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not applied
-	DNSBanListEnabled int `json:"dns_ban_list_enabled" db:"dns_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not applied
+	IsSpyNode         int    `json:"is_spy_node" db:"is_spy_node"`                   // 0 = no, 1 = yes, 2 = not applied
+	SpyNodeIP         string `json:"spy_node_ip" db:"spy_node_ip"`                   // node address matched as spy node
+	MRLBanListEnabled int    `json:"mrl_ban_list_enabled" db:"mrl_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not applied
+	DNSBanListEnabled int    `json:"dns_ban_list_enabled" db:"dns_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not applied
+	// probe schedule, see SchedulePolicy
+	NextCheck        int64 `json:"next_check" db:"next_check"`
+	MinCheckInterval uint  `json:"min_check_interval" db:"min_check_interval"` // in seconds, 0 = policy default
+	CheckFailures    uint  `json:"check_failures" db:"check_failures"`         // consecutive failed probes
 }
 
 // Get node from database by id
@@ -87,16 +113,23 @@ func (r *moneroRepo) Node(id int) (Node, error) {
 // QueryNodes represents database query parameters
 type QueryNodes struct {
 	paging.Paging
-	Host       string `url:"host,omitempty"`
-	Nettype    string `url:"nettype,omitempty"`  // Can be empty string, "any", mainnet, stagenet, testnet.
-	Protocol   string `url:"protocol,omitempty"` // Can be "any", tor, http, https. Default: "any"
-	CC         string `url:"cc,omitempty"`       // 2 letter country code
-	Status     int    `url:"status"`
-	CORS       string `url:"cors,omitempty"`
-	IsArchived int    `url:"archived,omitempty"`
-	IsSpyNode  int    `url:"spynode"`
-	MRLBan     string `url:"mrlban,omitempty"`
-	DNSBan     string `url:"dnsban,omitempty"`
+	Host          string `url:"host,omitempty"`
+	Nettype       string `url:"nettype,omitempty"`  // Can be empty string, "any", mainnet, stagenet, testnet.
+	Protocol      string `url:"protocol,omitempty"` // Can be "any", tor, http, https. Default: "any"
+	CC            string `url:"cc,omitempty"`       // 2 letter country code
+	Status        int    `url:"status"`
+	CORS          string `url:"cors,omitempty"`
+	IsArchived    int    `url:"archived,omitempty"`
+	IsSpyNode     int    `url:"spynode"`
+	IsBanned      int    `url:"banned"`
+	MRLBan        string `url:"mrlban,omitempty"`
+	DNSBan        string `url:"dnsban,omitempty"`
+	Pending       string `url:"pending,omitempty"`        // "on" to only show nodes that never successfully probed
+	VersionStatus string `url:"version_status,omitempty"` // one of VersionStatuses
+	ZMQ           string `url:"zmq,omitempty"`            // "on" to only show ZMQ pub capable nodes
+	P2P           string `url:"p2p,omitempty"`            // "on" to only show nodes with reachable P2P port
+	TLS           string `url:"tls_valid,omitempty"`      // one of TLSFilters
+	TLSExpiryDays int    `url:"-"`                        // days before expiry TLSFilterExpiring matches
 }
 
 // toSQL generates SQL query from query parameters
@@ -148,6 +181,10 @@ func (q *QueryNodes) toSQL() (args []interface{}, where string) {
 		wq = append(wq, "is_spy_node = ?")
 		args = append(args, q.IsSpyNode)
 	}
+	if q.IsBanned != -1 {
+		wq = append(wq, "is_banned = ?")
+		args = append(args, q.IsBanned)
+	}
 	if q.MRLBan == "on" {
 		wq = append(wq, "mrl_ban_list_enabled = ?")
 		args = append(args, 1)
@@ -156,12 +193,39 @@ func (q *QueryNodes) toSQL() (args []interface{}, where string) {
 		wq = append(wq, "dns_ban_list_enabled = ?")
 		args = append(args, 1)
 	}
+	if slices.Contains(VersionStatuses, q.VersionStatus) {
+		wq = append(wq, "version_status = ?")
+		args = append(args, q.VersionStatus)
+	}
+	if q.ZMQ == "on" {
+		wq = append(wq, "zmq_capable = ?")
+		args = append(args, 1)
+	}
+	if q.P2P == "on" {
+		wq = append(wq, "p2p_reachable = ?")
+		args = append(args, 1)
+	}
+	switch q.TLS {
+	case TLSFilterValid:
+		wq = append(wq, "tls_valid = ?")
+		args = append(args, 1)
+	case TLSFilterInvalid:
+		wq = append(wq, "tls_valid = ?")
+		args = append(args, 0)
+	case TLSFilterExpiring:
+		wq = append(wq, "tls_valid = ? AND id IN (SELECT node_id FROM tbl_node_tls WHERE not_after < ?)")
+		args = append(args, 1, time.Now().AddDate(0, 0, q.TLSExpiryDays).Unix())
+	}
+	if q.Pending == "on" {
+		wq = append(wq, "nettype = ?")
+		args = append(args, "")
+	}
 
 	if len(wq) > 0 {
 		where = "WHERE " + strings.Join(wq, " AND ")
 	}
 
-	if !slices.Contains([]string{"last_checked", "uptime"}, q.SortBy) {
+	if !slices.Contains([]string{"last_checked", "uptime", "latency_p50", "latency_p90", "latency_p99"}, q.SortBy) {
 		q.SortBy = "last_checked"
 	}
 
@@ -172,6 +236,15 @@ func (q *QueryNodes) toSQL() (args []interface{}, where string) {
 	return args, where
 }
 
+// orderBy returns ORDER BY clause of the sort key, nodes without latency
+// data are always listed last
+func (q QueryNodes) orderBy() string {
+	if strings.HasPrefix(q.SortBy, "latency_") {
+		return fmt.Sprintf("%s = 0 ASC, %s %s", q.SortBy, q.SortBy, q.SortDirection)
+	}
+	return q.SortBy + " " + q.SortDirection
+}
+
 // Nodes represents a list of nodes
 type Nodes struct {
 	TotalRows   int     `json:"total_rows"`
@@ -208,7 +281,10 @@ func (r *moneroRepo) Nodes(q QueryNodes) (Nodes, error) {
 			hostname,
 			ip_addr,
 			port,
+			zmq_port,
+			p2p_port,
 			protocol,
+			login_required,
 			is_tor,
 			is_i2p,
 			is_available,
@@ -218,23 +294,37 @@ func (r *moneroRepo) Nodes(q QueryNodes) (Nodes, error) {
 			database_size,
 			difficulty,
 			version,
+			version_status,
+			hf_version,
+			hf_voting,
 			uptime,
+			latency_p50,
+			latency_p90,
+			latency_p99,
 			estimate_fee,
 			asn,
 			asn_name,
 			country,
 			country_name,
+			continent,
 			city,
 			lat,
 			lon,
+			geo_provider,
 			date_entered,
 			last_checked,
 			last_check_status,
 			cors_capable,
+			tls_valid,
+			zmq_capable,
+			p2p_reachable,
 			ipv6_only,
 			ip_addresses,
 			is_archived,
+			is_banned,
+			ban_reason,
 			is_spy_node,
+			spy_node_ip,
 			mrl_ban_list_enabled,
 			dns_ban_list_enabled
 		FROM
@@ -242,15 +332,17 @@ func (r *moneroRepo) Nodes(q QueryNodes) (Nodes, error) {
 		%s
 		ORDER BY
 			%s
-			%s
 		LIMIT ?
-		OFFSET ?`, where, q.SortBy, q.SortDirection)
+		OFFSET ?`, where, q.orderBy())
 	err = r.db.Select(&nodes.Items, query, args...)
 
 	return nodes, err
 }
 
-func (r *moneroRepo) Add(submitterIP, salt, protocol, hostname string, port uint) error {
+// Add adds a new node to be monitored. Optional ZMQ pub and P2P ports (0 to
+// use the nettype default) and RPC login are declared by the node operator.
+// The RPC login is encrypted using the salt (APP_SECRET).
+func (r *moneroRepo) Add(submitterIP, salt, protocol, hostname string, port, zmqPort, p2pPort uint, login RPCLogin) error {
 	if protocol != "http" && protocol != "https" {
 		return errors.New("invalid protocol, must one of or HTTP/HTTPS")
 	}
@@ -258,6 +350,19 @@ func (r *moneroRepo) Add(submitterIP, salt, protocol, hostname string, port uint
 	if port > 65535 || port < 1 {
 		return errors.New("invalid port number")
 	}
+	if zmqPort > 65535 || p2pPort > 65535 {
+		return errors.New("invalid ZMQ or P2P port number")
+	}
+	if err := login.validate(); err != nil {
+		return err
+	}
+	rpcLogin := ""
+	if login.Username != "" {
+		var err error
+		if rpcLogin, err = encryptLogin(salt, login); err != nil {
+			return err
+		}
+	}
 
 	is_tor := false
 	if strings.HasSuffix(hostname, ".onion") {
@@ -334,7 +439,7 @@ func (r *moneroRepo) Add(submitterIP, salt, protocol, hostname string, port uint
 		}
 
 		if isArchived == 1 {
-			_, err := r.db.Exec(`UPDATE tbl_node SET is_archived = 0 WHERE id = ?`, id)
+			_, err := r.db.Exec(`UPDATE tbl_node SET is_archived = 0, archived_reason = '' WHERE id = ?`, id)
 			if err != nil {
 				return errors.New("failed to update node status")
 			}
@@ -349,6 +454,10 @@ func (r *moneroRepo) Add(submitterIP, salt, protocol, hostname string, port uint
 			protocol,
 			hostname,
 			port,
+			zmq_port,
+			p2p_port,
+			rpc_login,
+			login_required,
 			is_tor,
 			is_i2p,
 			nettype,
@@ -382,11 +491,19 @@ func (r *moneroRepo) Add(submitterIP, salt, protocol, hostname string, port uint
 			?,
 			?,
 			?,
+			?,
+			?,
+			?,
+			?,
 			?
 		)`,
 		protocol,
 		hostname,
 		port,
+		zmqPort,
+		p2pPort,
+		rpcLogin,
+		rpcLogin != "",
 		is_tor,
 		is_i2p,
 		"",
@@ -444,21 +561,103 @@ func (r *moneroRepo) Delete(id uint) error {
 	if _, err := r.db.Exec(`DELETE FROM tbl_probe_log WHERE node_id = ?`, id); err != nil {
 		return err
 	}
+	if _, err := r.db.Exec(`DELETE FROM tbl_node_tls WHERE node_id = ?`, id); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// Reasons of archived nodes, stored in `archived_reason` column of tbl_node
+const (
+	ArchivedByAdmin   = "admin"    // archived from the admin page
+	ArchivedByUptime  = "uptime"   // 0% uptime from > 50 probes
+	ArchivedByBanList = "ban_list" // auto-archived by RecheckBannedNodes
+)
+
 // Archive node instead of deleteing the records (especially for spy nodes)
 // it could be useful somehow in the future.
 // https://github.com/ditatompel/xmr-remote-nodes/issues/191#issuecomment-3090599618
-func (r *moneroRepo) Archive(id uint) error {
-	if _, err := r.db.Exec(`UPDATE tbl_node SET is_archived = ? WHERE id = ?`, 1, id); err != nil {
+func (r *moneroRepo) Archive(id uint, reason string) error {
+	if _, err := r.db.Exec(`UPDATE tbl_node SET is_archived = ?, archived_reason = ? WHERE id = ?`, 1, reason, id); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// NodeSummary represents total count of monitored nodes by its state
+type NodeSummary struct {
+	Total    int `json:"total" db:"total"`
+	Online   int `json:"online" db:"online"`
+	Archived int `json:"archived" db:"archived"`
+	Pending  int `json:"pending" db:"pending"`
+	SpyNodes int `json:"spy_nodes" db:"spy_nodes"`
+}
+
+// Default ZMQ pub and P2P ports of monerod per nettype
+var (
+	defaultZMQPorts = map[string]uint{"mainnet": 18083, "testnet": 28083, "stagenet": 38083}
+	defaultP2PPorts = map[string]uint{"mainnet": 18080, "testnet": 28080, "stagenet": 38080}
+)
+
+// ZMQEndpointPort returns the ZMQ pub port to check, the operator declared
+// port or the nettype default
+func (n Node) ZMQEndpointPort() uint {
+	if n.ZMQPort != 0 {
+		return n.ZMQPort
+	}
+	return defaultZMQPorts[n.Nettype]
+}
+
+// P2PEndpointPort returns the P2P port to check, the operator declared port
+// or the nettype default
+func (n Node) P2PEndpointPort() uint {
+	if n.P2PPort != 0 {
+		return n.P2PPort
+	}
+	return defaultP2PPorts[n.Nettype]
+}
+
+// SetPorts sets operator declared ZMQ pub and P2P ports of the node, 0 to
+// use the nettype default. Previous check results are reset.
+func (r *moneroRepo) SetPorts(id, zmqPort, p2pPort uint) error {
+	if zmqPort > 65535 || p2pPort > 65535 {
+		return errors.New("invalid ZMQ or P2P port number")
+	}
+	res, err := r.db.Exec(`
+		UPDATE tbl_node
+		SET
+			zmq_port = ?,
+			p2p_port = ?,
+			zmq_capable = ?,
+			p2p_reachable = ?
+		WHERE
+			id = ?`, zmqPort, p2pPort, 2, 2, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("node %d not found or ports unchanged", id)
+	}
+	return nil
+}
+
+// Summary returns total count of monitored nodes by its state
+func (r *moneroRepo) Summary() (NodeSummary, error) {
+	var s NodeSummary
+	err := r.db.Get(&s, `
+		SELECT
+			COUNT(id) AS total,
+			COALESCE(SUM(IF(is_available = 1 AND is_archived = 0, 1, 0)), 0) AS online,
+			COALESCE(SUM(IF(is_archived = 1, 1, 0)), 0) AS archived,
+			COALESCE(SUM(IF(nettype = '' AND is_archived = 0, 1, 0)), 0) AS pending,
+			COALESCE(SUM(IF(is_spy_node = 1, 1, 0)), 0) AS spy_nodes
+		FROM
+			tbl_node`)
+	return s, err
+}
+
 type NetFee struct {
 	Nettype     string `json:"nettype" db:"nettype"`
 	EstimateFee uint   `json:"estimate_fee" db:"estimate_fee"`
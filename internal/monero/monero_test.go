@@ -63,6 +63,7 @@ func TestQueryNodes_toSQL(t *testing.T) {
 				Status:     -1,
 				IsArchived: -1,
 				IsSpyNode:  -1,
+				IsBanned:   -1,
 				CORS:       "",
 			},
 			wantArgs:    []interface{}{},
@@ -86,6 +87,7 @@ func TestQueryNodes_toSQL(t *testing.T) {
 				Status:     -1,
 				IsArchived: -1,
 				IsSpyNode:  -1,
+				IsBanned:   -1,
 				CORS:       "",
 			},
 			wantArgs:    []interface{}{"%test%", "%test%"},
@@ -93,6 +95,30 @@ func TestQueryNodes_toSQL(t *testing.T) {
 			wantSortBy:  "last_checked",
 			wantSortDir: "DESC",
 		},
+		{
+			name: "Sort by latency",
+			query: QueryNodes{
+				Paging: paging.Paging{
+					Limit:         10,
+					Page:          1,
+					SortBy:        "latency_p90",
+					SortDirection: "asc",
+				},
+				Host:       "",
+				Nettype:    "any",
+				Protocol:   "any",
+				CC:         "any",
+				Status:     -1,
+				IsArchived: -1,
+				IsSpyNode:  -1,
+				IsBanned:   -1,
+				CORS:       "",
+			},
+			wantArgs:    []interface{}{},
+			wantWhere:   "",
+			wantSortBy:  "latency_p90",
+			wantSortDir: "asc",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/ditatompel/xmr-remote-nodes/internal/database"
 
@@ -19,10 +20,44 @@ type proberRepo struct {
 type Prober struct {
 	ID           int64     `json:"id" db:"id"`
 	Name         string    `json:"name" db:"name"`
+	Region       string    `json:"region" db:"region"` // used to group latency, see LatencyStats
 	APIKey       uuid.UUID `json:"api_key" db:"api_key"`
 	LastSubmitTS int64     `json:"last_submit_ts" db:"last_submit_ts"`
 }
 
+// ProberCapabilities are networks accepted and capacity declared by the
+// prober when fetching a job
+type ProberCapabilities struct {
+	AcceptTor  bool `json:"accept_tor" db:"accept_tor"`
+	AcceptI2P  bool `json:"accept_i2p" db:"accept_i2p"`
+	AcceptIPv6 bool `json:"accept_ipv6" db:"accept_ipv6"`
+	Capacity   int  `json:"capacity" db:"capacity"` // probes per hour, 0 = defaultProberCapacity
+}
+
+// Declare stores the prober capabilities and the last job request time
+func (r *proberRepo) Declare(id int64, caps ProberCapabilities) error {
+	if caps.Capacity < 0 {
+		caps.Capacity = 0
+	}
+	_, err := r.db.Exec(`
+		UPDATE tbl_prober
+		SET
+			accept_tor = ?,
+			accept_i2p = ?,
+			accept_ipv6 = ?,
+			capacity = ?,
+			last_job_ts = ?
+		WHERE
+			id = ?`,
+		caps.AcceptTor,
+		caps.AcceptI2P,
+		caps.AcceptIPv6,
+		caps.Capacity,
+		time.Now().Unix(),
+		id)
+	return err
+}
+
 // Initializes a new ProberRepository
 //
 // NOTE: This "prober" is different with "probe" which is used to fetch a new job
@@ -67,6 +102,26 @@ func (r *proberRepo) Edit(id int, name string) error {
 	return err
 }
 
+// SetRegion sets the prober region, empty to remove it
+func (r *proberRepo) SetRegion(id int, region string) error {
+	region = strings.TrimSpace(region)
+	if len(region) > 64 {
+		return fmt.Errorf("region must not exceed 64 characters")
+	}
+	res, err := r.db.Exec(`UPDATE tbl_prober SET region = ? WHERE id = ?`, region, id)
+	if err != nil {
+		return err
+	}
+	row, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if row == 0 {
+		return fmt.Errorf("no rows affected")
+	}
+	return err
+}
+
 // Delete an existing prober
 func (r *proberRepo) Delete(id int) error {
 	res, err := r.db.Exec(`DELETE FROM tbl_prober WHERE id = ?`, id)
@@ -121,6 +176,7 @@ func (r *proberRepo) Probers(q QueryProbers) ([]Prober, error) {
 		SELECT
 			id,
 			name,
+			region,
 			api_key,
 			last_submit_ts
 		FROM
@@ -136,7 +192,7 @@ func (r *proberRepo) Probers(q QueryProbers) ([]Prober, error) {
 
 	for row.Next() {
 		var p Prober
-		err = row.Scan(&p.ID, &p.Name, &p.APIKey, &p.LastSubmitTS)
+		err = row.Scan(&p.ID, &p.Name, &p.Region, &p.APIKey, &p.LastSubmitTS)
 		if err != nil {
 			return probers, err
 		}
@@ -1,6 +1,7 @@
 package monero
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +20,7 @@ type QueryLogs struct {
 	NodeID       int    `url:"node_id,omitempty"`       // 0 for all, >0 for specific node
 	Status       int    `url:"status"`                  // -1 for all, 0 for failed, 1 for success
 	FailedReason string `url:"failed_reason,omitempty"` // empty for all, non empty string will be used as search
+	FailureCode  string `url:"failure_code,omitempty"`  // empty for all, one of FailureCodes
 }
 
 func (q QueryLogs) toSQL() (args []interface{}, where, sortBy, sortDirection string) {
@@ -35,6 +37,10 @@ func (q QueryLogs) toSQL() (args []interface{}, where, sortBy, sortDirection str
 		wq = append(wq, "failed_reason LIKE ?")
 		args = append(args, "%"+q.FailedReason+"%")
 	}
+	if slices.Contains(FailureCodes, q.FailureCode) {
+		wq = append(wq, "failure_code = ?")
+		args = append(args, q.FailureCode)
+	}
 
 	if len(wq) > 0 {
 		where = "WHERE " + strings.Join(wq, " AND ")
@@ -65,6 +71,7 @@ type FetchLog struct {
 	EstimateFee  int     `db:"estimate_fee" json:"estimate_fee"`
 	DateChecked  int64   `db:"date_checked" json:"date_checked"`
 	FailedReason string  `db:"failed_reason" json:"failed_reason"`
+	FailureCode  string  `db:"failure_code" json:"failure_code"`
 	FetchRuntime float64 `db:"fetch_runtime" json:"fetch_runtime"`
 }
 
@@ -108,21 +115,23 @@ func (r *moneroRepo) Logs(q QueryLogs) (FetchLogs, error) {
 	return fetchLogs, err
 }
 
-// GiveJob returns node that should be probed for the next time
-func (r *moneroRepo) GiveJob(acceptTor, acceptI2P, acceptIPv6 int) (Node, error) {
+var errNoJob = errors.New("no node is due for probing")
+
+// GiveJob returns node that should be probed for the next time. Nodes of the
+// network type picked by pickNetwork are given first.
+func (r *moneroRepo) GiveJob(caps ProberCapabilities) (Node, error) {
 	args := []interface{}{}
 	wq := []string{}
-	where := ""
 
-	if acceptTor != 1 {
+	if !caps.AcceptTor {
 		wq = append(wq, "is_tor = ?")
 		args = append(args, 0)
 	}
-	if acceptI2P != 1 {
+	if !caps.AcceptI2P {
 		wq = append(wq, "is_i2p = ?")
 		args = append(args, 0)
 	}
-	if acceptIPv6 != 1 {
+	if !caps.AcceptIPv6 {
 		wq = append(wq, "ipv6_only = ?")
 		args = append(args, 0)
 	}
@@ -131,10 +140,41 @@ func (r *moneroRepo) GiveJob(acceptTor, acceptI2P, acceptIPv6 int) (Node, error)
 	wq = append(wq, "is_archived = ?")
 	args = append(args, 0)
 
-	if len(wq) > 0 {
-		where = "WHERE " + strings.Join(wq, " AND ")
+	// only nodes due for probing, see SchedulePolicy
+	now := time.Now()
+	wq = append(wq, "next_check <= ?")
+	args = append(args, now.Unix())
+
+	if caps.AcceptTor || caps.AcceptI2P {
+		status, err := r.jobSchedulerStatus(DefaultSchedulePolicy())
+		if err != nil {
+			slog.Warn(fmt.Sprintf("[JOB] Scheduler status: %s", err))
+		}
+		var network []string
+		switch pickNetwork(status.Networks, caps) {
+		case NetworkTor:
+			network = []string{"is_tor = 1"}
+		case NetworkI2P:
+			network = []string{"is_i2p = 1"}
+		case NetworkClearnet:
+			network = []string{"is_tor = 0", "is_i2p = 0"}
+		}
+		if len(network) > 0 {
+			// IPv6 only backlog may not be probed by the prober, fall back
+			// to any accepted network
+			node, err := r.giveJob(append(slices.Clone(wq), network...), args, now)
+			if !errors.Is(err, errNoJob) {
+				return node, err
+			}
+		}
 	}
 
+	return r.giveJob(wq, args, now)
+}
+
+func (r *moneroRepo) giveJob(wq []string, args []interface{}, now time.Time) (Node, error) {
+	where := "WHERE " + strings.Join(wq, " AND ")
+
 	var node Node
 
 	query := fmt.Sprintf(`
@@ -142,7 +182,10 @@ func (r *moneroRepo) GiveJob(acceptTor, acceptI2P, acceptIPv6 int) (Node, error)
 			id,
 			hostname,
 			port,
+			zmq_port,
+			p2p_port,
 			protocol,
+			rpc_login,
 			is_tor,
 			is_i2p,
 			last_check_status
@@ -150,24 +193,34 @@ func (r *moneroRepo) GiveJob(acceptTor, acceptI2P, acceptIPv6 int) (Node, error)
 			tbl_node
 		%s
 		ORDER BY
+			next_check ASC,
 			last_checked ASC
 		LIMIT 1`, where)
 	err := r.db.QueryRow(query, args...).Scan(
 		&node.ID,
 		&node.Hostname,
 		&node.Port,
+		&node.ZMQPort,
+		&node.P2PPort,
 		&node.Protocol,
+		&node.RPCLogin,
 		&node.IsTor,
 		&node.IsI2P,
 		&node.LastCheckStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return node, errNoJob
+	}
 	if err != nil {
 		return node, err
 	}
 
 	_, err = r.db.Exec(`
 		UPDATE tbl_node
-		SET last_checked = ?
-		WHERE id = ?`, time.Now().Unix(), node.ID)
+		SET
+			last_checked = ?,
+			next_check = ?
+		WHERE
+			id = ?`, now.Unix(), now.Add(jobLease).Unix(), node.ID)
 	if err != nil {
 		return node, err
 	}
@@ -176,9 +229,61 @@ func (r *moneroRepo) GiveJob(acceptTor, acceptI2P, acceptIPv6 int) (Node, error)
 }
 
 type ProbeReport struct {
-	TookTime float64 `json:"took_time"`
-	Message  string  `json:"message"`
-	Node     Node    `json:"node"`
+	TookTime    float64    `json:"took_time"`
+	Message     string     `json:"message"`
+	FailureCode string     `json:"failure_code,omitempty"` // one of FailureCodes if the node is not available
+	Node        Node       `json:"node"`
+	PortCheck   *PortCheck `json:"port_check,omitempty"` // nil if the prober doesn't check ports
+	TLS         *TLSInfo   `json:"tls,omitempty"`        // nil if no TLS handshake was made
+
+	// unix timestamp when the node was probed, set by probers replaying
+	// spooled reports. Zero means now.
+	CheckedAt int64 `json:"checked_at,omitempty"`
+
+	// seconds the successful get_info request took, without retries, port
+	// checks and other RPC calls. Zero if the node did not respond or the
+	// prober doesn't report it.
+	RPCTime float64 `json:"rpc_time,omitempty"`
+}
+
+const (
+	// ReportMaxAge is the maximum age of a probe report accepted by the
+	// server, older spooled reports are dropped
+	ReportMaxAge = 24 * time.Hour
+	// reportMaxSkew is the allowed prober clock skew of reports "from the
+	// future"
+	reportMaxSkew = 5 * time.Minute
+)
+
+var (
+	errReportTooOld = errors.New("checked_at is older than the maximum report age")
+	errReportFuture = errors.New("checked_at is in the future")
+)
+
+// CheckedTime returns the time the node was probed, the reported checked_at
+// must be within ReportMaxAge and the allowed clock skew of now
+func (p *ProbeReport) CheckedTime(now time.Time) (time.Time, error) {
+	if p.CheckedAt == 0 {
+		return now, nil
+	}
+	t := time.Unix(p.CheckedAt, 0)
+	switch {
+	case t.Before(now.Add(-ReportMaxAge)):
+		return now, errReportTooOld
+	case t.After(now.Add(reportMaxSkew)):
+		return now, errReportFuture
+	case t.After(now):
+		// small clock skew, don't record checks in the future
+		return now, nil
+	}
+	return t, nil
+}
+
+// PortCheck is ZMQ pub and P2P port check result of the prober, each is 0 =
+// failed, 1 = success, 2 = not checked
+type PortCheck struct {
+	ZMQ int `json:"zmq"`
+	P2P int `json:"p2p"`
 }
 
 type nodeStats struct {
@@ -217,6 +322,10 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 	}
 
 	now := time.Now()
+	checkedAt, err := report.CheckedTime(now)
+	if err != nil {
+		return err
+	}
 
 	qInsertLog := `
 		INSERT INTO tbl_probe_log (
@@ -230,7 +339,9 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			estimate_fee,
 			date_checked,
 			failed_reason,
-			fetch_runtime
+			failure_code,
+			fetch_runtime,
+			rpc_runtime
 		) VALUES (
 			?,
 			?,
@@ -242,9 +353,11 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			?,
 			?,
 			?,
+			?,
+			?,
 			?
 		)`
-	_, err := r.db.Exec(qInsertLog,
+	_, err = r.db.Exec(qInsertLog,
 		report.Node.ID,
 		proberId,
 		report.Node.IsAvailable,
@@ -253,9 +366,11 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 		report.Node.DatabaseSize,
 		report.Node.Difficulty,
 		report.Node.EstimateFee,
-		now.Unix(),
+		checkedAt.Unix(),
 		report.Message,
-		report.TookTime)
+		validFailureCode(report.FailureCode),
+		report.TookTime,
+		report.RPCTime)
 	if err != nil {
 		return err
 	}
@@ -281,6 +396,29 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 	avgUptime := (float64(stats.Online) / float64(stats.TotalFetched)) * 100
 	report.Node.Uptime = math.Ceil(avgUptime*100) / 100
 
+	// a replayed report must not overwrite the node state reported by a
+	// newer probe, only its probe log is recorded
+	if checkedAt.Before(now) {
+		var newer int
+		if err := r.db.Get(&newer, `
+			SELECT
+				COUNT(id)
+			FROM
+				tbl_probe_log
+			WHERE
+				node_id = ?
+				AND date_checked > ?`, report.Node.ID, checkedAt.Unix()); err != nil {
+			return err
+		}
+		if newer > 0 {
+			_, err = r.db.Exec(`
+				UPDATE tbl_prober
+				SET last_submit_ts = ?
+				WHERE id = ?`, now.Unix(), proberId)
+			return err
+		}
+	}
+
 	statuses := report.parseStatuses()
 
 	// recheck IP
@@ -288,13 +426,21 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 		if ipInfo, errGeoIp := geo.Info(report.Node.IP); errGeoIp != nil {
 			fmt.Println("WARN:", errGeoIp.Error())
 		} else {
-			report.Node.ASN = ipInfo.ASN
-			report.Node.ASNName = ipInfo.ASNOrg
-			report.Node.CountryCode = ipInfo.CountryCode
-			report.Node.CountryName = ipInfo.CountryName
-			report.Node.City = ipInfo.City
-			report.Node.Longitude = ipInfo.Longitude
-			report.Node.Latitude = ipInfo.Latitude
+			// only one of the databases may be available, keep the
+			// previous values of the missing one
+			if ipInfo.ASN != 0 {
+				report.Node.ASN = ipInfo.ASN
+				report.Node.ASNName = ipInfo.ASNOrg
+			}
+			if ipInfo.CountryCode != "" {
+				report.Node.CountryCode = ipInfo.CountryCode
+				report.Node.CountryName = ipInfo.CountryName
+				report.Node.ContinentCode = ipInfo.ContinentCode
+				report.Node.City = ipInfo.City
+				report.Node.Longitude = ipInfo.Longitude
+				report.Node.Latitude = ipInfo.Latitude
+			}
+			report.Node.GeoProvider = ipInfo.Provider
 		}
 	}
 
@@ -309,6 +455,9 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			database_size = ?,
 			difficulty = ?,
 			version = ?,
+			version_status = ?,
+			hf_version = ?,
+			hf_voting = ?,
 			uptime = ?,
 			estimate_fee = ?,
 			ip_addr = ?,
@@ -316,12 +465,18 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			asn_name = ?,
 			country = ?,
 			country_name = ?,
+			continent = ?,
 			city = ?,
+			lat = ?,
+			lon = ?,
+			geo_provider = ?,
 			last_checked = ?,
 			last_check_status = ?,
 			cors_capable = ?,
+			login_required = (rpc_login != '' OR ?),
 			ip_addresses = ?,
-			ipv6_only = ?
+			ipv6_only = ?,
+			check_failures = 0
 		WHERE
 			id = ?`
 		_, err := r.db.Exec(update,
@@ -332,6 +487,9 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			report.Node.DatabaseSize,
 			report.Node.Difficulty,
 			report.Node.Version,
+			r.nodeVersionStatus(report.Node.Version),
+			report.Node.HFVersion,
+			report.Node.HFVoting,
 			report.Node.Uptime,
 			report.Node.EstimateFee,
 			report.Node.IP,
@@ -339,10 +497,15 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			report.Node.ASNName,
 			report.Node.CountryCode,
 			report.Node.CountryName,
+			report.Node.ContinentCode,
 			report.Node.City,
-			now.Unix(),
+			report.Node.Latitude,
+			report.Node.Longitude,
+			report.Node.GeoProvider,
+			checkedAt.Unix(),
 			statuses,
 			report.Node.CORSCapable,
+			report.Node.LoginRequired,
 			report.Node.IPAddresses,
 			report.Node.IPv6Only,
 			report.Node.ID)
@@ -357,18 +520,35 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 			uptime = ?,
 			last_checked = ?,
 			last_check_status = ?,
+			login_required = (rpc_login != '' OR ?),
 			ip_addresses = ?,
-			ipv6_only = ?
+			ipv6_only = ?,
+			check_failures = check_failures + 1
 		WHERE
 			id = ?`
-		if _, err := r.db.Exec(u, 0, report.Node.Uptime, now.Unix(), statuses, report.Node.IPAddresses, report.Node.IPv6Only, report.Node.ID); err != nil {
+		if _, err := r.db.Exec(u, 0, report.Node.Uptime, checkedAt.Unix(), statuses, report.Node.LoginRequired, report.Node.IPAddresses, report.Node.IPv6Only, report.Node.ID); err != nil {
+			slog.Warn(err.Error())
+		}
+	}
+
+	// TLS handshake may succeed even if the RPC request fails
+	if report.TLS != nil && report.Node.Protocol == "https" {
+		if err := r.updateTLS(report.Node.ID, *report.TLS, checkedAt); err != nil {
 			slog.Warn(err.Error())
 		}
 	}
 
+	if report.Node.IsAvailable && report.PortCheck != nil {
+		r.updatePortCheck(report.Node.ID, *report.PortCheck)
+	}
+
+	if err := r.scheduleNext(DefaultSchedulePolicy(), report.Node.ID, checkedAt); err != nil {
+		slog.Warn(err.Error())
+	}
+
 	if avgUptime <= 0 && stats.TotalFetched > 50 {
 		fmt.Println("Archiving Monero node (0% uptime from > 50 records)")
-		if err := r.Archive(report.Node.ID); err != nil {
+		if err := r.Archive(report.Node.ID, ArchivedByUptime); err != nil {
 			slog.Warn(err.Error())
 		}
 	}
@@ -380,3 +560,18 @@ func (r *moneroRepo) ProcessJob(report ProbeReport, proberId int64) error {
 
 	return err
 }
+
+// updatePortCheck stores ZMQ pub and P2P port check results, unchecked ports
+// keep their previous result
+func (r *moneroRepo) updatePortCheck(nodeID uint, pc PortCheck) {
+	if pc.ZMQ == 0 || pc.ZMQ == 1 {
+		if _, err := r.db.Exec(`UPDATE tbl_node SET zmq_capable = ? WHERE id = ?`, pc.ZMQ, nodeID); err != nil {
+			slog.Warn(err.Error())
+		}
+	}
+	if pc.P2P == 0 || pc.P2P == 1 {
+		if _, err := r.db.Exec(`UPDATE tbl_node SET p2p_reachable = ? WHERE id = ?`, pc.P2P, nodeID); err != nil {
+			slog.Warn(err.Error())
+		}
+	}
+}
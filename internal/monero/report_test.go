@@ -1,7 +1,9 @@
 package monero
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/ditatompel/xmr-remote-nodes/internal/paging"
 	"github.com/jmoiron/sqlx/types"
@@ -53,6 +55,36 @@ func TestNode_parseStatuses(t *testing.T) {
 	}
 }
 
+// Single test:
+// go test -race ./internal/monero -run=TestProbeReport_CheckedTime -v
+func TestProbeReport_CheckedTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name      string
+		checkedAt int64
+		want      time.Time
+		wantErr   error
+	}{
+		{"not set", 0, now, nil},
+		{"replayed report", now.Add(-time.Hour).Unix(), now.Add(-time.Hour), nil},
+		{"too old", now.Add(-ReportMaxAge - time.Second).Unix(), now, errReportTooOld},
+		{"small clock skew", now.Add(time.Minute).Unix(), now, nil},
+		{"in the future", now.Add(time.Hour).Unix(), now, errReportFuture},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &ProbeReport{CheckedAt: tt.checkedAt}
+			got, err := p.CheckedTime(now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProbeReport.CheckedTime() error = %v, want %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ProbeReport.CheckedTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestQueryLogs_toSQL(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -116,6 +148,24 @@ func TestQueryLogs_toSQL(t *testing.T) {
 			wantSortBy:        "date_checked",
 			wantSortDirection: "ASC",
 		},
+		{
+			name: "With failure_code query",
+			fields: QueryLogs{
+				Paging: paging.Paging{
+					Limit:         10,
+					Page:          1,
+					SortBy:        "date_checked",
+					SortDirection: "desc",
+				},
+				NodeID:      1,
+				Status:      0,
+				FailureCode: FailureTimeout,
+			},
+			wantArgs:          []interface{}{1, 0, FailureTimeout},
+			wantWhere:         "WHERE node_id = ? AND is_available = ? AND failure_code = ?",
+			wantSortBy:        "date_checked",
+			wantSortDirection: "DESC",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -129,6 +179,7 @@ func TestQueryLogs_toSQL(t *testing.T) {
 				NodeID:       tt.fields.NodeID,
 				Status:       tt.fields.Status,
 				FailedReason: tt.fields.FailedReason,
+				FailureCode:  tt.fields.FailureCode,
 			}
 			gotArgs, gotWhere, gotSortBy, gotSortDirection := q.toSQL()
 			if !equalArgs(gotArgs, tt.wantArgs) {
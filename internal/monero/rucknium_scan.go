@@ -1,14 +1,15 @@
 package monero
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net"
-	"net/http"
+	"net/netip"
 	"strings"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
 )
 
 // RuckniumNodeData represents a single remote node data from Rucknium's MRL ban list
@@ -22,37 +23,67 @@ type RuckniumNodeData struct {
 	DNSBanListEnabled int    `json:"dns_ban_list_enabled" db:"dns_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not applied
 }
 
-// Get Individual node info from moneronet.info
-func (r *moneroRepo) FetchRuckniumNodeData() error {
-	req, err := http.NewRequest(http.MethodGet, "https://api.moneronet.info/individual_node_data?date=latest", nil)
-	if err != nil {
-		return err
+// DefaultRuckniumAPIURL is used when `RUCKNIUM_API_URL` is not configured
+const DefaultRuckniumAPIURL = "https://api.moneronet.info/individual_node_data?date=latest"
+
+// parseRuckniumNodeData parses Rucknium's `individual_node_data` API
+// response. Entries without scan date or IP address are ignored.
+func parseRuckniumNodeData(body []byte) ([]RuckniumNodeData, error) {
+	var data []RuckniumNodeData
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
 	}
+	nodes := make([]RuckniumNodeData, 0, len(data))
+	for _, node := range data {
+		if node.Date == "" || node.ConnectedNodeIP == "" {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// fetchRuckniumNodeData downloads and parses Rucknium's node data. The
+// returned nodes are nil if the data is unchanged since the last import.
+func fetchRuckniumNodeData(ctx context.Context, f *fetcher.Fetcher, url string) ([]RuckniumNodeData, *fetcher.Response, error) {
+	resp, err := f.Fetch(ctx, url)
 	if err != nil {
-		return err
+		return nil, nil, fmt.Errorf("failed to fetch Rucknium's API: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		slog.Error("[MRL] Failed to fetch Rucknium's API")
-		return errors.New("failed to fetch Rucknium's API")
+	if resp.NotModified {
+		return nil, resp, nil
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	nodes, err := parseRuckniumNodeData(resp.Body)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	if len(nodes) == 0 {
+		return nil, nil, errors.New("no Rucknium data found")
+	}
+	return nodes, resp, nil
+}
 
-	var nodes []RuckniumNodeData
-	if err := json.Unmarshal(body, &nodes); err != nil {
-		return err
+// Get Individual node info from Rucknium's API (moneronet.info by default).
+// Returns the number of inserted or updated rows, zero if the API response
+// is unchanged since the last import.
+func (r *moneroRepo) FetchRuckniumNodeData(ctx context.Context, f *fetcher.Fetcher, url string) (int64, error) {
+	nodes, resp, err := fetchRuckniumNodeData(ctx, f, url)
+	if err != nil {
+		slog.Error(fmt.Sprintf("[MRL] %s", err))
+		return 0, err
+	}
+	if resp.NotModified {
+		slog.Info("[MRL] Rucknium's node data unchanged")
+		return 0, nil
 	}
 
+	var affected int64
 	for _, node := range nodes {
-		_, err := r.db.Exec(`INSERT INTO tbl_rucknium_scan (
+		if ctx.Err() != nil {
+			return affected, ctx.Err()
+		}
+		_, err := r.db.ExecContext(ctx, `INSERT INTO tbl_rucknium_scan (
 			scan_date,
 			connected_node_ip,
 			is_spy_node,
@@ -79,10 +110,16 @@ func (r *moneroRepo) FetchRuckniumNodeData() error {
 			node.DNSBanListEnabled)
 		if err != nil {
 			slog.Error(fmt.Sprintf("[MRL] Failed to insert or update Rucknium's node list: %s", err))
+			continue
 		}
+		affected++
 	}
 
-	return nil
+	if err := f.Commit(ctx, resp); err != nil {
+		slog.Warn(fmt.Sprintf("[MRL] Failed to store Rucknium's API cache validators: %s", err))
+	}
+
+	return affected, nil
 }
 
 // Get Rucknium node data from local database
@@ -113,21 +150,130 @@ func (r *moneroRepo) GetLatestRuckniumDate() (string, error) {
 	return latestDate, err
 }
 
-// Check Rucknium ban list
-func (r *moneroRepo) CheckMRLBan() error {
+// ruckniumIndex is Rucknium's node data indexed by normalised IP address.
+// Entries containing a subnet are matched by prefix.
+type ruckniumIndex struct {
+	addrs    map[netip.Addr]RuckniumNodeData
+	prefixes []ruckniumPrefix
+}
+
+type ruckniumPrefix struct {
+	prefix netip.Prefix
+	data   RuckniumNodeData
+}
+
+// mrlResult is the result of matching node IP addresses with Rucknium's
+// node data
+type mrlResult struct {
+	IsSpyNode         int
+	SpyNodeIP         string // the node address matched as spy node
+	MRLBanListEnabled int
+	DNSBanListEnabled int
+}
+
+func newRuckniumIndex(data []RuckniumNodeData) ruckniumIndex {
+	idx := ruckniumIndex{addrs: make(map[netip.Addr]RuckniumNodeData, len(data))}
+	for _, rn := range data {
+		p, ok := parseIPCIDRToPrefix(strings.TrimSpace(rn.ConnectedNodeIP))
+		if !ok {
+			continue
+		}
+		if p.IsSingleIP() {
+			idx.addrs[p.Addr().Unmap()] = rn
+			continue
+		}
+		idx.prefixes = append(idx.prefixes, ruckniumPrefix{prefix: p.Masked(), data: rn})
+	}
+	return idx
+}
+
+func (idx ruckniumIndex) lookup(addr netip.Addr) (RuckniumNodeData, bool) {
+	if rn, ok := idx.addrs[addr]; ok {
+		return rn, true
+	}
+	for _, p := range idx.prefixes {
+		if p.prefix.Contains(addr) {
+			return p.data, true
+		}
+	}
+	return RuckniumNodeData{}, false
+}
+
+// evaluate matches comma separated IPv4 and IPv6 node addresses.
+//
+// A node is a spy node if any of its addresses matches a spy node. Ban list
+// is considered enabled only if all node addresses found in the scan have it
+// enabled. If none of the addresses is found in the scan, ban list is
+// considered disabled (0), see inScan.
+func (idx ruckniumIndex) evaluate(ipAddresses string) mrlResult {
+	res := mrlResult{MRLBanListEnabled: 1, DNSBanListEnabled: 1}
+	found := false
+	for _, addr := range parseNodeAddrs(ipAddresses) {
+		rn, ok := idx.lookup(addr)
+		if !ok {
+			continue
+		}
+		found = true
+		if rn.IsSpyNode == 1 && res.IsSpyNode == 0 {
+			res.IsSpyNode = 1
+			res.SpyNodeIP = addr.String()
+		}
+		if rn.MRLBanListEnabled != 1 {
+			res.MRLBanListEnabled = 0
+		}
+		if rn.DNSBanListEnabled != 1 {
+			res.DNSBanListEnabled = 0
+		}
+	}
+	if !found {
+		res.MRLBanListEnabled = 0
+		res.DNSBanListEnabled = 0
+	}
+	return res
+}
+
+// inScan reports whether any of comma separated node addresses is found in
+// the scan
+func (idx ruckniumIndex) inScan(ipAddresses string) bool {
+	for _, addr := range parseNodeAddrs(ipAddresses) {
+		if _, ok := idx.lookup(addr); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNodeAddrs parses comma separated node addresses, invalid addresses
+// are skipped
+func parseNodeAddrs(ipAddresses string) []netip.Addr {
+	var addrs []netip.Addr
+	for _, ip := range strings.Split(ipAddresses, ",") {
+		addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, addr.Unmap())
+	}
+	return addrs
+}
+
+// Check Rucknium ban list for both IPv4 and IPv6 node addresses.
+// Returns the number of updated nodes.
+func (r *moneroRepo) CheckMRLBan(ctx context.Context) (int64, error) {
 	latestDate, err := r.GetLatestRuckniumDate()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	mrlData, err := r.GetRuckniumData(latestDate)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if len(mrlData) == 0 {
-		return errors.New("no Rucknium data found")
+		return 0, errors.New("no Rucknium data found")
 	}
+	idx := newRuckniumIndex(mrlData)
 
 	var nodes []Node
 
@@ -136,77 +282,191 @@ func (r *moneroRepo) CheckMRLBan() error {
 		WHERE nettype = ?
 			AND is_archived = ?
 			AND is_tor = ?
-			AND is_i2p = ?
-			AND is_i2p = ?
-			AND ipv6_only = ?`
+			AND is_i2p = ?`
 	// For now, Monero Network scan only checks mainnet
-	err = r.db.Select(&nodes, query, "mainnet", 0, 0, 0, 0, 0)
+	err = r.db.Select(&nodes, query, "mainnet", 0, 0, 0)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	// maps for each property
-	spyIPMap := make(map[string]bool)
-	mrlBanMap := make(map[string]bool)
-	dnsBanMap := make(map[string]bool)
-
-	for _, rn := range mrlData {
-		ip := rn.ConnectedNodeIP
-		if rn.IsSpyNode == 1 {
-			spyIPMap[ip] = true
-		}
-		if rn.MRLBanListEnabled == 1 {
-			mrlBanMap[ip] = true
+	var updated int64
+	for _, node := range nodes {
+		if ctx.Err() != nil {
+			return updated, ctx.Err()
 		}
-		if rn.DNSBanListEnabled == 1 {
-			dnsBanMap[ip] = true
+		res := idx.evaluate(node.IPAddresses)
+
+		// Update node MRL columns info in the database
+		_, err := r.db.ExecContext(ctx, `UPDATE tbl_node SET
+			is_spy_node = ?,
+			spy_node_ip = ?,
+			mrl_ban_list_enabled = ?,
+			dns_ban_list_enabled = ?
+			WHERE id = ?`,
+			res.IsSpyNode,
+			res.SpyNodeIP,
+			res.MRLBanListEnabled,
+			res.DNSBanListEnabled,
+			node.ID)
+		if err != nil {
+			return updated, err
 		}
+		updated++
 	}
 
-	for i, node := range nodes {
-		ipList := strings.Split(node.IPAddresses, ",")
-		nodes[i].IsSpyNode = 0
-		nodes[i].MRLBanListEnabled = 1
-		nodes[i].DNSBanListEnabled = 1
+	return updated, nil
+}
 
-		for _, ip := range ipList {
-			c := net.ParseIP(ip)
-			if c != nil && c.To4() == nil {
-				slog.Debug(fmt.Sprintf("[MRL] Skipping IPv6 address: %s", ip))
-				continue
-			}
+// RuckniumHistory represents node spy node and ban list status of a single
+// Rucknium's scan date
+type RuckniumHistory struct {
+	Date              string `json:"date"`
+	IsSpyNode         int    `json:"is_spy_node"` // 0 = no, 1 = yes
+	SpyNodeIP         string `json:"spy_node_ip"`
+	MRLBanListEnabled int    `json:"mrl_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not in scan
+	DNSBanListEnabled int    `json:"dns_ban_list_enabled"` // 0 = no, 1 = yes, 2 = not in scan
+	Changed           bool   `json:"changed"`              // differs from the previous scan date
+}
 
-			if spyIPMap[ip] {
-				nodes[i].IsSpyNode = 1
-			}
-			if !mrlBanMap[ip] {
-				nodes[i].MRLBanListEnabled = 0
-			}
-			if !dnsBanMap[ip] {
-				nodes[i].DNSBanListEnabled = 0
-			}
+// RuckniumTrend represents network-wide node counts of a single Rucknium's
+// scan date
+type RuckniumTrend struct {
+	Date               string `json:"date" db:"scan_date"`
+	TotalNodes         int    `json:"total_nodes" db:"total_nodes"`
+	SpyNodes           int    `json:"spy_nodes" db:"spy_nodes"`
+	MRLBanListEnabled  int    `json:"mrl_ban_list_enabled" db:"mrl_ban_list_enabled"`
+	DNSBanListEnabled  int    `json:"dns_ban_list_enabled" db:"dns_ban_list_enabled"`
+	BothBanListEnabled int    `json:"both_ban_list_enabled" db:"both_ban_list_enabled"`
+}
 
-			// if nodes[i].MRLBanListEnabled == 0 &&
-			// 	nodes[i].DNSBanListEnabled == 0 {
-			// 	break
-			// }
-		}
+// maxRuckniumDates is the maximum number of scan dates returned by history
+// and trends queries
+const maxRuckniumDates = 365
 
-		// Update node MRL columns info in the database
-		_, err := r.db.Exec(`UPDATE tbl_node SET
-			is_spy_node = ?,
-			mrl_ban_list_enabled = ?,
-			dns_ban_list_enabled = ?
-			WHERE id = ?`,
-			nodes[i].IsSpyNode,
-			nodes[i].MRLBanListEnabled,
-			nodes[i].DNSBanListEnabled,
-			nodes[i].ID)
+func ruckniumDatesLimit(limit int) int {
+	if limit <= 0 {
+		return 30
+	}
+	return min(limit, maxRuckniumDates)
+}
+
+// RuckniumDates returns the latest Rucknium's scan dates, newest first
+func (r *moneroRepo) RuckniumDates(limit int) ([]string, error) {
+	dates := []string{}
+	query := `
+	SELECT DISTINCT
+		scan_date
+	FROM
+		tbl_rucknium_scan
+	ORDER BY
+		scan_date DESC
+	LIMIT ?`
+	err := r.db.Select(&dates, query, ruckniumDatesLimit(limit))
+	return dates, err
+}
+
+// RuckniumHistory returns how spy node and ban list flags of the given comma
+// separated node IP addresses changed across the latest Rucknium's scan
+// dates, newest first.
+func (r *moneroRepo) RuckniumHistory(ipAddresses string, limit int) ([]RuckniumHistory, error) {
+	dates, err := r.RuckniumDates(limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(dates) == 0 {
+		return []RuckniumHistory{}, nil
+	}
+
+	var addrs []any
+	for _, ip := range strings.Split(ipAddresses, ",") {
+		addr, err := netip.ParseAddr(strings.TrimSpace(ip))
 		if err != nil {
-			return err
+			continue
 		}
+		addrs = append(addrs, addr.Unmap().String())
+	}
 
+	// subnet entries (eg. IPv6 /64) are matched by ruckniumIndex the same way
+	// as CheckMRLBan does, so they are always loaded
+	var rows []RuckniumNodeData
+	if len(addrs) > 0 {
+		query := fmt.Sprintf(`
+		SELECT
+			scan_date,
+			connected_node_ip,
+			is_spy_node,
+			mrl_ban_list_enabled,
+			dns_ban_list_enabled
+		FROM
+			tbl_rucknium_scan
+		WHERE
+			scan_date >= ?
+			AND (
+				connected_node_ip IN (%s)
+				OR connected_node_ip LIKE '%%/%%'
+			)`, strings.TrimSuffix(strings.Repeat("?,", len(addrs)), ","))
+		args := append([]any{dates[len(dates)-1]}, addrs...)
+		if err := r.db.Select(&rows, query, args...); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return ruckniumTimeline(dates, rows, ipAddresses), nil
+}
+
+// ruckniumTimeline evaluates node IP addresses against scan rows of each
+// scan date. Dates must be sorted newest first.
+func ruckniumTimeline(dates []string, rows []RuckniumNodeData, ipAddresses string) []RuckniumHistory {
+	byDate := make(map[string][]RuckniumNodeData, len(dates))
+	for _, rn := range rows {
+		byDate[rn.Date] = append(byDate[rn.Date], rn)
+	}
+
+	history := make([]RuckniumHistory, len(dates))
+	for i, date := range dates {
+		idx := newRuckniumIndex(byDate[date])
+		res := idx.evaluate(ipAddresses)
+		history[i] = RuckniumHistory{
+			Date:              date,
+			IsSpyNode:         res.IsSpyNode,
+			SpyNodeIP:         res.SpyNodeIP,
+			MRLBanListEnabled: res.MRLBanListEnabled,
+			DNSBanListEnabled: res.DNSBanListEnabled,
+		}
+		if !idx.inScan(ipAddresses) {
+			history[i].MRLBanListEnabled = 2
+			history[i].DNSBanListEnabled = 2
+		}
+	}
+	for i := 0; i < len(history)-1; i++ {
+		cur, prev := history[i], history[i+1]
+		history[i].Changed = cur.IsSpyNode != prev.IsSpyNode ||
+			cur.MRLBanListEnabled != prev.MRLBanListEnabled ||
+			cur.DNSBanListEnabled != prev.DNSBanListEnabled
+	}
+
+	return history
+}
+
+// RuckniumTrends returns network-wide spy node and ban list counts of the
+// latest Rucknium's scan dates, newest first.
+func (r *moneroRepo) RuckniumTrends(limit int) ([]RuckniumTrend, error) {
+	trends := []RuckniumTrend{}
+	query := `
+	SELECT
+		scan_date,
+		COUNT(*) AS total_nodes,
+		SUM(is_spy_node = 1) AS spy_nodes,
+		SUM(mrl_ban_list_enabled = 1) AS mrl_ban_list_enabled,
+		SUM(dns_ban_list_enabled = 1) AS dns_ban_list_enabled,
+		SUM(mrl_ban_list_enabled = 1 AND dns_ban_list_enabled = 1) AS both_ban_list_enabled
+	FROM
+		tbl_rucknium_scan
+	GROUP BY
+		scan_date
+	ORDER BY
+		scan_date DESC
+	LIMIT ?`
+	err := r.db.Select(&trends, query, ruckniumDatesLimit(limit))
+	return trends, err
 }
@@ -0,0 +1,265 @@
+package monero
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/fetcher"
+)
+
+// newFixtureServer serves recorded external data from testdata directory.
+// Responses include Last-Modified header, so conditional requests work.
+func newFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRuckniumIndex_evaluate -v
+func TestRuckniumIndex_evaluate(t *testing.T) {
+	idx := newRuckniumIndex([]RuckniumNodeData{
+		{ConnectedNodeIP: "1.1.1.1", IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		{ConnectedNodeIP: "2.2.2.2", IsSpyNode: 1, MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		{ConnectedNodeIP: "3.3.3.3", IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 0},
+		{ConnectedNodeIP: "2001:0db8:0000:0000:0000:0000:0000:0001", IsSpyNode: 1, MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		{ConnectedNodeIP: "2001:db8:1::/48", IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		{ConnectedNodeIP: "invalid", IsSpyNode: 1},
+	})
+
+	tests := []struct {
+		name        string
+		ipAddresses string
+		want        mrlResult
+	}{
+		{
+			name:        "IPv4 with ban lists enabled",
+			ipAddresses: "1.1.1.1",
+			want:        mrlResult{IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		},
+		{
+			name:        "IPv4 spy node",
+			ipAddresses: "2.2.2.2",
+			want:        mrlResult{IsSpyNode: 1, SpyNodeIP: "2.2.2.2", MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		},
+		{
+			name:        "IPv6 only spy node with different notation",
+			ipAddresses: "2001:db8::1",
+			want:        mrlResult{IsSpyNode: 1, SpyNodeIP: "2001:db8::1", MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		},
+		{
+			name:        "IPv6 matched by prefix",
+			ipAddresses: "2001:db8:1::abcd",
+			want:        mrlResult{IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		},
+		{
+			name:        "Dual stack, spy on IPv6 address",
+			ipAddresses: "1.1.1.1,2001:db8::1",
+			want:        mrlResult{IsSpyNode: 1, SpyNodeIP: "2001:db8::1", MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		},
+		{
+			name:        "Ban list must be enabled on every scanned address",
+			ipAddresses: "1.1.1.1,3.3.3.3",
+			want:        mrlResult{IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 0},
+		},
+		{
+			name:        "Address missing from scan is ignored",
+			ipAddresses: "1.1.1.1,9.9.9.9",
+			want:        mrlResult{IsSpyNode: 0, MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		},
+		{
+			name:        "IPv4-mapped IPv6 address",
+			ipAddresses: "::ffff:2.2.2.2",
+			want:        mrlResult{IsSpyNode: 1, SpyNodeIP: "2.2.2.2", MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		},
+		{
+			name:        "Not in scan is disabled",
+			ipAddresses: "9.9.9.9,2001:db9::1",
+			want:        mrlResult{IsSpyNode: 0, MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		},
+		{
+			name:        "Empty",
+			ipAddresses: "",
+			want:        mrlResult{IsSpyNode: 0, MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idx.evaluate(tt.ipAddresses); got != tt.want {
+				t.Errorf("ruckniumIndex.evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRuckniumIndex_inScan -v
+func TestRuckniumIndex_inScan(t *testing.T) {
+	idx := newRuckniumIndex([]RuckniumNodeData{
+		{ConnectedNodeIP: "1.1.1.1"},
+		{ConnectedNodeIP: "2001:db8:1::/48"},
+	})
+
+	tests := []struct {
+		ipAddresses string
+		want        bool
+	}{
+		{"1.1.1.1", true},
+		{"9.9.9.9,1.1.1.1", true},
+		{"2001:db8:1::7", true},
+		{"9.9.9.9,2001:db9::1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := idx.inScan(tt.ipAddresses); got != tt.want {
+			t.Errorf("ruckniumIndex.inScan(%q) = %v, want %v", tt.ipAddresses, got, tt.want)
+		}
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRuckniumTimeline -v
+func TestRuckniumTimeline(t *testing.T) {
+	dates := []string{"2026-01-04", "2026-01-03", "2026-01-02", "2026-01-01"}
+	rows := []RuckniumNodeData{
+		{Date: "2026-01-04", ConnectedNodeIP: "1.1.1.1", MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		{Date: "2026-01-03", ConnectedNodeIP: "1.1.1.1", MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		{Date: "2026-01-02", ConnectedNodeIP: "1.1.1.1", IsSpyNode: 1},
+		// 2026-01-01: node not in scan
+	}
+
+	got := ruckniumTimeline(dates, rows, "1.1.1.1")
+	want := []RuckniumHistory{
+		{Date: "2026-01-04", MRLBanListEnabled: 1, DNSBanListEnabled: 1, Changed: false},
+		{Date: "2026-01-03", MRLBanListEnabled: 1, DNSBanListEnabled: 1, Changed: true},
+		{Date: "2026-01-02", IsSpyNode: 1, SpyNodeIP: "1.1.1.1", Changed: true},
+		{Date: "2026-01-01", MRLBanListEnabled: 2, DNSBanListEnabled: 2, Changed: false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ruckniumTimeline() returned %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ruckniumTimeline()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRuckniumTimeline_prefix -v
+func TestRuckniumTimeline_prefix(t *testing.T) {
+	dates := []string{"2026-01-02", "2026-01-01"}
+	rows := []RuckniumNodeData{
+		{Date: "2026-01-02", ConnectedNodeIP: "2001:db8:ff::/64", IsSpyNode: 1},
+		{Date: "2026-01-02", ConnectedNodeIP: "192.0.2.10", MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		{Date: "2026-01-01", ConnectedNodeIP: "2001:db8:ff::/64", MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+	}
+
+	got := ruckniumTimeline(dates, rows, "192.0.2.10,2001:db8:ff::7")
+	want := []RuckniumHistory{
+		{Date: "2026-01-02", IsSpyNode: 1, SpyNodeIP: "2001:db8:ff::7", Changed: true},
+		{Date: "2026-01-01", MRLBanListEnabled: 1, DNSBanListEnabled: 1, Changed: false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ruckniumTimeline() returned %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ruckniumTimeline()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRuckniumFixture -v
+func TestRuckniumFixture(t *testing.T) {
+	srv := newFixtureServer(t)
+	ctx := context.Background()
+	f := fetcher.New(fetcher.WithClient(srv.Client()), fetcher.WithCache(fetcher.NewMemoryCache()))
+	url := srv.URL + "/rucknium_individual_node_data.json"
+
+	nodes, resp, err := fetchRuckniumNodeData(ctx, f, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// entry without scan date is ignored
+	if len(nodes) != 5 {
+		t.Fatalf("fetchRuckniumNodeData() returned %d nodes, want 5", len(nodes))
+	}
+
+	if err := f.Commit(ctx, resp); err != nil {
+		t.Fatal(err)
+	}
+	nodes, resp, err = fetchRuckniumNodeData(ctx, f, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.NotModified || nodes != nil {
+		t.Error("fetchRuckniumNodeData() of unchanged data must not return nodes")
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestRuckniumFixture_CheckMRLBan -v
+func TestRuckniumFixture_CheckMRLBan(t *testing.T) {
+	srv := newFixtureServer(t)
+	f := fetcher.New(fetcher.WithClient(srv.Client()), fetcher.WithCache(fetcher.NewMemoryCache()))
+	data, _, err := fetchRuckniumNodeData(context.Background(), f, srv.URL+"/rucknium_individual_node_data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := newRuckniumIndex(data)
+	dates := []string{"2026-01-15"}
+
+	tests := []struct {
+		ipAddresses string
+		want        mrlResult
+		wantHistory RuckniumHistory
+	}{
+		{
+			ipAddresses: "192.0.2.10",
+			want:        mrlResult{MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+			wantHistory: RuckniumHistory{Date: "2026-01-15", MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		},
+		{
+			ipAddresses: "192.0.2.20",
+			want:        mrlResult{MRLBanListEnabled: 1, DNSBanListEnabled: 0},
+			wantHistory: RuckniumHistory{Date: "2026-01-15", MRLBanListEnabled: 1, DNSBanListEnabled: 0},
+		},
+		{
+			ipAddresses: "198.51.100.7",
+			want:        mrlResult{IsSpyNode: 1, SpyNodeIP: "198.51.100.7"},
+			wantHistory: RuckniumHistory{Date: "2026-01-15", IsSpyNode: 1, SpyNodeIP: "198.51.100.7"},
+		},
+		{
+			ipAddresses: "192.0.2.10,2001:db8::10",
+			want:        mrlResult{MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+			wantHistory: RuckniumHistory{Date: "2026-01-15", MRLBanListEnabled: 1, DNSBanListEnabled: 1},
+		},
+		{
+			ipAddresses: "2001:db8:ff::7",
+			want:        mrlResult{IsSpyNode: 1, SpyNodeIP: "2001:db8:ff::7"},
+			wantHistory: RuckniumHistory{Date: "2026-01-15", IsSpyNode: 1, SpyNodeIP: "2001:db8:ff::7"},
+		},
+		{
+			// the fixture entry without scan date is not imported
+			ipAddresses: "203.0.113.1",
+			want:        mrlResult{MRLBanListEnabled: 0, DNSBanListEnabled: 0},
+			wantHistory: RuckniumHistory{Date: "2026-01-15", MRLBanListEnabled: 2, DNSBanListEnabled: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ipAddresses, func(t *testing.T) {
+			if got := idx.evaluate(tt.ipAddresses); got != tt.want {
+				t.Errorf("ruckniumIndex.evaluate() = %+v, want %+v", got, tt.want)
+			}
+			history := ruckniumTimeline(dates, data, tt.ipAddresses)
+			if len(history) != 1 || history[0] != tt.wantHistory {
+				t.Errorf("ruckniumTimeline() = %+v, want %+v", history, tt.wantHistory)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package monero
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ditatompel/xmr-remote-nodes/internal/config"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Schedule states of nodes, see SchedulePolicy
+const (
+	ScheduleNew      = "new"      // never probed
+	ScheduleOnline   = "online"   // stable online
+	ScheduleFlapping = "flapping" // both online and offline in the last statuses
+	ScheduleOffline  = "offline"  // stable offline
+	ScheduleDead     = "dead"     // offline for deadAfter consecutive probes
+)
+
+const (
+	// deadAfter is the number of consecutive failed probes after which the
+	// check interval of the node grows exponentially
+	deadAfter = 5
+	// jobLease postpones the next check of a node given to a prober, so it
+	// is not given to other probers while the report is pending
+	jobLease = 10 * time.Minute
+)
+
+// SchedulePolicy decides when a node is probed next. Nodes are given to
+// probers in next check order, new nodes have no next check and are probed
+// first.
+type SchedulePolicy struct {
+	// target check interval per network type
+	Clearnet time.Duration
+	Tor      time.Duration
+	I2P      time.Duration
+	// maximum check interval of dead nodes, the node minimum check interval
+	// takes precedence
+	Max time.Duration
+}
+
+// DefaultSchedulePolicy returns the schedule policy from the app config
+func DefaultSchedulePolicy() SchedulePolicy {
+	cfg := config.AppCfg()
+	seconds := func(v int) time.Duration { return time.Duration(v) * time.Second }
+	return SchedulePolicy{
+		Clearnet: seconds(cfg.CheckIntervalClearnet),
+		Tor:      seconds(cfg.CheckIntervalTor),
+		I2P:      seconds(cfg.CheckIntervalI2P),
+		Max:      seconds(cfg.CheckIntervalMax),
+	}
+}
+
+// ScheduleEntry is the schedule of a node
+type ScheduleEntry struct {
+	ID               uint           `json:"id" db:"id"`
+	Hostname         string         `json:"hostname" db:"hostname"`
+	Port             uint           `json:"port" db:"port"`
+	IsTor            bool           `json:"is_tor" db:"is_tor"`
+	IsI2P            bool           `json:"is_i2p" db:"is_i2p"`
+	IsAvailable      bool           `json:"is_available" db:"is_available"`
+	LastChecked      int64          `json:"last_checked" db:"last_checked"`
+	NextCheck        int64          `json:"next_check" db:"next_check"`
+	MinCheckInterval uint           `json:"min_check_interval" db:"min_check_interval"` // in seconds, 0 = policy default
+	CheckFailures    uint           `json:"check_failures" db:"check_failures"`         // consecutive failed probes
+	LastCheckStatus  types.JSONText `json:"last_check_statuses" db:"last_check_status"`
+
+	State    string        `json:"state" db:"-"`
+	Interval time.Duration `json:"interval" db:"-"` // check interval after the next probe with the same result
+}
+
+// State returns the schedule state of the node
+func (p SchedulePolicy) State(e ScheduleEntry) string {
+	if e.NextCheck == 0 {
+		return ScheduleNew
+	}
+	if e.CheckFailures >= deadAfter {
+		return ScheduleDead
+	}
+
+	var statuses []int
+	_ = json.Unmarshal(e.LastCheckStatus, &statuses)
+	var online, offline bool
+	for _, s := range statuses {
+		switch s {
+		case 0:
+			offline = true
+		case 1:
+			online = true
+		}
+	}
+	switch {
+	case online && offline:
+		return ScheduleFlapping
+	case e.CheckFailures > 0:
+		return ScheduleOffline
+	}
+	return ScheduleOnline
+}
+
+// Interval returns the check interval of the node after its latest probe
+func (p SchedulePolicy) Interval(e ScheduleEntry) time.Duration {
+	interval := p.Clearnet
+	switch {
+	case e.IsTor:
+		interval = p.Tor
+	case e.IsI2P:
+		interval = p.I2P
+	}
+
+	switch p.State(e) {
+	case ScheduleFlapping:
+		interval /= 4
+	case ScheduleDead:
+		for i := deadAfter; i <= int(e.CheckFailures) && interval < p.Max; i++ {
+			interval *= 2
+		}
+	}
+	if p.Max > 0 && interval > p.Max {
+		interval = p.Max
+	}
+	if minInterval := time.Duration(e.MinCheckInterval) * time.Second; interval < minInterval {
+		interval = minInterval
+	}
+	return interval
+}
+
+// Schedule returns non-archived nodes in the order they are given to
+// probers, at most limit nodes
+func (r *moneroRepo) Schedule(policy SchedulePolicy, limit int) ([]ScheduleEntry, error) {
+	entries := []ScheduleEntry{}
+	err := r.db.Select(&entries, `
+		SELECT
+			id,
+			hostname,
+			port,
+			is_tor,
+			is_i2p,
+			is_available,
+			last_checked,
+			next_check,
+			min_check_interval,
+			check_failures,
+			last_check_status
+		FROM
+			tbl_node
+		WHERE
+			is_archived = ?
+		ORDER BY
+			next_check ASC,
+			last_checked ASC
+		LIMIT ?`, 0, limit)
+	if err != nil {
+		return entries, err
+	}
+	for i := range entries {
+		entries[i].State = policy.State(entries[i])
+		entries[i].Interval = policy.Interval(entries[i])
+	}
+	return entries, nil
+}
+
+// scheduleNext sets the next check of the node after its probe report is
+// processed
+func (r *moneroRepo) scheduleNext(policy SchedulePolicy, nodeID uint, checkedAt time.Time) error {
+	var e ScheduleEntry
+	err := r.db.Get(&e, `
+		SELECT
+			id,
+			is_tor,
+			is_i2p,
+			next_check,
+			min_check_interval,
+			check_failures,
+			last_check_status
+		FROM
+			tbl_node
+		WHERE
+			id = ?`, nodeID)
+	if err != nil {
+		return err
+	}
+	// the next check of a probed node is never "new"
+	e.NextCheck = checkedAt.Unix()
+
+	_, err = r.db.Exec(`
+		UPDATE tbl_node
+		SET next_check = ?
+		WHERE id = ?`, checkedAt.Add(policy.Interval(e)).Unix(), nodeID)
+	return err
+}
+
+// SetMinCheckInterval sets the node minimum check interval in seconds, 0
+// uses the schedule policy intervals
+func (r *moneroRepo) SetMinCheckInterval(id uint, seconds uint) error {
+	if seconds > uint((30 * 24 * time.Hour).Seconds()) {
+		return errors.New("minimum check interval must not exceed 30 days")
+	}
+	res, err := r.db.Exec(`
+		UPDATE tbl_node
+		SET min_check_interval = ?
+		WHERE id = ?`, seconds, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("node %d not found or interval unchanged", id)
+	}
+	return nil
+}
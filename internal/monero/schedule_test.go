@@ -0,0 +1,84 @@
+package monero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestSchedulePolicy -v
+func TestSchedulePolicy(t *testing.T) {
+	policy := SchedulePolicy{
+		Clearnet: time.Hour,
+		Tor:      2 * time.Hour,
+		I2P:      3 * time.Hour,
+		Max:      24 * time.Hour,
+	}
+	tests := []struct {
+		name         string
+		entry        ScheduleEntry
+		wantState    string
+		wantInterval time.Duration
+	}{
+		{
+			name:         "new node",
+			entry:        ScheduleEntry{},
+			wantState:    ScheduleNew,
+			wantInterval: time.Hour,
+		},
+		{
+			name:         "online clearnet node",
+			entry:        ScheduleEntry{NextCheck: 1, LastCheckStatus: types.JSONText("[1,1,1,1,1]")},
+			wantState:    ScheduleOnline,
+			wantInterval: time.Hour,
+		},
+		{
+			name:         "online tor node",
+			entry:        ScheduleEntry{NextCheck: 1, IsTor: true, LastCheckStatus: types.JSONText("[2,2,1,1,1]")},
+			wantState:    ScheduleOnline,
+			wantInterval: 2 * time.Hour,
+		},
+		{
+			name:         "flapping i2p node",
+			entry:        ScheduleEntry{NextCheck: 1, IsI2P: true, CheckFailures: 1, LastCheckStatus: types.JSONText("[1,0,1,1,0]")},
+			wantState:    ScheduleFlapping,
+			wantInterval: 45 * time.Minute,
+		},
+		{
+			name:         "offline node",
+			entry:        ScheduleEntry{NextCheck: 1, CheckFailures: 3, LastCheckStatus: types.JSONText("[2,2,0,0,0]")},
+			wantState:    ScheduleOffline,
+			wantInterval: time.Hour,
+		},
+		{
+			name:         "dead node",
+			entry:        ScheduleEntry{NextCheck: 1, CheckFailures: 7, LastCheckStatus: types.JSONText("[0,0,0,0,0]")},
+			wantState:    ScheduleDead,
+			wantInterval: 8 * time.Hour,
+		},
+		{
+			name:         "long dead node is capped",
+			entry:        ScheduleEntry{NextCheck: 1, CheckFailures: 500, LastCheckStatus: types.JSONText("[0,0,0,0,0]")},
+			wantState:    ScheduleDead,
+			wantInterval: 24 * time.Hour,
+		},
+		{
+			name:         "node minimum interval",
+			entry:        ScheduleEntry{NextCheck: 1, MinCheckInterval: 7200, LastCheckStatus: types.JSONText("[1,0,1,0,1]")},
+			wantState:    ScheduleFlapping,
+			wantInterval: 2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.State(tt.entry); got != tt.wantState {
+				t.Errorf("SchedulePolicy.State() = %v, want %v", got, tt.wantState)
+			}
+			if got := policy.Interval(tt.entry); got != tt.wantInterval {
+				t.Errorf("SchedulePolicy.Interval() = %v, want %v", got, tt.wantInterval)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package monero
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TLS filter values of QueryNodes
+const (
+	TLSFilterValid    = "valid"
+	TLSFilterInvalid  = "invalid"
+	TLSFilterExpiring = "expiring" // valid, but expires within QueryNodes.TLSExpiryDays
+)
+
+// TLSFilters is a list of QueryNodes TLS filter values
+var TLSFilters = []string{TLSFilterValid, TLSFilterInvalid, TLSFilterExpiring}
+
+// TLSInfo represents TLS certificate details of HTTPS node
+type TLSInfo struct {
+	NodeID           uint   `json:"node_id,omitempty" db:"node_id"`
+	Subject          string `json:"subject" db:"subject"`
+	Issuer           string `json:"issuer" db:"issuer"`
+	DNSNames         string `json:"dns_names" db:"dns_names"` // comma separated DNS and IP SANs
+	NotBefore        int64  `json:"not_before" db:"not_before"`
+	NotAfter         int64  `json:"not_after" db:"not_after"`
+	IsValid          bool   `json:"is_valid" db:"is_valid"` // chain, validity period and hostname are verified
+	IsSelfSigned     bool   `json:"is_self_signed" db:"is_self_signed"`
+	HostnameMismatch bool   `json:"hostname_mismatch" db:"hostname_mismatch"`
+	TLSVersion       string `json:"tls_version" db:"tls_version"`
+	CipherSuite      string `json:"cipher_suite" db:"cipher_suite"`
+	LastChecked      int64  `json:"last_checked" db:"last_checked"`
+}
+
+// InspectTLS returns certificate details of the TLS connection state. The
+// certificate chain is verified against roots, or the system roots if nil.
+func InspectTLS(cs tls.ConnectionState, roots *x509.CertPool) *TLSInfo {
+	info := &TLSInfo{
+		TLSVersion:  tls.VersionName(cs.Version),
+		CipherSuite: tls.CipherSuiteName(cs.CipherSuite),
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return info
+	}
+
+	leaf := cs.PeerCertificates[0]
+	sans := append([]string{}, leaf.DNSNames...)
+	for _, ip := range leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	info.Subject = leaf.Subject.String()
+	info.Issuer = leaf.Issuer.String()
+	info.DNSNames = strings.Join(sans, ",")
+	info.NotBefore = leaf.NotBefore.Unix()
+	info.NotAfter = leaf.NotAfter.Unix()
+	info.IsSelfSigned = bytes.Equal(leaf.RawIssuer, leaf.RawSubject) && leaf.CheckSignatureFrom(leaf) == nil
+	info.HostnameMismatch = cs.ServerName != "" && leaf.VerifyHostname(cs.ServerName) != nil
+
+	opts := x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(opts)
+	info.IsValid = err == nil
+
+	return info
+}
+
+// DaysLeft returns the number of days until the certificate expires,
+// negative if already expired
+func (t TLSInfo) DaysLeft(now time.Time) int {
+	return int(time.Unix(t.NotAfter, 0).Sub(now).Hours() / 24)
+}
+
+// ExpiresWithin reports whether the certificate expires within the given
+// number of days
+func (t TLSInfo) ExpiresWithin(days int, now time.Time) bool {
+	return t.NotAfter > 0 && t.NotAfter < now.AddDate(0, 0, days).Unix()
+}
+
+// NodeTLS returns the latest TLS certificate details of the node, nil if
+// no prober reported it yet
+func (r *moneroRepo) NodeTLS(nodeID uint) (*TLSInfo, error) {
+	var info TLSInfo
+	err := r.db.Get(&info, `SELECT * FROM tbl_node_tls WHERE node_id = ?`, nodeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// updateTLS stores TLS certificate details reported by the prober
+func (r *moneroRepo) updateTLS(nodeID uint, info TLSInfo, checkedAt time.Time) error {
+	_, err := r.db.Exec(`
+		INSERT INTO tbl_node_tls (
+			node_id,
+			subject,
+			issuer,
+			dns_names,
+			not_before,
+			not_after,
+			is_valid,
+			is_self_signed,
+			hostname_mismatch,
+			tls_version,
+			cipher_suite,
+			last_checked
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			subject = VALUES(subject),
+			issuer = VALUES(issuer),
+			dns_names = VALUES(dns_names),
+			not_before = VALUES(not_before),
+			not_after = VALUES(not_after),
+			is_valid = VALUES(is_valid),
+			is_self_signed = VALUES(is_self_signed),
+			hostname_mismatch = VALUES(hostname_mismatch),
+			tls_version = VALUES(tls_version),
+			cipher_suite = VALUES(cipher_suite),
+			last_checked = VALUES(last_checked)`,
+		nodeID,
+		info.Subject,
+		info.Issuer,
+		info.DNSNames,
+		info.NotBefore,
+		info.NotAfter,
+		info.IsValid,
+		info.IsSelfSigned,
+		info.HostnameMismatch,
+		info.TLSVersion,
+		info.CipherSuite,
+		checkedAt.Unix())
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`UPDATE tbl_node SET tls_valid = ? WHERE id = ?`, info.IsValid, nodeID)
+	return err
+}
@@ -0,0 +1,115 @@
+package monero
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func testCertificate(t *testing.T, notAfter time.Time) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "node.example.com"},
+		DNSNames:              []string{"node.example.com"},
+		IPAddresses:           []net.IP{net.ParseIP("192.0.2.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestInspectTLS -v
+func TestInspectTLS(t *testing.T) {
+	cert := testCertificate(t, time.Now().AddDate(0, 0, 30))
+	trusted := x509.NewCertPool()
+	trusted.AddCert(cert)
+
+	tests := []struct {
+		name         string
+		serverName   string
+		roots        *x509.CertPool
+		wantValid    bool
+		wantMismatch bool
+	}{
+		{"trusted", "node.example.com", trusted, true, false},
+		{"untrusted self-signed", "node.example.com", x509.NewCertPool(), false, false},
+		{"hostname mismatch", "other.example.com", trusted, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := InspectTLS(tls.ConnectionState{
+				Version:          tls.VersionTLS13,
+				CipherSuite:      tls.TLS_AES_128_GCM_SHA256,
+				ServerName:       tt.serverName,
+				PeerCertificates: []*x509.Certificate{cert},
+			}, tt.roots)
+			if info.IsValid != tt.wantValid {
+				t.Errorf("IsValid = %v, want %v", info.IsValid, tt.wantValid)
+			}
+			if info.HostnameMismatch != tt.wantMismatch {
+				t.Errorf("HostnameMismatch = %v, want %v", info.HostnameMismatch, tt.wantMismatch)
+			}
+			if !info.IsSelfSigned {
+				t.Error("IsSelfSigned = false, want true")
+			}
+			if info.DNSNames != "node.example.com,192.0.2.1" {
+				t.Errorf("DNSNames = %q", info.DNSNames)
+			}
+			if info.TLSVersion != "TLS 1.3" || info.CipherSuite != "TLS_AES_128_GCM_SHA256" {
+				t.Errorf("TLSVersion, CipherSuite = %q, %q", info.TLSVersion, info.CipherSuite)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestTLSInfo_ExpiresWithin -v
+func TestTLSInfo_ExpiresWithin(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		notAfter time.Time
+		want     bool
+		wantDays int
+	}{
+		{"far", now.AddDate(0, 0, 60), false, 60},
+		{"soon", now.AddDate(0, 0, 7), true, 7},
+		{"expired", now.AddDate(0, 0, -2), true, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := TLSInfo{NotAfter: tt.notAfter.Unix()}
+			if got := info.ExpiresWithin(14, now); got != tt.want {
+				t.Errorf("ExpiresWithin() = %v, want %v", got, tt.want)
+			}
+			if got := info.DaysLeft(now); got != tt.wantDays {
+				t.Errorf("DaysLeft() = %d, want %d", got, tt.wantDays)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package monero
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node version statuses, stored in `tbl_node.version_status`
+const (
+	VersionCurrent     = "current"     // latest known release or newer
+	VersionOutdated    = "outdated"    // older than the latest known release
+	VersionUnsupported = "unsupported" // older than the current hard fork minimum
+	VersionVulnerable  = "vulnerable"  // known vulnerable release
+	VersionUnknown     = "unknown"     // version is not reported or can't be parsed
+)
+
+// VersionStatuses is the list of valid version statuses
+var VersionStatuses = []string{VersionCurrent, VersionOutdated, VersionUnsupported, VersionVulnerable, VersionUnknown}
+
+// monerodVersionRe matches monerod version string reported by `get_info`,
+// eg. "0.18.3.4-release" or "0.18.3.3-4b11b1e"
+var monerodVersionRe = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// MoneroVersion is parsed monerod version number
+type MoneroVersion [4]int
+
+// ParseMoneroVersion parses monerod version string
+func ParseMoneroVersion(s string) (MoneroVersion, bool) {
+	var v MoneroVersion
+	m := monerodVersionRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return v, false
+	}
+	for i := range v {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// Compare returns -1, 0 or 1 if v is older, equal or newer than other
+func (v MoneroVersion) Compare(other MoneroVersion) int {
+	for i := range v {
+		if v[i] != other[i] {
+			if v[i] < other[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func (v MoneroVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v[0], v[1], v[2], v[3])
+}
+
+// MoneroRelease represents a known monerod release
+type MoneroRelease struct {
+	Version       string `json:"version" db:"version"`
+	ReleaseDate   int64  `json:"release_date" db:"release_date"`
+	IsForkMinimum bool   `json:"is_fork_minimum" db:"is_fork_minimum"`
+	IsVulnerable  bool   `json:"is_vulnerable" db:"is_vulnerable"`
+	Note          string `json:"note" db:"note"`
+}
+
+// Releases returns known monerod releases, newest first
+func (r *moneroRepo) Releases() ([]MoneroRelease, error) {
+	releases := []MoneroRelease{}
+	err := r.db.Select(&releases, `
+		SELECT
+			version,
+			release_date,
+			is_fork_minimum,
+			is_vulnerable,
+			note
+		FROM
+			tbl_monero_release`)
+	if err != nil {
+		return releases, err
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		vi, _ := ParseMoneroVersion(releases[i].Version)
+		vj, _ := ParseMoneroVersion(releases[j].Version)
+		return vi.Compare(vj) > 0
+	})
+	return releases, nil
+}
+
+// SetRelease adds or updates known monerod release, then refreshes version
+// status of all nodes
+func (r *moneroRepo) SetRelease(release MoneroRelease) error {
+	v, ok := ParseMoneroVersion(release.Version)
+	if !ok {
+		return fmt.Errorf("invalid monerod version %q", release.Version)
+	}
+	release.Version = v.String()
+	_, err := r.db.Exec(`
+		INSERT INTO tbl_monero_release (
+			version,
+			release_date,
+			is_fork_minimum,
+			is_vulnerable,
+			note
+		) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			release_date = VALUES(release_date),
+			is_fork_minimum = VALUES(is_fork_minimum),
+			is_vulnerable = VALUES(is_vulnerable),
+			note = VALUES(note)`,
+		release.Version,
+		release.ReleaseDate,
+		release.IsForkMinimum,
+		release.IsVulnerable,
+		release.Note)
+	if err != nil {
+		return err
+	}
+	_, err = r.RefreshVersionStatus()
+	return err
+}
+
+// DeleteRelease removes known monerod release, then refreshes version status
+// of all nodes
+func (r *moneroRepo) DeleteRelease(version string) error {
+	v, ok := ParseMoneroVersion(version)
+	if !ok {
+		return fmt.Errorf("invalid monerod version %q", version)
+	}
+	res, err := r.db.Exec(`DELETE FROM tbl_monero_release WHERE version = ?`, v.String())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("release not found")
+	}
+	_, err = r.RefreshVersionStatus()
+	return err
+}
+
+// RefreshVersionStatus recalculates version status of all nodes, returns the
+// number of updated nodes
+func (r *moneroRepo) RefreshVersionStatus() (int, error) {
+	releases, err := r.Releases()
+	if err != nil {
+		return 0, err
+	}
+	var nodes []struct {
+		ID            uint   `db:"id"`
+		Version       string `db:"version"`
+		VersionStatus string `db:"version_status"`
+	}
+	if err := r.db.Select(&nodes, `SELECT id, version, version_status FROM tbl_node`); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, node := range nodes {
+		status := VersionStatus(node.Version, releases)
+		if status == node.VersionStatus {
+			continue
+		}
+		if _, err := r.db.Exec(`UPDATE tbl_node SET version_status = ? WHERE id = ?`, status, node.ID); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// nodeVersionStatus returns version status of the reported version, releases
+// are loaded from the database
+func (r *moneroRepo) nodeVersionStatus(version string) string {
+	releases, err := r.Releases()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("[MONERO] Failed to get releases: %s", err))
+		return VersionUnknown
+	}
+	return VersionStatus(version, releases)
+}
+
+// VersionStatus classifies monerod version against known releases. Versions
+// newer than the latest known release are considered current, so a missing
+// release entry never flags up to date nodes.
+func VersionStatus(version string, releases []MoneroRelease) string {
+	v, ok := ParseMoneroVersion(version)
+	if !ok {
+		return VersionUnknown
+	}
+
+	var latest, forkMin MoneroVersion
+	for _, release := range releases {
+		rv, ok := ParseMoneroVersion(release.Version)
+		if !ok {
+			continue
+		}
+		if release.IsVulnerable && rv.Compare(v) == 0 {
+			return VersionVulnerable
+		}
+		if rv.Compare(latest) > 0 {
+			latest = rv
+		}
+		if release.IsForkMinimum && rv.Compare(forkMin) > 0 {
+			forkMin = rv
+		}
+	}
+
+	switch {
+	case v.Compare(forkMin) < 0:
+		return VersionUnsupported
+	case v.Compare(latest) < 0:
+		return VersionOutdated
+	default:
+		return VersionCurrent
+	}
+}
+
+// VersionAdoption is the number of online nodes running a monerod version
+type VersionAdoption struct {
+	Nettype string  `json:"nettype"`
+	Version string  `json:"version"` // normalized version, empty if unknown
+	Status  string  `json:"version_status"`
+	Nodes   int     `json:"nodes"`
+	Percent float64 `json:"percent"` // percentage of online nodes of the nettype
+}
+
+// VersionAdoption returns online node count by monerod version per nettype,
+// ordered by nettype and newest version first
+func (r *moneroRepo) VersionAdoption(nettype string) ([]VersionAdoption, error) {
+	where := `is_available = 1 AND is_archived = 0 AND nettype != ''`
+	var args []interface{}
+	if nettype != "" {
+		where += ` AND nettype = ?`
+		args = append(args, nettype)
+	}
+	var rows []struct {
+		Nettype string `db:"nettype"`
+		Version string `db:"version"`
+		Nodes   int    `db:"nodes"`
+	}
+	err := r.db.Select(&rows, fmt.Sprintf(`
+		SELECT
+			nettype,
+			version,
+			COUNT(id) AS nodes
+		FROM
+			tbl_node
+		WHERE
+			%s
+		GROUP BY
+			nettype,
+			version`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	releases, err := r.Releases()
+	if err != nil {
+		return nil, err
+	}
+
+	adoption := []VersionAdoption{}
+	index := make(map[[2]string]int)
+	totals := make(map[string]int)
+	for _, row := range rows {
+		// version strings differ by their suffix, eg. "-release" and commit
+		// hash, group them by version number
+		version := ""
+		if v, ok := ParseMoneroVersion(row.Version); ok {
+			version = v.String()
+		}
+		key := [2]string{row.Nettype, version}
+		i, ok := index[key]
+		if !ok {
+			i = len(adoption)
+			index[key] = i
+			adoption = append(adoption, VersionAdoption{
+				Nettype: row.Nettype,
+				Version: version,
+				Status:  VersionStatus(version, releases),
+			})
+		}
+		adoption[i].Nodes += row.Nodes
+		totals[row.Nettype] += row.Nodes
+	}
+	for i := range adoption {
+		adoption[i].Percent = math.Round(float64(adoption[i].Nodes)/float64(totals[adoption[i].Nettype])*10000) / 100
+	}
+
+	sort.Slice(adoption, func(i, j int) bool {
+		if adoption[i].Nettype != adoption[j].Nettype {
+			return nettypeOrder(adoption[i].Nettype) < nettypeOrder(adoption[j].Nettype)
+		}
+		vi, _ := ParseMoneroVersion(adoption[i].Version)
+		vj, _ := ParseMoneroVersion(adoption[j].Version)
+		return vi.Compare(vj) > 0
+	})
+	return adoption, nil
+}
@@ -0,0 +1,65 @@
+package monero
+
+import (
+	"testing"
+)
+
+// Single test:
+// go test -race ./internal/monero -run=TestParseMoneroVersion -v
+func TestParseMoneroVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		wantOK  bool
+	}{
+		{"0.18.3.4-release", "0.18.3.4", true},
+		{"0.18.3.3-4b11b1e", "0.18.3.3", true},
+		{"v0.18.4.0", "0.18.4.0", true},
+		{"0.17.3", "0.17.3.0", true},
+		{"", "0.0.0.0", false},
+		{"release-v0.18", "0.0.0.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, ok := ParseMoneroVersion(tt.version)
+			if ok != tt.wantOK || v.String() != tt.want {
+				t.Errorf("ParseMoneroVersion(%q) = %s, %t, want %s, %t", tt.version, v, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/monero -run=TestVersionStatus -v
+func TestVersionStatus(t *testing.T) {
+	releases := []MoneroRelease{
+		{Version: "0.18.3.4"},
+		{Version: "0.18.0.0", IsForkMinimum: true},
+		{Version: "0.18.3.1", IsVulnerable: true},
+		{Version: "0.17.0.0", IsForkMinimum: true},
+	}
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"0.18.3.4-release", VersionCurrent},
+		{"0.18.4.0-release", VersionCurrent},
+		{"0.18.3.3-release", VersionOutdated},
+		{"0.18.3.1-release", VersionVulnerable},
+		{"0.17.3.2-release", VersionUnsupported},
+		{"", VersionUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := VersionStatus(tt.version, releases); got != tt.want {
+				t.Errorf("VersionStatus(%q) = %s, want %s", tt.version, got, tt.want)
+			}
+		})
+	}
+
+	// without known releases, every parseable version is current
+	if got := VersionStatus("0.18.3.4", nil); got != VersionCurrent {
+		t.Errorf("VersionStatus without releases = %s, want %s", got, VersionCurrent)
+	}
+}
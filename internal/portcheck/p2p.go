@@ -0,0 +1,118 @@
+package portcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Levin protocol constants, see monero `contrib/epee/include/net/levin_base.h`
+const (
+	levinSignature       uint64 = 0x0101010101012101
+	levinHeaderSize             = 33
+	levinProtocolVersion uint32 = 1
+	levinPacketRequest   uint32 = 1
+	levinPacketResponse  uint32 = 2
+	levinMaxResponseSize        = 64 * 1024
+
+	// COMMAND_PING is allowed before handshake, monerod uses it to check
+	// whether the announced P2P port of a peer is reachable
+	commandPing uint32 = 1003
+)
+
+// emptyPortableStorage is epee portable storage with an empty root section:
+// signature A, signature B, format version and zero entries count
+var emptyPortableStorage = []byte{0x01, 0x11, 0x01, 0x01, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00}
+
+type levinHeader struct {
+	Signature      uint64
+	BodySize       uint64
+	ExpectResponse bool
+	Command        uint32
+	ReturnCode     int32
+	Flags          uint32
+	Version        uint32
+}
+
+func levinRequest(command uint32, body []byte) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, levinHeader{
+		Signature:      levinSignature,
+		BodySize:       uint64(len(body)),
+		ExpectResponse: true,
+		Command:        command,
+		Flags:          levinPacketRequest,
+		Version:        levinProtocolVersion,
+	})
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// P2P tests that addr is reachable Monero P2P port. It sends levin ping
+// request and expects "OK" status response.
+func P2P(ctx context.Context, dialFn DialFunc, addr string) error {
+	conn, err := dial(ctx, dialFn, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(levinRequest(commandPing, emptyPortableStorage)); err != nil {
+		return err
+	}
+
+	for {
+		var h levinHeader
+		if err := binary.Read(conn, binary.LittleEndian, &h); err != nil {
+			return fmt.Errorf("read levin header: %w", err)
+		}
+		if h.Signature != levinSignature {
+			return fmt.Errorf("%w: not a levin packet", ErrUnexpectedResponse)
+		}
+		if h.BodySize > levinMaxResponseSize {
+			return fmt.Errorf("%w: levin packet too large", ErrUnexpectedResponse)
+		}
+		body := make([]byte, h.BodySize)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return fmt.Errorf("read levin body: %w", err)
+		}
+		// skip notifications the node may send before the response
+		if h.Flags&levinPacketResponse == 0 || h.Command != commandPing {
+			continue
+		}
+		if h.ReturnCode < 0 {
+			return fmt.Errorf("%w: ping return code %d", ErrUnexpectedResponse, h.ReturnCode)
+		}
+		if status := pingStatus(body); status != "OK" {
+			return fmt.Errorf("%w: ping status %q", ErrUnexpectedResponse, status)
+		}
+		return nil
+	}
+}
+
+// pingStatus returns the "status" string of ping response portable storage
+// body. The response is a flat section, so the value directly follows the
+// key name.
+func pingStatus(body []byte) string {
+	const typeString = 0x0a
+	key := append([]byte{byte(len("status"))}, "status"...)
+	i := bytes.Index(body, key)
+	if i < 0 {
+		return ""
+	}
+	rest := body[i+len(key):]
+	if len(rest) < 2 || rest[0] != typeString {
+		return ""
+	}
+	// varint size, the low 2 bits are the size mark (1 byte for short strings)
+	if rest[1]&0x03 != 0 {
+		return ""
+	}
+	n := int(rest[1] >> 2)
+	if len(rest) < 2+n {
+		return ""
+	}
+	return string(rest[2 : 2+n])
+}
@@ -0,0 +1,44 @@
+// Package portcheck tests whether Monero node ZMQ pub and P2P ports are
+// reachable and speak the expected protocol.
+package portcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// DefaultTimeout is used when the context has no deadline
+const DefaultTimeout = 15 * time.Second
+
+// DialFunc connects to the address, eg. net.Dialer.DialContext or SOCKS5
+// proxy dialer
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// ErrUnexpectedResponse is returned when the port is open but doesn't speak
+// the expected protocol
+var ErrUnexpectedResponse = errors.New("unexpected response")
+
+// dial connects to addr and applies the context deadline to the connection
+func dial(ctx context.Context, dialFn DialFunc, addr string) (net.Conn, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	if dialFn == nil {
+		var d net.Dialer
+		dialFn = d.DialContext
+	}
+	conn, err := dialFn(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,139 @@
+package portcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve accepts a single connection on a random local port and handles it
+// with fn
+func serve(t *testing.T, fn func(conn net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fn(conn)
+	}()
+	return ln.Addr().String()
+}
+
+func fakeZMQ(socketType string) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		buf := make([]byte, zmtpGreetingSize)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		greeting := zmtpGreeting()
+		greeting[32] = 1 // as-server
+		conn.Write(greeting)
+		if _, err := readZMTPReady(conn); err != nil {
+			return
+		}
+		conn.Write(zmtpReady(socketType))
+	}
+}
+
+func fakeP2P(status string) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		var h levinHeader
+		if err := binary.Read(conn, binary.LittleEndian, &h); err != nil {
+			return
+		}
+		io.CopyN(io.Discard, conn, int64(h.BodySize))
+
+		var body bytes.Buffer
+		body.Write(emptyPortableStorage[:9])
+		body.WriteByte(2 << 2) // 2 entries
+		body.WriteByte(byte(len("status")))
+		body.WriteString("status")
+		body.WriteByte(0x0a)
+		body.WriteByte(byte(len(status) << 2))
+		body.WriteString(status)
+		body.WriteByte(byte(len("peer_id")))
+		body.WriteString("peer_id")
+		body.WriteByte(0x05)
+		binary.Write(&body, binary.LittleEndian, uint64(42))
+
+		binary.Write(conn, binary.LittleEndian, levinHeader{
+			Signature:  levinSignature,
+			BodySize:   uint64(body.Len()),
+			Command:    commandPing,
+			ReturnCode: 1,
+			Flags:      levinPacketResponse,
+			Version:    levinProtocolVersion,
+		})
+		conn.Write(body.Bytes())
+	}
+}
+
+func notMonero(conn net.Conn) {
+	conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n" + string(make([]byte, 64))))
+}
+
+// Single test:
+// go test -race ./internal/portcheck -run=TestZMQ -v
+func TestZMQ(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(conn net.Conn)
+		wantErr bool
+	}{
+		{"PUB socket", fakeZMQ("PUB"), false},
+		{"REP socket", fakeZMQ("REP"), true},
+		{"not ZMQ", notMonero, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := ZMQ(ctx, nil, serve(t, tt.handler))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ZMQ() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnexpectedResponse) {
+				t.Errorf("ZMQ() error = %v, want ErrUnexpectedResponse", err)
+			}
+		})
+	}
+}
+
+// Single test:
+// go test -race ./internal/portcheck -run=TestP2P -v
+func TestP2P(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(conn net.Conn)
+		wantErr bool
+	}{
+		{"ping OK", fakeP2P("OK"), false},
+		{"ping failed", fakeP2P("FAIL"), true},
+		{"not levin", notMonero, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := P2P(ctx, nil, serve(t, tt.handler))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("P2P() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnexpectedResponse) {
+				t.Errorf("P2P() error = %v, want ErrUnexpectedResponse", err)
+			}
+		})
+	}
+}
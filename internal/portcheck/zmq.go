@@ -0,0 +1,137 @@
+package portcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ZMTP 3.0 (https://rfc.zeromq.org/spec/23/) greeting is 64 bytes:
+// signature (10), version (2), mechanism (20), as-server (1), filler (31)
+const zmtpGreetingSize = 64
+
+// zmtpGreeting returns NULL security mechanism client greeting
+func zmtpGreeting() []byte {
+	g := make([]byte, zmtpGreetingSize)
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10] = 3 // version major
+	g[11] = 0 // version minor
+	copy(g[12:], "NULL")
+	return g
+}
+
+// zmtpReady returns READY command announcing the socket type
+func zmtpReady(socketType string) []byte {
+	var body bytes.Buffer
+	body.WriteByte(5)
+	body.WriteString("READY")
+	body.WriteByte(byte(len("Socket-Type")))
+	body.WriteString("Socket-Type")
+	_ = binary.Write(&body, binary.BigEndian, uint32(len(socketType)))
+	body.WriteString(socketType)
+
+	// short command frame, body is always less than 256 bytes
+	return append([]byte{0x04, byte(body.Len())}, body.Bytes()...)
+}
+
+// ZMQ tests that addr is ZMQ publisher (monerod `--zmq-pub`). It completes
+// ZMTP 3.0 handshake as SUB socket and checks that the peer is PUB socket.
+func ZMQ(ctx context.Context, dialFn DialFunc, addr string) error {
+	conn, err := dial(ctx, dialFn, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(zmtpGreeting()); err != nil {
+		return err
+	}
+	greeting := make([]byte, zmtpGreetingSize)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return fmt.Errorf("read ZMTP greeting: %w", err)
+	}
+	if err := checkZMTPGreeting(greeting); err != nil {
+		return err
+	}
+
+	if _, err := conn.Write(zmtpReady("SUB")); err != nil {
+		return err
+	}
+	socketType, err := readZMTPReady(conn)
+	if err != nil {
+		return err
+	}
+	if socketType != "PUB" && socketType != "XPUB" {
+		return fmt.Errorf("%w: ZMQ socket type is %s, not PUB", ErrUnexpectedResponse, socketType)
+	}
+	return nil
+}
+
+func checkZMTPGreeting(g []byte) error {
+	if len(g) != zmtpGreetingSize || g[0] != 0xff || g[9] != 0x7f {
+		return fmt.Errorf("%w: not a ZMTP greeting", ErrUnexpectedResponse)
+	}
+	if g[10] < 3 {
+		return fmt.Errorf("%w: unsupported ZMTP version %d.%d", ErrUnexpectedResponse, g[10], g[11])
+	}
+	if mechanism := string(bytes.TrimRight(g[12:32], "\x00")); mechanism != "NULL" {
+		return fmt.Errorf("%w: unsupported ZMTP security mechanism %q", ErrUnexpectedResponse, mechanism)
+	}
+	return nil
+}
+
+// readZMTPReady reads peer READY command and returns its socket type
+func readZMTPReady(r io.Reader) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", fmt.Errorf("read ZMTP command: %w", err)
+	}
+	flags := header[0]
+	if flags&0x04 == 0 {
+		return "", fmt.Errorf("%w: expected ZMTP command frame", ErrUnexpectedResponse)
+	}
+	size := uint64(header[1])
+	if flags&0x02 != 0 {
+		// long frame, 8 bytes size of which the first byte is already read
+		rest := make([]byte, 7)
+		if _, err := io.ReadFull(r, rest); err != nil {
+			return "", err
+		}
+		size = binary.BigEndian.Uint64(append([]byte{header[1]}, rest...))
+	}
+	if size > 4096 {
+		return "", fmt.Errorf("%w: ZMTP command too large", ErrUnexpectedResponse)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return "", err
+	}
+	return parseZMTPReady(body)
+}
+
+func parseZMTPReady(body []byte) (string, error) {
+	if len(body) < 6 || body[0] != 5 || string(body[1:6]) != "READY" {
+		return "", fmt.Errorf("%w: expected ZMTP READY command", ErrUnexpectedResponse)
+	}
+	props := body[6:]
+	for len(props) > 0 {
+		nameLen := int(props[0])
+		if len(props) < 1+nameLen+4 {
+			break
+		}
+		name := string(props[1 : 1+nameLen])
+		valueLen := int(binary.BigEndian.Uint32(props[1+nameLen:]))
+		props = props[1+nameLen+4:]
+		if len(props) < valueLen {
+			break
+		}
+		if name == "Socket-Type" {
+			return string(props[:valueLen]), nil
+		}
+		props = props[valueLen:]
+	}
+	return "", fmt.Errorf("%w: ZMTP READY command without socket type", ErrUnexpectedResponse)
+}
@@ -47,6 +47,14 @@ func FormatFloat(f float64) string {
 	return strconv.FormatFloat(f, 'f', -1, 64)
 }
 
+// Formats count along with its percentage of total, eg. "12 (34.5%)"
+func FormatCountPercent(count, total int) string {
+	if total == 0 {
+		return strconv.Itoa(count)
+	}
+	return fmt.Sprintf("%d (%.1f%%)", count, float64(count)/float64(total)*100)
+}
+
 // Formats bytes as a human-readable string with the specified number of decimal places.
 func FormatBytes(bytes, decimals int) string {
 	if bytes == 0 {